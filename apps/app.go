@@ -1,6 +1,7 @@
 package apps
 
 import (
+	"crypto/subtle"
 	"unicode"
 
 	"github.com/mattermost/mattermost-server/v5/model"
@@ -60,6 +61,14 @@ type App struct {
 	// mmclient.StoreOAuth2App to update.
 	RemoteOAuth2 OAuth2App `json:"remote_oauth2,omitempty"`
 
+	// PreviousSecret, PreviousWebhookSecret and PreviousBotAccessToken are
+	// the values replaced by the last rotation of each secret, see `/apps
+	// rotate-secret`. They remain valid until they expire, so that the app
+	// has time to switch to the new values.
+	PreviousSecret         RotatedSecret `json:"previous_secret,omitempty"`
+	PreviousWebhookSecret  RotatedSecret `json:"previous_webhook_secret,omitempty"`
+	PreviousBotAccessToken RotatedSecret `json:"previous_bot_access_token,omitempty"`
+
 	// In V1, GrantedPermissions are simply copied from RequestedPermissions
 	// upon the sysadmin's consent, during installing the App.
 	GrantedPermissions Permissions `json:"granted_permissions,omitempty"`
@@ -72,6 +81,60 @@ type App struct {
 	GrantedLocations Locations `json:"granted_locations,omitempty"`
 }
 
+// SecretType is the type of an app's secret that can be rotated.
+type SecretType string
+
+const (
+	// SecretTypeJWT is App.Secret, used to issue the JWTs sent to HTTP apps.
+	SecretTypeJWT SecretType = "jwt"
+	// SecretTypeWebhook is App.WebhookSecret.
+	SecretTypeWebhook SecretType = "webhook"
+	// SecretTypeBotToken is App.BotAccessToken.
+	SecretTypeBotToken SecretType = "bot-token"
+)
+
+// RotatedSecret is a secret that was replaced with a new one. It remains
+// valid until ExpiresAt (in milliseconds).
+type RotatedSecret struct {
+	Secret string `json:"secret,omitempty"`
+	// ID is the ID of the secret, if it has one, e.g. of an access token.
+	ID        string `json:"id,omitempty"`
+	ExpiresAt int64  `json:"expires_at,omitempty"`
+	// InUse is set while the previous JWT secret is still used to issue the
+	// JWTs, until the app receives the new secret in its OnSecretRotated call.
+	InUse bool `json:"in_use,omitempty"`
+}
+
+// IsValidAt returns true if the secret is set, and has not expired at now (in
+// milliseconds).
+func (s RotatedSecret) IsValidAt(now int64) bool {
+	return s.Secret != "" && now < s.ExpiresAt
+}
+
+// JWTSecret returns the secret to issue the JWTs with. After App.Secret is
+// rotated, the previous secret is used until the app has been notified of the
+// new one, or, if it could not be notified, until the previous secret
+// expires, so that the app keeps receiving the JWTs it can validate.
+func (a *App) JWTSecret() string {
+	if a.PreviousSecret.InUse && a.PreviousSecret.IsValidAt(model.GetMillis()) {
+		return a.PreviousSecret.Secret
+	}
+	return a.Secret
+}
+
+// IsWebhookSecret returns true if secret is App.WebhookSecret, or the previous
+// webhook secret that has not expired yet.
+func (a *App) IsWebhookSecret(secret string) bool {
+	if secret == "" {
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(a.WebhookSecret)) == 1 {
+		return true
+	}
+	return a.PreviousWebhookSecret.IsValidAt(model.GetMillis()) &&
+		subtle.ConstantTimeCompare([]byte(secret), []byte(a.PreviousWebhookSecret.Secret)) == 1
+}
+
 // OAuth2App contains the setored settings for an "OAuth2 app" used by the App.
 // It is used to describe the OAuth2 connections both to Mattermost, and
 // optionally to a 3rd party remote system.
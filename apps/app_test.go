@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-server/v5/model"
 )
 
 func TestAppIDIsValid(t *testing.T) {
@@ -61,3 +63,34 @@ func TestAppVersionIsValid(t *testing.T) {
 		})
 	}
 }
+
+func TestAppRotatedSecrets(t *testing.T) {
+	t.Parallel()
+
+	now := model.GetMillis()
+	app := &App{
+		Secret:        "new",
+		WebhookSecret: "new-webhook",
+	}
+	assert.Equal(t, "new", app.JWTSecret())
+	assert.True(t, app.IsWebhookSecret("new-webhook"))
+	assert.False(t, app.IsWebhookSecret("old-webhook"))
+	assert.False(t, app.IsWebhookSecret(""))
+
+	app.PreviousSecret = RotatedSecret{Secret: "old", ExpiresAt: now + 60000, InUse: true}
+	app.PreviousWebhookSecret = RotatedSecret{Secret: "old-webhook", ExpiresAt: now + 60000}
+	assert.Equal(t, "old", app.JWTSecret())
+	assert.True(t, app.IsWebhookSecret("new-webhook"))
+	assert.True(t, app.IsWebhookSecret("old-webhook"))
+
+	// The app received the new secret.
+	app.PreviousSecret.InUse = false
+	assert.Equal(t, "new", app.JWTSecret())
+	app.PreviousSecret.InUse = true
+
+	app.PreviousSecret.ExpiresAt = now - 1
+	app.PreviousWebhookSecret.ExpiresAt = now - 1
+	assert.Equal(t, "new", app.JWTSecret())
+	assert.True(t, app.IsWebhookSecret("new-webhook"))
+	assert.False(t, app.IsWebhookSecret("old-webhook"))
+}
@@ -50,10 +50,15 @@ package apps
 //  call or form - either embed a form, or provide a call to fetch it.
 //
 // Bindings are currently refreshed when a user visits a channel, in the context
-// of the current channel, from all the registered Apps. A server-side cache
-// implementation is in the works,
-// https://mattermost.atlassian.net/browse/MM-30472. This allows each App to
-// dynamically add things to the UI on a per-channel basis.
+// of the current channel, from all the registered Apps. This allows each App to
+// dynamically add things to the UI on a per-channel basis. The bindings are
+// cached on the server, per App and per user. The DependsOnTeam,
+// DependsOnChannel, and DependsOnPost flags of the returned bindings determine
+// whether the cached bindings are reused across teams, channels, and posts; if
+// an App sets none of the DependsOn flags, its bindings are cached per team,
+// channel, and post. The cache is invalidated when the App is installed, enabled, or disabled, when a
+// user completes the remote OAuth2 flow, and when the App requests it with
+// mmclient.RefreshBindings.
 //
 // Example bindings (hello world app) create a button in the channel header, and
 // a "/helloworld send" command:
@@ -186,7 +191,8 @@ type Binding struct {
 	RoleID string `json:"role_id,omitempty"`
 
 	// DependsOnTeam, etc. specifies the scope of the binding and how it can be
-	// shared across various user sessions.
+	// shared across various user sessions. Bindings are always cached per
+	// user, DependsOnUser is reserved for future use.
 	DependsOnTeam    bool `json:"depends_on_team,omitempty"`
 	DependsOnChannel bool `json:"depends_on_channel,omitempty"`
 	DependsOnUser    bool `json:"depends_on_user,omitempty"`
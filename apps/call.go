@@ -60,6 +60,11 @@ type CallRequest struct {
 	// command, as submitted by the user.
 	RawCommand string `json:"raw_command,omitempty"`
 
+	// Form is the definition of the form that the values were entered in, if
+	// any. The proxy validates the values of submit calls against it, and does
+	// not pass it on to the app.
+	Form *Form `json:"form,omitempty"`
+
 	// SelectedField and Query are used in calls of type lookup, and calls type
 	// form used to refresh the form upon user entry, to communicate what field
 	// is selected, and what query string is already entered by the user for it.
@@ -83,8 +88,12 @@ const (
 	// modal.
 	CallResponseTypeForm CallResponseType = "form"
 
-	// CallResponseTypeCall indicates that another Call that should be executed
-	// (all the way from the user-agent). Call is returned. NOT YET IMPLEMENTED.
+	// CallResponseTypeCall indicates that another Call should be executed. Call
+	// is returned. The proxy follows the returned Call to the same App,
+	// re-expanding the Context as requested by the new Call's Expand, and
+	// returns the final response to the user-agent. The number of chained
+	// calls is limited, and a Call with the same Path and State may not be
+	// repeated within a chain.
 	CallResponseTypeCall CallResponseType = "call"
 
 	// CallResponseTypeNavigate indicates that the user should be forcefully
@@ -98,8 +107,8 @@ const (
 // Submit requests expect ok, error, form, call, or navigate response types.
 // Returning a "form" type in response to a submission from the user-agent
 // triggers displaying a Modal. Returning a "call" type in response to a
-// submission causes the call to be executed from the user-agent (NOT
-// IMPLEMENTED YET)
+// submission causes the proxy to execute the returned call, and respond with
+// its result.
 //
 // Form requests expect form or error.
 //
@@ -155,6 +164,18 @@ func NewErrorCallResponse(err error) *CallResponse {
 	}
 }
 
+// NewFieldErrorsCallResponse returns an error response with field-level
+// errors, keyed by field name, in Data.
+func NewFieldErrorsCallResponse(errorText string, fieldErrors map[string]string) *CallResponse {
+	return &CallResponse{
+		Type:      CallResponseTypeError,
+		ErrorText: errorText,
+		Data: map[string]interface{}{
+			"errors": fieldErrors,
+		},
+	}
+}
+
 // Error() makes CallResponse a valid error, for convenience
 func (cr *CallResponse) Error() string {
 	if cr.Type == CallResponseTypeError {
@@ -79,6 +79,10 @@ type ExpandedContext struct {
 	RootPost              *model.Post    `json:"root_post,omitempty"`
 	Team                  *model.Team    `json:"team,omitempty"`
 
+	// Reaction is included in reaction_added and reaction_removed
+	// notifications, it can not be expanded.
+	Reaction *model.Reaction `json:"reaction,omitempty"`
+
 	// TODO replace User with mentions
 	User *model.User `json:"user,omitempty"`
 }
@@ -12,6 +12,10 @@ const (
 	ExpandSummary ExpandLevel = "summary"
 )
 
+// MaxMentionedUsers is the maximum number of users included in
+// ExpandedContext.Mentioned.
+const MaxMentionedUsers = 20
+
 // Expand is a clause in the Call struct that controls what additional
 // information is to be provided in each request made.
 //
@@ -55,7 +59,9 @@ type Expand struct {
 	// DisplayName, Name
 	Channel ExpandLevel `json:"channel,omitempty"`
 
-	// Not currently implemented
+	// Mentioned: the users @-mentioned in the post, or in the root post if
+	// there is no post in the context. all for model.User, summary as for
+	// User. At most MaxMentionedUsers are included, in the order they appear.
 	Mentioned ExpandLevel `json:"mentioned,omitempty"`
 
 	// Post, RootPost: all for model.Post, summary for Id, Type, UserId,
@@ -1,5 +1,14 @@
 package apps
 
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
 type FieldType string
 type TextFieldSubtype string
 
@@ -93,3 +102,143 @@ type Field struct {
 	TextMinLength int              `json:"min_length,omitempty"`
 	TextMaxLength int              `json:"max_length,omitempty"`
 }
+
+// validateValue checks a submitted value against the field's definition. It
+// returns a user-facing error message, or "" if the value is valid. A nil
+// value means that the field was not submitted.
+func (f *Field) validateValue(v interface{}) string {
+	if isEmptyValue(v) {
+		if f.IsRequired {
+			return "this field is required"
+		}
+		return ""
+	}
+
+	switch f.Type {
+	case FieldTypeText:
+		s, ok := v.(string)
+		if !ok {
+			return "must be text"
+		}
+		return f.validateText(s)
+
+	case FieldTypeBool:
+		switch b := v.(type) {
+		case bool:
+			return ""
+		case string:
+			if _, err := strconv.ParseBool(b); err == nil {
+				return ""
+			}
+		}
+		return "must be true or false"
+
+	case FieldTypeStaticSelect:
+		selected, ok := selectedOptionValues(v, f.SelectIsMulti)
+		if !ok {
+			return "must be one of the options"
+		}
+		for _, s := range selected {
+			if !f.hasStaticOption(s) {
+				return fmt.Sprintf("%q is not one of the options", s)
+			}
+		}
+		return ""
+
+	case FieldTypeDynamicSelect, FieldTypeUser, FieldTypeChannel:
+		if _, ok := selectedOptionValues(v, f.SelectIsMulti); !ok {
+			return "must be a selected option"
+		}
+		return ""
+	}
+	return ""
+}
+
+func (f *Field) validateText(s string) string {
+	length := utf8.RuneCountInString(s)
+	if f.TextMinLength > 0 && length < f.TextMinLength {
+		return fmt.Sprintf("must be at least %v characters long", f.TextMinLength)
+	}
+	if f.TextMaxLength > 0 && length > f.TextMaxLength {
+		return fmt.Sprintf("must be at most %v characters long", f.TextMaxLength)
+	}
+
+	switch f.TextSubtype {
+	case TextFieldSubtypeNumber:
+		if _, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err != nil {
+			return "must be a number"
+		}
+	case TextFieldSubtypeEmail:
+		addr, err := mail.ParseAddress(s)
+		if err != nil || addr.Address != s {
+			return "must be an email address"
+		}
+	case TextFieldSubtypeURL:
+		u, err := url.Parse(s)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be a URL"
+		}
+	}
+	return ""
+}
+
+func (f *Field) hasStaticOption(value string) bool {
+	for _, o := range f.SelectStaticOptions {
+		if o.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// selectedOptionValues returns the values of the options selected in a select
+// field. Options are submitted as {"label":..., "value":...} objects, or as
+// plain values from the command line; multiselect fields submit a list.
+func selectedOptionValues(v interface{}, isMulti bool) ([]string, bool) {
+	if list, ok := v.([]interface{}); ok {
+		if !isMulti {
+			return nil, false
+		}
+		out := []string{}
+		for _, item := range list {
+			s, ok := selectedOptionValue(item)
+			if !ok {
+				return nil, false
+			}
+			out = append(out, s)
+		}
+		return out, true
+	}
+
+	s, ok := selectedOptionValue(v)
+	if !ok {
+		return nil, false
+	}
+	return []string{s}, true
+}
+
+func selectedOptionValue(v interface{}) (string, bool) {
+	switch option := v.(type) {
+	case string:
+		return option, true
+	case map[string]interface{}:
+		s, ok := option["value"].(string)
+		return s, ok
+	}
+	return "", false
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return true
+	case string:
+		return value == ""
+	case []interface{}:
+		return len(value) == 0
+	case map[string]interface{}:
+		s, _ := value["value"].(string)
+		return s == ""
+	}
+	return false
+}
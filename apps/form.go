@@ -50,3 +50,23 @@ type Form struct {
 	// Fields is the list of fields in the form.
 	Fields []*Field `json:"fields,omitempty"`
 }
+
+// ValidateValues checks the values submitted with the form against the
+// definitions of its fields. It returns the field-level errors, keyed by field
+// name, or nil if all values are valid. Values for the fields that are not in
+// the form are ignored.
+func (f *Form) ValidateValues(values map[string]interface{}) map[string]string {
+	var fieldErrors map[string]string
+	for _, field := range f.Fields {
+		if field == nil || field.Type == FieldTypeMarkdown || field.ReadOnly {
+			continue
+		}
+		if message := field.validateValue(values[field.Name]); message != "" {
+			if fieldErrors == nil {
+				fieldErrors = map[string]string{}
+			}
+			fieldErrors[field.Name] = message
+		}
+	}
+	return fieldErrors
+}
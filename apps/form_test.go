@@ -0,0 +1,156 @@
+// +build !e2e
+
+package apps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormValidateValues(t *testing.T) {
+	options := []SelectOption{
+		{Label: "Red", Value: "red"},
+		{Label: "Blue", Value: "blue"},
+	}
+
+	for name, tc := range map[string]struct {
+		field    Field
+		value    interface{}
+		expected string
+	}{
+		"required missing": {
+			field:    Field{Type: FieldTypeText, IsRequired: true},
+			expected: "this field is required",
+		},
+		"required empty option": {
+			field:    Field{Type: FieldTypeStaticSelect, IsRequired: true, SelectStaticOptions: options},
+			value:    map[string]interface{}{"label": "", "value": ""},
+			expected: "this field is required",
+		},
+		"optional missing": {
+			field: Field{Type: FieldTypeText, TextMinLength: 3},
+		},
+		"text too short": {
+			field:    Field{Type: FieldTypeText, TextMinLength: 3},
+			value:    "ab",
+			expected: "must be at least 3 characters long",
+		},
+		"text too long": {
+			field:    Field{Type: FieldTypeText, TextMaxLength: 3},
+			value:    "abcd",
+			expected: "must be at most 3 characters long",
+		},
+		"text length in characters": {
+			field: Field{Type: FieldTypeText, TextMaxLength: 3},
+			value: "äöü",
+		},
+		"text not a string": {
+			field:    Field{Type: FieldTypeText},
+			value:    12.0,
+			expected: "must be text",
+		},
+		"number": {
+			field: Field{Type: FieldTypeText, TextSubtype: TextFieldSubtypeNumber},
+			value: "-1.5",
+		},
+		"not a number": {
+			field:    Field{Type: FieldTypeText, TextSubtype: TextFieldSubtypeNumber},
+			value:    "one",
+			expected: "must be a number",
+		},
+		"email": {
+			field: Field{Type: FieldTypeText, TextSubtype: TextFieldSubtypeEmail},
+			value: "test@example.com",
+		},
+		"email with a name": {
+			field:    Field{Type: FieldTypeText, TextSubtype: TextFieldSubtypeEmail},
+			value:    "Test <test@example.com>",
+			expected: "must be an email address",
+		},
+		"url": {
+			field: Field{Type: FieldTypeText, TextSubtype: TextFieldSubtypeURL},
+			value: "https://example.com/path",
+		},
+		"not a url": {
+			field:    Field{Type: FieldTypeText, TextSubtype: TextFieldSubtypeURL},
+			value:    "example.com",
+			expected: "must be a URL",
+		},
+		"bool": {
+			field: Field{Type: FieldTypeBool},
+			value: false,
+		},
+		"bool from the command line": {
+			field: Field{Type: FieldTypeBool},
+			value: "true",
+		},
+		"not a bool": {
+			field:    Field{Type: FieldTypeBool},
+			value:    "yes please",
+			expected: "must be true or false",
+		},
+		"static option": {
+			field: Field{Type: FieldTypeStaticSelect, SelectStaticOptions: options},
+			value: map[string]interface{}{"label": "Red", "value": "red"},
+		},
+		"static option from the command line": {
+			field: Field{Type: FieldTypeStaticSelect, SelectStaticOptions: options},
+			value: "blue",
+		},
+		"unknown static option": {
+			field:    Field{Type: FieldTypeStaticSelect, SelectStaticOptions: options},
+			value:    map[string]interface{}{"label": "Green", "value": "green"},
+			expected: `"green" is not one of the options`,
+		},
+		"multiselect": {
+			field: Field{Type: FieldTypeStaticSelect, SelectIsMulti: true, SelectStaticOptions: options},
+			value: []interface{}{
+				map[string]interface{}{"label": "Red", "value": "red"},
+				map[string]interface{}{"label": "Blue", "value": "blue"},
+			},
+		},
+		"list for a single select": {
+			field:    Field{Type: FieldTypeStaticSelect, SelectStaticOptions: options},
+			value:    []interface{}{"red"},
+			expected: "must be one of the options",
+		},
+		"dynamic option": {
+			field: Field{Type: FieldTypeDynamicSelect},
+			value: map[string]interface{}{"label": "Anything", "value": "anything"},
+		},
+		"invalid user": {
+			field:    Field{Type: FieldTypeUser},
+			value:    true,
+			expected: "must be a selected option",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tc.field.Name = "f"
+			form := &Form{
+				Fields: []*Field{&tc.field},
+			}
+			values := map[string]interface{}{}
+			if tc.value != nil {
+				values["f"] = tc.value
+			}
+
+			fieldErrors := form.ValidateValues(values)
+			if tc.expected == "" {
+				require.Nil(t, fieldErrors)
+			} else {
+				require.Equal(t, map[string]string{"f": tc.expected}, fieldErrors)
+			}
+		})
+	}
+
+	t.Run("skips markdown and readonly fields", func(t *testing.T) {
+		form := &Form{
+			Fields: []*Field{
+				{Name: "md", Type: FieldTypeMarkdown, IsRequired: true},
+				{Name: "ro", Type: FieldTypeText, ReadOnly: true, IsRequired: true},
+			},
+		}
+		require.Nil(t, form.ValidateValues(nil))
+	})
+}
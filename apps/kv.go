@@ -0,0 +1,66 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package apps
+
+import (
+	"encoding/json"
+
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+// MaxKVBatchSize is the maximum number of values in a KV batch get or set,
+// MaxKVListPerPage - of IDs in a page of a KV list.
+const (
+	MaxKVBatchSize   = 100
+	MaxKVListPerPage = 1000
+)
+
+// KVBatchGetRequest is submitted by an app to get several values of a KV
+// namespace.
+type KVBatchGetRequest struct {
+	Prefix string   `json:"prefix,omitempty"`
+	IDs    []string `json:"ids"`
+}
+
+// KVBatchSetRequest is submitted by an app to set several values of a KV
+// namespace. The values are set one by one, the batch is not atomic.
+type KVBatchSetRequest struct {
+	Prefix string                     `json:"prefix,omitempty"`
+	Values map[string]json.RawMessage `json:"values"`
+}
+
+// KVListResponse is a page of the IDs that an app set in a KV namespace,
+// sorted. The next page follows after the last ID.
+type KVListResponse struct {
+	IDs []string `json:"ids"`
+
+	// Incomplete is set if some of the app's IDs may not be listed, e.g. the
+	// ones set before the app's keys were indexed.
+	Incomplete bool `json:"incomplete,omitempty"`
+}
+
+// KVSetOptions make a KV set conditional, or make the value expire.
+type KVSetOptions struct {
+	// ExpireInSeconds, if set, makes the value expire (and be deleted) after
+	// the given number of seconds.
+	ExpireInSeconds int64 `json:"expire_in_seconds,omitempty"`
+
+	// IfAbsent sets the value only if the key is not set.
+	IfAbsent bool `json:"if_absent,omitempty"`
+
+	// OldValue, if set, makes the set a compare-and-set: the value is set
+	// only if the current value is OldValue. The values are compared as
+	// stored, i.e. JSON-encoded.
+	OldValue interface{} `json:"old_value,omitempty"`
+}
+
+func (o KVSetOptions) IsValid() error {
+	if o.ExpireInSeconds < 0 {
+		return utils.NewInvalidError("expire_in_seconds must not be negative")
+	}
+	if o.IfAbsent && o.OldValue != nil {
+		return utils.NewInvalidError("only one of if_absent and old_value may be set")
+	}
+	return nil
+}
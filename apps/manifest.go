@@ -57,6 +57,18 @@ type Manifest struct {
 	// explicitly provided in the manifest.
 	OnUninstall *Call `json:"on_uninstall,omitempty"`
 
+	// OnSecretRotated gets invoked when a sysadmin rotates one of the app's
+	// secrets with the `/apps rotate-secret` command. The call's values
+	// contain "secret_type" (jwt, webhook or bot-token), the new "secret",
+	// and "previous_expires_at" in milliseconds, until when the previous
+	// secret remains valid. The call itself is issued with the previous JWT
+	// secret; once it succeeds, the JWTs are issued with the new one. If it
+	// fails, the previous JWT secret is used until it expires. The call is made
+	// once the new secret is saved; without a grace period, the previous
+	// secret is invalidated after the call. It is not called unless explicitly
+	// provided in the manifest.
+	OnSecretRotated *Call `json:"on_secret_rotated,omitempty"`
+
 	// OnEnable, OnDisable are not yet supported
 	OnDisable *Call `json:"on_disable,omitempty"`
 	OnEnable  *Call `json:"on_enable,omitempty"`
@@ -76,6 +88,10 @@ type Manifest struct {
 	// mmclient.StoreOAuth2User.
 	OnOAuth2Complete *Call `json:"on_oauth2_complete,omitempty"`
 
+	// Schedules are the recurring calls that the proxy makes to the app, see
+	// Schedule for details.
+	Schedules []Schedule `json:"schedules,omitempty"`
+
 	// Requested Access
 
 	RequestedPermissions Permissions `json:"requested_permissions,omitempty"`
@@ -186,6 +202,18 @@ func (m Manifest) IsValid() error {
 		}
 	}
 
+	names := map[string]bool{}
+	for _, s := range m.Schedules {
+		err := s.IsValid()
+		if err != nil {
+			return errors.Wrapf(err, "schedule %q is not valid", s.Name)
+		}
+		if names[s.Name] {
+			return utils.NewInvalidError("duplicate schedule name %q", s.Name)
+		}
+		names[s.Name] = true
+	}
+
 	switch m.AppType {
 	case AppTypeHTTP:
 		if m.HTTPRootURL == "" {
@@ -51,6 +51,44 @@ func TestManifestIsValid(t *testing.T) {
 			},
 			ExpectedError: false,
 		},
+		"valid schedules": {
+			Manifest: apps.Manifest{
+				AppID:       "abc",
+				AppType:     apps.AppTypeHTTP,
+				HomepageURL: "https://example.org",
+				HTTPRootURL: "https://example.org/root",
+				Schedules: []apps.Schedule{
+					{Name: "standup", Cron: "0 9 * * mon-fri", Call: apps.NewCall("/standup")},
+					{Name: "sync", Cron: "@hourly", MissedRuns: apps.MissedRunsSkip, Call: apps.NewCall("/sync")},
+				},
+			},
+			ExpectedError: false,
+		},
+		"invalid schedule cron": {
+			Manifest: apps.Manifest{
+				AppID:       "abc",
+				AppType:     apps.AppTypeHTTP,
+				HomepageURL: "https://example.org",
+				HTTPRootURL: "https://example.org/root",
+				Schedules: []apps.Schedule{
+					{Name: "standup", Cron: "0 9 * *", Call: apps.NewCall("/standup")},
+				},
+			},
+			ExpectedError: true,
+		},
+		"duplicate schedule name": {
+			Manifest: apps.Manifest{
+				AppID:       "abc",
+				AppType:     apps.AppTypeHTTP,
+				HomepageURL: "https://example.org",
+				HTTPRootURL: "https://example.org/root",
+				Schedules: []apps.Schedule{
+					{Name: "sync", Cron: "@hourly", Call: apps.NewCall("/sync")},
+					{Name: "sync", Cron: "@daily", Call: apps.NewCall("/sync")},
+				},
+			},
+			ExpectedError: true,
+		},
 		"invalid Icon": {
 			Manifest: apps.Manifest{
 				AppID:       "abc",
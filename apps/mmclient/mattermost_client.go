@@ -1,8 +1,10 @@
 package mmclient
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/pkg/errors"
@@ -57,6 +59,20 @@ func (c *Client) KVSet(id string, prefix string, in interface{}) (interface{}, e
 	return mapRes, nil
 }
 
+// KVSetWithOptions sets a value with an expiry, or only if the conditions in
+// opts are met, e.g. to implement locks or counters. It returns false if the
+// value was not set.
+func (c *Client) KVSetWithOptions(id string, prefix string, in interface{}, opts apps.KVSetOptions) (bool, error) {
+	changed, res := c.ClientPP.KVSetWithOptions(id, prefix, in, opts)
+	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusOK {
+		if res.Error != nil {
+			return false, res.Error
+		}
+		return false, fmt.Errorf("returned with status %d", res.StatusCode)
+	}
+	return changed, nil
+}
+
 func (c *Client) KVGet(id string, prefix string, ref interface{}) error {
 	res := c.ClientPP.KVGet(id, prefix, ref)
 	if res.StatusCode != http.StatusOK {
@@ -68,6 +84,45 @@ func (c *Client) KVGet(id string, prefix string, ref interface{}) error {
 	return nil
 }
 
+// KVList returns up to perPage of the IDs set by the app in a namespace that
+// follow after, sorted.
+func (c *Client) KVList(prefix, after string, perPage int) (*apps.KVListResponse, error) {
+	list, res := c.ClientPP.KVList(prefix, after, perPage)
+	if res.StatusCode != http.StatusOK {
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		return nil, fmt.Errorf("returned with status %d", res.StatusCode)
+	}
+	return list, nil
+}
+
+// KVGetBatch returns the raw (JSON) values of the IDs that are set, up to
+// apps.MaxKVBatchSize at a time.
+func (c *Client) KVGetBatch(prefix string, ids []string) (map[string]json.RawMessage, error) {
+	values, res := c.ClientPP.KVGetBatch(prefix, ids)
+	if res.StatusCode != http.StatusOK {
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		return nil, fmt.Errorf("returned with status %d", res.StatusCode)
+	}
+	return values, nil
+}
+
+// KVSetBatch sets up to apps.MaxKVBatchSize values. The values are set one
+// by one, the batch is not atomic.
+func (c *Client) KVSetBatch(prefix string, values map[string]interface{}) error {
+	res := c.ClientPP.KVSetBatch(prefix, values)
+	if res.StatusCode != http.StatusOK {
+		if res.Error != nil {
+			return res.Error
+		}
+		return fmt.Errorf("returned with status %d", res.StatusCode)
+	}
+	return nil
+}
+
 func (c *Client) KVDelete(id string, prefix string) (bool, error) {
 	var opRes bool
 	var res *model.Response
@@ -82,6 +137,53 @@ func (c *Client) KVDelete(id string, prefix string) (bool, error) {
 	return opRes, nil
 }
 
+// UserKVSet sets a value for a user, JSON-encoded. The client must use the
+// app's bot token.
+func (c *Client) UserKVSet(userID, id string, in interface{}) error {
+	res := c.ClientPP.UserKVSet(userID, id, in)
+	if res.StatusCode != http.StatusOK {
+		if res.Error != nil {
+			return res.Error
+		}
+		return fmt.Errorf("returned with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+func (c *Client) UserKVGet(userID, id string, ref interface{}) error {
+	res := c.ClientPP.UserKVGet(userID, id, ref)
+	if res.StatusCode != http.StatusOK {
+		if res.Error != nil {
+			return res.Error
+		}
+		return fmt.Errorf("returned with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+func (c *Client) UserKVDelete(userID, id string) error {
+	res := c.ClientPP.UserKVDelete(userID, id)
+	if res.StatusCode != http.StatusOK {
+		if res.Error != nil {
+			return res.Error
+		}
+		return fmt.Errorf("returned with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// UserKVList returns the IDs that the app set for a user, sorted.
+func (c *Client) UserKVList(userID string) ([]string, error) {
+	ids, res := c.ClientPP.UserKVList(userID)
+	if res.StatusCode != http.StatusOK {
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		return nil, fmt.Errorf("returned with status %d", res.StatusCode)
+	}
+	return ids, nil
+}
+
 func (c *Client) Subscribe(sub *apps.Subscription) (*apps.SubscriptionResponse, error) {
 	var subResponse *apps.SubscriptionResponse
 	var res *model.Response
@@ -112,6 +214,82 @@ func (c *Client) Unsubscribe(sub *apps.Subscription) (*apps.SubscriptionResponse
 	return subResponse, nil
 }
 
+// GetSubscriptions returns the subscriptions of the app. It must be called
+// with the app's bot credentials.
+func (c *Client) GetSubscriptions() ([]*apps.Subscription, error) {
+	subs, res := c.ClientPP.GetSubscriptions()
+	if res.StatusCode != http.StatusOK {
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		return nil, fmt.Errorf("returned with status %d", res.StatusCode)
+	}
+	return subs, nil
+}
+
+// ScheduleCall schedules a one-time call to the app, to be made with the
+// app's bot as the acting user, either at runAt, or after delay if runAt is
+// zero. It must be called with the app's bot credentials.
+func (c *Client) ScheduleCall(call apps.Call, runAt time.Time, delay time.Duration) (*apps.ScheduledCall, error) {
+	req := &apps.ScheduleCallRequest{
+		Call: call,
+	}
+	if runAt.IsZero() {
+		req.DelaySeconds = int64(delay / time.Second)
+	} else {
+		req.RunAt = model.GetMillisForTime(runAt)
+	}
+
+	sc, res := c.ClientPP.ScheduleCall(req)
+	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusOK {
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		return nil, fmt.Errorf("returned with status %d", res.StatusCode)
+	}
+	return sc, nil
+}
+
+// GetScheduledCalls returns the app's pending one-time calls. It must be
+// called with the app's bot credentials.
+func (c *Client) GetScheduledCalls() ([]*apps.ScheduledCall, error) {
+	calls, res := c.ClientPP.GetScheduledCalls()
+	if res.StatusCode != http.StatusOK {
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		return nil, fmt.Errorf("returned with status %d", res.StatusCode)
+	}
+	return calls, nil
+}
+
+// CancelScheduledCall deletes one of the app's pending one-time calls. It must
+// be called with the app's bot credentials.
+func (c *Client) CancelScheduledCall(id string) error {
+	res := c.ClientPP.CancelScheduledCall(id)
+	if res.StatusCode != http.StatusOK {
+		if res.Error != nil {
+			return res.Error
+		}
+		return fmt.Errorf("returned with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// RefreshBindings invalidates the cached bindings of the app, and makes the
+// user-agents fetch them again. It must be called with the app's bot
+// credentials. If userID is empty, the bindings are refreshed for all users.
+func (c *Client) RefreshBindings(userID string) error {
+	res := c.ClientPP.RefreshBindings(userID)
+	if res.StatusCode != http.StatusOK {
+		if res.Error != nil {
+			return res.Error
+		}
+		return fmt.Errorf("returned with status %d", res.StatusCode)
+	}
+	return nil
+}
+
 func (c *Client) StoreOAuth2App(appID apps.AppID, clientID, clientSecret string) error {
 	res := c.ClientPP.StoreOAuth2App(appID, clientID, clientSecret)
 	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusOK {
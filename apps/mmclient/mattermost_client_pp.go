@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/url"
 	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/pkg/errors"
@@ -28,15 +31,38 @@ const (
 	PathAPI = "/api/v1"
 
 	// Other sub-paths.
-	PathKV          = "/kv"
-	PathSubscribe   = "/subscribe"
-	PathUnsubscribe = "/unsubscribe"
+	PathKV            = "/kv"
+	PathKVBatchGet    = "/kv-batch/get"
+	PathKVBatchSet    = "/kv-batch/set"
+	PathUserKV        = "/user-kv"
+	PathSubscribe     = "/subscribe"
+	PathUnsubscribe   = "/unsubscribe"
+	PathSubscriptions = "/subscriptions"
+
+	PathScheduledCalls = "/scheduled-calls"
+)
+
+// Query parameters of the KV APIs.
+const (
+	QueryPrefix  = "prefix"
+	QueryAfter   = "after"
+	QueryPerPage = "per_page"
+
+	QueryExpireInSeconds = "expire_in_seconds"
+	QueryIfAbsent        = "if_absent"
+	QueryOldValue        = "old_value"
 
 	PathApps      = "/apps"
 	PathApp       = "/app"
 	PathEnable    = "/enable"
 	PathDisable   = "/disable"
 	PathUninstall = "/uninstall"
+	PathBackup    = "/backup"
+	PathRestore   = "/restore"
+
+	PathRotateSecret = "/rotate-secret"
+
+	PathRefreshBindings = "/refresh-bindings"
 
 	PathBotIDs      = "/bot-ids"
 	PathOAuthAppIDs = "/oauth-app-ids"
@@ -87,6 +113,40 @@ func (c *ClientPP) KVSet(id string, prefix string, in interface{}) (interface{},
 	return interfaceFromJSON(r.Body), model.BuildResponse(r)
 }
 
+// KVSetWithOptions sets a value with an expiry, or only if the conditions in
+// opts are met. It returns false if the value was not set.
+func (c *ClientPP) KVSetWithOptions(id string, prefix string, in interface{}, opts apps.KVSetOptions) (bool, *model.Response) {
+	query := url.Values{}
+	if opts.ExpireInSeconds > 0 {
+		query.Set(QueryExpireInSeconds, strconv.FormatInt(opts.ExpireInSeconds, 10))
+	}
+	if opts.IfAbsent {
+		query.Set(QueryIfAbsent, "true")
+	}
+	if opts.OldValue != nil {
+		query.Set(QueryOldValue, utils.ToJSON(opts.OldValue))
+	}
+	path := c.kvpath(prefix, id)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	r, appErr := c.DoAPIPOST(path, utils.ToJSON(in)) // nolint:bodyclose
+	if appErr != nil {
+		return false, model.BuildErrorResponse(r, appErr)
+	}
+	defer c.closeBody(r)
+
+	var out struct {
+		Changed bool `json:"changed"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&out)
+	if err != nil {
+		return false, model.BuildErrorResponse(r, model.NewAppError("KVSetWithOptions", "", nil, err.Error(), http.StatusInternalServerError))
+	}
+	return out.Changed, model.BuildResponse(r)
+}
+
 func (c *ClientPP) KVGet(id string, prefix string, ref interface{}) *model.Response {
 	r, appErr := c.DoAPIGET(c.kvpath(prefix, id), "") // nolint:bodyclose
 	if appErr != nil {
@@ -101,6 +161,66 @@ func (c *ClientPP) KVGet(id string, prefix string, ref interface{}) *model.Respo
 	return model.BuildResponse(r)
 }
 
+// KVList returns up to perPage of the IDs set by the app in a namespace that
+// follow after, sorted. To list all IDs, start with an empty after, and pass
+// the last ID of each page to get the next one.
+func (c *ClientPP) KVList(prefix, after string, perPage int) (*apps.KVListResponse, *model.Response) {
+	query := url.Values{}
+	query.Set(QueryPrefix, prefix)
+	query.Set(QueryAfter, after)
+	query.Set(QueryPerPage, strconv.Itoa(perPage))
+	r, appErr := c.DoAPIGET(c.apipath(PathKV)+"?"+query.Encode(), "") // nolint:bodyclose
+	if appErr != nil {
+		return nil, model.BuildErrorResponse(r, appErr)
+	}
+	defer c.closeBody(r)
+
+	list := &apps.KVListResponse{}
+	err := json.NewDecoder(r.Body).Decode(list)
+	if err != nil {
+		return nil, model.BuildErrorResponse(r, model.NewAppError("KVList", "", nil, err.Error(), http.StatusInternalServerError))
+	}
+	return list, model.BuildResponse(r)
+}
+
+// KVGetBatch returns the raw (JSON) values of the IDs that are set.
+func (c *ClientPP) KVGetBatch(prefix string, ids []string) (map[string]json.RawMessage, *model.Response) {
+	data := utils.ToJSON(apps.KVBatchGetRequest{
+		Prefix: prefix,
+		IDs:    ids,
+	})
+	r, appErr := c.DoAPIPOST(c.apipath(PathKVBatchGet), data) // nolint:bodyclose
+	if appErr != nil {
+		return nil, model.BuildErrorResponse(r, appErr)
+	}
+	defer c.closeBody(r)
+
+	values := map[string]json.RawMessage{}
+	err := json.NewDecoder(r.Body).Decode(&values)
+	if err != nil {
+		return nil, model.BuildErrorResponse(r, model.NewAppError("KVGetBatch", "", nil, err.Error(), http.StatusInternalServerError))
+	}
+	return values, model.BuildResponse(r)
+}
+
+// KVSetBatch sets several values, JSON-encoded. The values are set one by
+// one, the batch is not atomic.
+func (c *ClientPP) KVSetBatch(prefix string, values map[string]interface{}) *model.Response {
+	req := apps.KVBatchSetRequest{
+		Prefix: prefix,
+		Values: map[string]json.RawMessage{},
+	}
+	for id, v := range values {
+		req.Values[id] = json.RawMessage(utils.ToJSON(v))
+	}
+	r, appErr := c.DoAPIPOST(c.apipath(PathKVBatchSet), utils.ToJSON(req)) // nolint:bodyclose
+	if appErr != nil {
+		return model.BuildErrorResponse(r, appErr)
+	}
+	defer c.closeBody(r)
+	return model.BuildResponse(r)
+}
+
 func (c *ClientPP) KVDelete(id string, prefix string) (bool, *model.Response) {
 	r, appErr := c.DoAPIDELETE(c.kvpath(prefix, id)) // nolint:bodyclose
 	if appErr != nil {
@@ -110,6 +230,56 @@ func (c *ClientPP) KVDelete(id string, prefix string) (bool, *model.Response) {
 	return model.CheckStatusOK(r), model.BuildResponse(r)
 }
 
+// UserKVSet sets a value for a user, JSON-encoded. It must be called with the
+// app's bot token.
+func (c *ClientPP) UserKVSet(userID, id string, in interface{}) *model.Response {
+	r, appErr := c.DoAPIPOST(c.userKVPath(userID, id), utils.ToJSON(in)) // nolint:bodyclose
+	if appErr != nil {
+		return model.BuildErrorResponse(r, appErr)
+	}
+	defer c.closeBody(r)
+	return model.BuildResponse(r)
+}
+
+func (c *ClientPP) UserKVGet(userID, id string, ref interface{}) *model.Response {
+	r, appErr := c.DoAPIGET(c.userKVPath(userID, id), "") // nolint:bodyclose
+	if appErr != nil {
+		return model.BuildErrorResponse(r, appErr)
+	}
+	defer c.closeBody(r)
+
+	err := json.NewDecoder(r.Body).Decode(ref)
+	if err != nil {
+		return model.BuildErrorResponse(r, model.NewAppError("UserKVGet", "", nil, err.Error(), http.StatusInternalServerError))
+	}
+	return model.BuildResponse(r)
+}
+
+func (c *ClientPP) UserKVDelete(userID, id string) *model.Response {
+	r, appErr := c.DoAPIDELETE(c.userKVPath(userID, id)) // nolint:bodyclose
+	if appErr != nil {
+		return model.BuildErrorResponse(r, appErr)
+	}
+	defer c.closeBody(r)
+	return model.BuildResponse(r)
+}
+
+// UserKVList returns the IDs that the app set for a user, sorted.
+func (c *ClientPP) UserKVList(userID string) ([]string, *model.Response) {
+	r, appErr := c.DoAPIGET(c.userKVPath(userID, ""), "") // nolint:bodyclose
+	if appErr != nil {
+		return nil, model.BuildErrorResponse(r, appErr)
+	}
+	defer c.closeBody(r)
+
+	var ids []string
+	err := json.NewDecoder(r.Body).Decode(&ids)
+	if err != nil {
+		return nil, model.BuildErrorResponse(r, model.NewAppError("UserKVList", "", nil, err.Error(), http.StatusInternalServerError))
+	}
+	return ids, model.BuildResponse(r)
+}
+
 func (c *ClientPP) Subscribe(request *apps.Subscription) (*apps.SubscriptionResponse, *model.Response) {
 	r, appErr := c.DoAPIPOST(c.apipath(PathSubscribe), request.ToJSON()) // nolint:bodyclose
 	if appErr != nil {
@@ -132,6 +302,78 @@ func (c *ClientPP) Unsubscribe(request *apps.Subscription) (*apps.SubscriptionRe
 	return subResponse, model.BuildResponse(r)
 }
 
+func (c *ClientPP) GetSubscriptions() ([]*apps.Subscription, *model.Response) {
+	r, appErr := c.DoAPIGET(c.apipath(PathSubscriptions), "") // nolint:bodyclose
+	if appErr != nil {
+		return nil, model.BuildErrorResponse(r, appErr)
+	}
+	defer c.closeBody(r)
+
+	var subs []*apps.Subscription
+	err := json.NewDecoder(r.Body).Decode(&subs)
+	if err != nil {
+		return nil, model.BuildErrorResponse(r, model.NewAppError("GetSubscriptions", "", nil, err.Error(), http.StatusInternalServerError))
+	}
+	return subs, model.BuildResponse(r)
+}
+
+func (c *ClientPP) ScheduleCall(request *apps.ScheduleCallRequest) (*apps.ScheduledCall, *model.Response) {
+	r, appErr := c.DoAPIPOST(c.apipath(PathScheduledCalls), utils.ToJSON(request)) // nolint:bodyclose
+	if appErr != nil {
+		return nil, model.BuildErrorResponse(r, appErr)
+	}
+	defer c.closeBody(r)
+
+	var sc apps.ScheduledCall
+	err := json.NewDecoder(r.Body).Decode(&sc)
+	if err != nil {
+		return nil, model.BuildErrorResponse(r, model.NewAppError("ScheduleCall", "", nil, err.Error(), http.StatusInternalServerError))
+	}
+	return &sc, model.BuildResponse(r)
+}
+
+func (c *ClientPP) GetScheduledCalls() ([]*apps.ScheduledCall, *model.Response) {
+	r, appErr := c.DoAPIGET(c.apipath(PathScheduledCalls), "") // nolint:bodyclose
+	if appErr != nil {
+		return nil, model.BuildErrorResponse(r, appErr)
+	}
+	defer c.closeBody(r)
+
+	var calls []*apps.ScheduledCall
+	err := json.NewDecoder(r.Body).Decode(&calls)
+	if err != nil {
+		return nil, model.BuildErrorResponse(r, model.NewAppError("GetScheduledCalls", "", nil, err.Error(), http.StatusInternalServerError))
+	}
+	return calls, model.BuildResponse(r)
+}
+
+func (c *ClientPP) CancelScheduledCall(id string) *model.Response {
+	r, appErr := c.DoAPIDELETE(c.apipath(PathScheduledCalls) + "/" + id) // nolint:bodyclose
+	if appErr != nil {
+		return model.BuildErrorResponse(r, appErr)
+	}
+	defer c.closeBody(r)
+	return model.BuildResponse(r)
+}
+
+// RefreshBindingsRequest is the payload of the refresh bindings API. UserID
+// is optional, if omitted the bindings are refreshed for all users.
+type RefreshBindingsRequest struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+func (c *ClientPP) RefreshBindings(userID string) *model.Response {
+	data := utils.ToJSON(RefreshBindingsRequest{
+		UserID: userID,
+	})
+	r, appErr := c.DoAPIPOST(c.apipath(PathRefreshBindings), data) // nolint:bodyclose
+	if appErr != nil {
+		return model.BuildErrorResponse(r, appErr)
+	}
+	defer c.closeBody(r)
+	return model.BuildResponse(r)
+}
+
 func (c *ClientPP) StoreOAuth2App(appID apps.AppID, clientID, clientSecret string) *model.Response {
 	data := utils.ToJSON(apps.OAuth2App{
 		ClientID:     clientID,
@@ -230,6 +472,59 @@ func (c *ClientPP) DisableApp(appID apps.AppID) error {
 	return nil
 }
 
+// ExportApp returns a backup of an app's data, as JSON. The backup has no
+// credentials, the app needs to be installed before it is imported.
+func (c *ClientPP) ExportApp(appID apps.AppID) ([]byte, error) {
+	r, appErr := c.DoAPIGET(c.apipath(PathApps)+"/"+string(appID)+PathBackup, "") // nolint:bodyclose
+	if appErr != nil {
+		return nil, appErr
+	}
+	defer c.closeBody(r)
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response")
+	}
+	return data, nil
+}
+
+// ImportApp restores a backup made with ExportApp to the installed app,
+// replacing its data.
+func (c *ClientPP) ImportApp(appID apps.AppID, backup []byte) error {
+	r, appErr := c.DoAPIPOST(c.apipath(PathApps)+"/"+string(appID)+PathRestore, string(backup)) // nolint:bodyclose
+	if appErr != nil {
+		return appErr
+	}
+	defer c.closeBody(r)
+
+	return nil
+}
+
+// RotateSecretRequest is the body of a request to rotate an app's secret.
+type RotateSecretRequest struct {
+	SecretType apps.SecretType `json:"secret_type"`
+	// GracePeriodSeconds is how long the previous secret remains valid. If
+	// omitted, the default grace period is used.
+	GracePeriodSeconds *int64 `json:"grace_period_seconds,omitempty"`
+}
+
+// RotateSecret replaces one of the app's secrets with a newly generated one.
+// The previous secret remains valid for gracePeriod.
+func (c *ClientPP) RotateSecret(appID apps.AppID, secretType apps.SecretType, gracePeriod time.Duration) error {
+	seconds := int64(gracePeriod / time.Second)
+	data := utils.ToJSON(RotateSecretRequest{
+		SecretType:         secretType,
+		GracePeriodSeconds: &seconds,
+	})
+	r, appErr := c.DoAPIPOST(c.apipath(PathApps)+"/"+string(appID)+PathRotateSecret, data) // nolint:bodyclose
+	if appErr != nil {
+		return appErr
+	}
+	defer c.closeBody(r)
+
+	return nil
+}
+
 func (c *ClientPP) GetPluginsRoute() string {
 	return "/plugins"
 }
@@ -320,3 +615,7 @@ func (c *ClientPP) apipath(p string) string {
 func (c *ClientPP) kvpath(prefix, id string) string {
 	return c.apipath(path.Join("/kv", prefix, id))
 }
+
+func (c *ClientPP) userKVPath(userID, id string) string {
+	return c.apipath(path.Join(PathUserKV, userID, id))
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package apps
+
+import (
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+// MissedRuns determines what happens to the runs of a schedule that were
+// missed, for instance while the app was disabled, or the server was down.
+type MissedRuns string
+
+const (
+	// MissedRunsRunOnce, the default, makes a single call for all of the
+	// missed runs, as soon as possible.
+	MissedRunsRunOnce MissedRuns = "run_once"
+
+	// MissedRunsSkip skips the missed runs, the next call is made at the next
+	// scheduled time.
+	MissedRunsSkip MissedRuns = "skip"
+)
+
+// Schedule is a recurring call declared in the app's manifest. The call is
+// made with the app's bot as the acting user, and with the following Values:
+//  - "schedule": the name of the schedule.
+//  - "scheduled_at": when the call was due, in milliseconds since the epoch.
+//  - "missed_runs": the number of earlier runs that were missed, if any.
+type Schedule struct {
+	// Name identifies the schedule within the app.
+	Name string `json:"name"`
+
+	// Cron is a 5-field cron expression (minute, hour, day of month, month,
+	// day of week) in UTC, e.g. "0 9 * * mon-fri", or one of the @yearly,
+	// @monthly, @weekly, @daily, @hourly shorthands.
+	Cron string `json:"cron"`
+
+	MissedRuns MissedRuns `json:"missed_runs,omitempty"`
+
+	Call *Call `json:"call"`
+}
+
+func (s Schedule) IsValid() error {
+	if s.Name == "" {
+		return utils.NewInvalidError("schedule name must not be empty")
+	}
+	if _, err := utils.ParseCron(s.Cron); err != nil {
+		return err
+	}
+	switch s.MissedRuns {
+	case "", MissedRunsRunOnce, MissedRunsSkip:
+	default:
+		return utils.NewInvalidError("invalid missed_runs %q", s.MissedRuns)
+	}
+	if s.Call == nil || s.Call.Path == "" {
+		return utils.NewInvalidError("schedule must have a call path")
+	}
+	return nil
+}
+
+// MaxScheduledCallsPerApp is the maximum number of pending one-time calls an
+// app may have. MaxScheduledCallDelay is how far ahead (in milliseconds) a
+// call may be scheduled: 30 days.
+const (
+	MaxScheduledCallsPerApp = 100
+	MaxScheduledCallDelay   = 30 * 24 * 60 * 60 * 1000
+)
+
+// ScheduledCall is a one-time call that an app registered to be made later,
+// with the app's bot as the acting user. The call's Values include
+// "scheduled_call_id", and "scheduled_at" - when the call was due. Scheduled
+// calls are checked every minute, so they may be up to a minute late. A call
+// that was due while the app was disabled is made once it is enabled again.
+type ScheduledCall struct {
+	ID    string `json:"id"`
+	AppID AppID  `json:"app_id"`
+	Call  Call   `json:"call"`
+
+	// RunAt and CreatedAt are in milliseconds since the epoch.
+	RunAt     int64 `json:"run_at"`
+	CreatedAt int64 `json:"created_at"`
+}
+
+// ScheduleCallRequest is submitted by an app to schedule a one-time call,
+// either at a specific time, or after a delay.
+type ScheduleCallRequest struct {
+	Call Call `json:"call"`
+
+	// RunAt is in milliseconds since the epoch.
+	RunAt int64 `json:"run_at,omitempty"`
+
+	DelaySeconds int64 `json:"delay_seconds,omitempty"`
+}
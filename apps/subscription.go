@@ -6,6 +6,11 @@ package apps
 import (
 	"encoding/json"
 	"io"
+	"regexp"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-apps/utils"
 )
 
 type Subject string
@@ -13,7 +18,8 @@ type Subject string
 const (
 	// SubjectUserCreated subscribes to UserHasBeenCreated plugin events. By
 	// default, fully expanded User object is included in the notifications.
-	// There is no other data to expand.
+	// There is no other data to expand. Requires the act_as_admin
+	// permission.
 	SubjectUserCreated Subject = "user_created"
 
 	// SubjectUserJoinedChannel and SubjectUserLeftChannel subscribes to
@@ -42,14 +48,53 @@ const (
 	// RootPostID, ChannelID, but only Post is fully expanded. Expand can be
 	// used to expand other entities.
 	SubjectPostCreated Subject = "post_created"
+
+	// SubjectPostUpdated subscribes to MessageHasBeenUpdated plugin events, for
+	// the specified channel. Notifications include the same data as for
+	// SubjectPostCreated, with the updated Post.
+	SubjectPostUpdated Subject = "post_updated"
+
+	// SubjectPostDeleted is sent when a post is deleted in the specified
+	// channel, with the same data as SubjectPostCreated, and the deleted Post.
+	// The server does not report the deleted posts to plugins, so they are
+	// detected by polling the subscribed channels, and are delayed by up to a
+	// couple of minutes.
+	SubjectPostDeleted Subject = "post_deleted"
+
+	// SubjectReactionAdded and SubjectReactionRemoved subscribe to
+	// ReactionHasBeenAdded and ReactionHasBeenRemoved plugin events, for the
+	// specified channel. By default notifications include UserID (the user who
+	// reacted), PostID, RootPostID, ChannelID, and Reaction; Post is fully
+	// expanded. Expand can be used to expand other entities.
+	SubjectReactionAdded   Subject = "reaction_added"
+	SubjectReactionRemoved Subject = "reaction_removed"
+
+	// SubjectUserUpdated and SubjectUserDeactivated are sent when a user's
+	// profile changes, or the user is deactivated. By default notifications
+	// include UserID, and the fully expanded User. The server has no plugin
+	// hooks for these events, so they are detected by polling, and are
+	// delayed by up to a couple of minutes. Several changes made between two
+	// polls are notified once. A reactivated user is notified as updated.
+	// Both require the act_as_admin permission.
+	SubjectUserUpdated     Subject = "user_updated"
+	SubjectUserDeactivated Subject = "user_deactivated"
+
+	// SubjectChannelUpdated, SubjectChannelArchived and SubjectChannelRestored
+	// are sent when the specified channel is changed, archived, or restored
+	// from the archive. By default notifications include ChannelID, TeamID,
+	// and the fully expanded Channel. Like the user lifecycle subjects, they
+	// are detected by polling.
+	SubjectChannelUpdated  Subject = "channel_updated"
+	SubjectChannelArchived Subject = "channel_archived"
+	SubjectChannelRestored Subject = "channel_restored"
 )
 
 // Subscription is submitted by an app to the Subscribe API. It determines what
 // events the app would like to be notified on, and how these notifications
 // should be invoked.
 type Subscription struct {
-	// AppID is used internally by Mattermost. It does not need to be set by app
-	// developers.
+	// AppID is used internally by Mattermost. It is set from the bot user that
+	// made the request, so it does not need to be set by app developers.
 	AppID AppID `json:"app_id,omitempty"`
 
 	// Subscription subject. See type Subject godoc (linked) for details.
@@ -61,14 +106,91 @@ type Subscription struct {
 
 	// Call is the (one-way) call to make upon the event.
 	Call *Call
+
+	// Filter optionally limits the notifications to the posts that match it.
+	Filter *SubscriptionFilter `json:"filter,omitempty"`
+}
+
+// ThreadFilter selects the posts by their position in a thread.
+type ThreadFilter string
+
+const (
+	ThreadFilterAny   ThreadFilter = ""
+	ThreadFilterRoot  ThreadFilter = "root"
+	ThreadFilterReply ThreadFilter = "reply"
+)
+
+// MaxFilterRegexpLength is the maximum length of SubscriptionFilter's
+// MessageRegexp.
+const MaxFilterRegexpLength = 1024
+
+// SubscriptionFilter is evaluated by the proxy before notifying an app, so that
+// the app is only invoked for the posts it is interested in. It applies to the
+// post subjects (post_created, post_updated, post_deleted), and to the
+// reaction subjects, where it matches the post that was reacted to. All of the
+// conditions that are set must match.
+type SubscriptionFilter struct {
+	// MessagePrefix and MessageRegexp match the text of the post. The regular
+	// expression uses the Go (RE2) syntax, and matches anywhere in the text
+	// unless anchored.
+	MessagePrefix string `json:"message_prefix,omitempty"`
+	MessageRegexp string `json:"message_regexp,omitempty"`
+
+	// PostTypes lists the accepted post types, "" for regular posts.
+	PostTypes []string `json:"post_types,omitempty"`
+
+	// AuthorIsBot, if set, requires the post's author to be (or not to be) a
+	// bot.
+	AuthorIsBot *bool `json:"author_is_bot,omitempty"`
+
+	// AuthorRoles requires the post's author to have at least one of the
+	// roles, e.g. "system_admin".
+	AuthorRoles []string `json:"author_roles,omitempty"`
+
+	// Thread accepts only the thread root posts, or only the replies.
+	Thread ThreadFilter `json:"thread,omitempty"`
 }
 
 func (sub *Subscription) EqualScope(other *Subscription) bool {
 	s1, s2 := *sub, *other
 	s1.Call, s2.Call = nil, nil
+	s1.Filter, s2.Filter = nil, nil
 	return s1 == s2
 }
 
+// Validate checks that the subscription's filter, if any, is valid for its
+// subject.
+func (sub *Subscription) Validate() error {
+	if sub.Filter == nil {
+		return nil
+	}
+	switch sub.Subject {
+	case SubjectPostCreated, SubjectPostUpdated, SubjectPostDeleted,
+		SubjectReactionAdded, SubjectReactionRemoved:
+	default:
+		return utils.NewInvalidError("filters are not supported for %s subscriptions", sub.Subject)
+	}
+	return sub.Filter.Validate()
+}
+
+func (f *SubscriptionFilter) Validate() error {
+	if len(f.MessageRegexp) > MaxFilterRegexpLength {
+		return utils.NewInvalidError("message_regexp is longer than %v characters", MaxFilterRegexpLength)
+	}
+	if f.MessageRegexp != "" {
+		_, err := regexp.Compile(f.MessageRegexp)
+		if err != nil {
+			return utils.NewInvalidError(errors.Wrap(err, "invalid message_regexp"))
+		}
+	}
+	switch f.Thread {
+	case ThreadFilterAny, ThreadFilterRoot, ThreadFilterReply:
+	default:
+		return utils.NewInvalidError("invalid thread filter %q", f.Thread)
+	}
+	return nil
+}
+
 func (sub *Subscription) ToJSON() string {
 	b, _ := json.Marshal(sub)
 	return string(b)
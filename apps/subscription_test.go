@@ -0,0 +1,63 @@
+// +build !e2e
+
+package apps
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionValidate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		sub           Subscription
+		expectedError string
+	}{
+		"no filter": {
+			sub: Subscription{Subject: SubjectUserCreated},
+		},
+		"post filter": {
+			sub: Subscription{Subject: SubjectPostCreated, Filter: &SubscriptionFilter{
+				MessageRegexp: "^!deploy",
+				Thread:        ThreadFilterRoot,
+			}},
+		},
+		"reaction filter": {
+			sub: Subscription{Subject: SubjectReactionAdded, Filter: &SubscriptionFilter{MessagePrefix: "vote:"}},
+		},
+		"filter for a subject without posts": {
+			sub:           Subscription{Subject: SubjectUserJoinedChannel, Filter: &SubscriptionFilter{}},
+			expectedError: "filters are not supported for user_joined_channel subscriptions: invalid input",
+		},
+		"invalid regexp": {
+			sub:           Subscription{Subject: SubjectPostCreated, Filter: &SubscriptionFilter{MessageRegexp: "(unclosed"}},
+			expectedError: "invalid message_regexp: error parsing regexp: missing closing ): `(unclosed`: invalid input",
+		},
+		"regexp too long": {
+			sub:           Subscription{Subject: SubjectPostCreated, Filter: &SubscriptionFilter{MessageRegexp: strings.Repeat("a", MaxFilterRegexpLength+1)}},
+			expectedError: "message_regexp is longer than 1024 characters: invalid input",
+		},
+		"invalid thread": {
+			sub:           Subscription{Subject: SubjectPostCreated, Filter: &SubscriptionFilter{Thread: "middle"}},
+			expectedError: `invalid thread filter "middle": invalid input`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := tc.sub.Validate()
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestSubscriptionEqualScope(t *testing.T) {
+	s1 := &Subscription{Subject: SubjectPostCreated, ChannelID: "channel1", Filter: &SubscriptionFilter{MessagePrefix: "a"}}
+	s2 := &Subscription{Subject: SubjectPostCreated, ChannelID: "channel1", Filter: &SubscriptionFilter{MessagePrefix: "b"}}
+	s3 := &Subscription{Subject: SubjectPostCreated, ChannelID: "channel2"}
+	require.True(t, s1.EqualScope(s2))
+	require.False(t, s1.EqualScope(s3))
+}
@@ -1,5 +1,12 @@
 package appservices
 
+import (
+	"encoding/json"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
 func (a *AppServices) KVSet(botUserID, prefix, id string, ref interface{}) (bool, error) {
 	if err := a.ensureFromBot(botUserID); err != nil {
 		return false, err
@@ -7,6 +14,15 @@ func (a *AppServices) KVSet(botUserID, prefix, id string, ref interface{}) (bool
 	return a.store.AppKV.Set(botUserID, prefix, id, ref)
 }
 
+// KVSetWithOptions sets a value with an expiry, or only if the conditions in
+// opts are met. It returns false if the value was not set.
+func (a *AppServices) KVSetWithOptions(botUserID, prefix, id string, ref interface{}, opts apps.KVSetOptions) (bool, error) {
+	if err := a.ensureFromBot(botUserID); err != nil {
+		return false, err
+	}
+	return a.store.AppKV.SetWithOptions(botUserID, prefix, id, ref, opts)
+}
+
 func (a *AppServices) KVGet(botUserID, prefix, id string, ref interface{}) error {
 	if err := a.ensureFromBot(botUserID); err != nil {
 		return err
@@ -20,3 +36,39 @@ func (a *AppServices) KVDelete(botUserID, prefix, id string) error {
 	}
 	return a.store.AppKV.Delete(botUserID, prefix, id)
 }
+
+// KVList returns up to perPage of the IDs set by the app in a namespace that
+// follow after, sorted.
+func (a *AppServices) KVList(botUserID, prefix, after string, perPage int) (*apps.KVListResponse, error) {
+	if err := a.ensureFromBot(botUserID); err != nil {
+		return nil, err
+	}
+	if perPage <= 0 || perPage > apps.MaxKVListPerPage {
+		return nil, utils.NewInvalidError("per_page must be between 1 and %v", apps.MaxKVListPerPage)
+	}
+	return a.store.AppKV.ListKeys(botUserID, prefix, after, perPage)
+}
+
+func (a *AppServices) KVGetBatch(botUserID string, req *apps.KVBatchGetRequest) (map[string]json.RawMessage, error) {
+	if err := a.ensureFromBot(botUserID); err != nil {
+		return nil, err
+	}
+	if len(req.IDs) > apps.MaxKVBatchSize {
+		return nil, utils.NewInvalidError("a batch may have at most %v IDs", apps.MaxKVBatchSize)
+	}
+	return a.store.AppKV.GetBatch(botUserID, req.Prefix, req.IDs)
+}
+
+func (a *AppServices) KVSetBatch(botUserID string, req *apps.KVBatchSetRequest) error {
+	if err := a.ensureFromBot(botUserID); err != nil {
+		return err
+	}
+	if len(req.Values) > apps.MaxKVBatchSize {
+		return utils.NewInvalidError("a batch may have at most %v values", apps.MaxKVBatchSize)
+	}
+	values := map[string]interface{}{}
+	for id, v := range req.Values {
+		values[id] = []byte(v)
+	}
+	return a.store.AppKV.SetBatch(botUserID, req.Prefix, values)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package appservices
+
+import (
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+// ScheduleCall schedules a one-time call for the app that botUserID belongs
+// to, either at req.RunAt, or after req.DelaySeconds. An app may have up to
+// apps.MaxScheduledCallsPerApp calls scheduled.
+func (a *AppServices) ScheduleCall(botUserID string, req *apps.ScheduleCallRequest) (*apps.ScheduledCall, error) {
+	app, err := a.appForBot(botUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Call.Path == "" || req.Call.Path[0] != '/' {
+		return nil, utils.NewInvalidError("call path must start with a %q: %q", "/", req.Call.Path)
+	}
+	if _, err = utils.CleanPath(req.Call.Path); err != nil {
+		return nil, err
+	}
+
+	now := model.GetMillis()
+	runAt := req.RunAt
+	switch {
+	case req.RunAt != 0 && req.DelaySeconds != 0:
+		return nil, utils.NewInvalidError("only one of run_at and delay_seconds may be set")
+	case req.DelaySeconds < 0:
+		return nil, utils.NewInvalidError("delay_seconds must not be negative")
+	case req.DelaySeconds > 0:
+		runAt = now + req.DelaySeconds*1000
+	case req.RunAt == 0:
+		return nil, utils.NewInvalidError("run_at or delay_seconds must be set")
+	}
+	if runAt > now+apps.MaxScheduledCallDelay {
+		return nil, utils.NewInvalidError("calls may only be scheduled up to %v days ahead", apps.MaxScheduledCallDelay/(24*60*60*1000))
+	}
+
+	sc := &apps.ScheduledCall{
+		ID:        model.NewId(),
+		AppID:     app.AppID,
+		Call:      req.Call,
+		RunAt:     runAt,
+		CreatedAt: now,
+	}
+	err = a.store.Schedule.SaveCall(sc)
+	if err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// ListScheduledCalls returns the pending one-time calls of the app that
+// botUserID belongs to, by when they are due.
+func (a *AppServices) ListScheduledCalls(botUserID string) ([]*apps.ScheduledCall, error) {
+	app, err := a.appForBot(botUserID)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.Schedule.ListCalls(app.AppID)
+}
+
+// CancelScheduledCall deletes a pending one-time call of the app that
+// botUserID belongs to.
+func (a *AppServices) CancelScheduledCall(botUserID, id string) error {
+	app, err := a.appForBot(botUserID)
+	if err != nil {
+		return err
+	}
+	sc, err := a.store.Schedule.GetCall(id)
+	if err != nil {
+		return err
+	}
+	if sc.AppID != app.AppID {
+		return utils.ErrNotFound
+	}
+	return a.store.Schedule.DeleteCall(app.AppID, id)
+}
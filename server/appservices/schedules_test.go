@@ -0,0 +1,106 @@
+// +build !e2e
+
+package appservices
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/server/mocks/mock_store"
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestScheduleCall(t *testing.T) {
+	app := &apps.App{
+		BotUserID: "botid",
+		Manifest: apps.Manifest{
+			AppID: "app1",
+		},
+	}
+
+	setup := func(t *testing.T) (*AppServices, *mock_store.MockScheduleStore) {
+		mm := pluginapi.NewClient(&plugintest.API{}, &plugintest.Driver{})
+		ctrl := gomock.NewController(t)
+		appStore := mock_store.NewMockAppStore(ctrl)
+		appStore.EXPECT().AsMap().Return(map[apps.AppID]*apps.App{app.AppID: app}).AnyTimes()
+		scheduleStore := mock_store.NewMockScheduleStore(ctrl)
+		s := &store.Service{
+			App:      appStore,
+			Schedule: scheduleStore,
+		}
+		return NewService(mm, config.NewTestConfigurator(config.Config{}), s), scheduleStore
+	}
+
+	t.Run("delay", func(t *testing.T) {
+		a, scheduleStore := setup(t)
+		scheduleStore.EXPECT().SaveCall(gomock.Any()).Return(nil)
+
+		before := model.GetMillis()
+		sc, err := a.ScheduleCall("botid", &apps.ScheduleCallRequest{
+			Call:         apps.Call{Path: "/later"},
+			DelaySeconds: 60,
+		})
+		require.NoError(t, err)
+		require.Equal(t, apps.AppID("app1"), sc.AppID)
+		require.Equal(t, "/later", sc.Call.Path)
+		require.NotEmpty(t, sc.ID)
+		require.GreaterOrEqual(t, sc.RunAt, before+60*1000)
+		require.LessOrEqual(t, sc.RunAt, sc.CreatedAt+60*1000)
+	})
+
+	t.Run("too many", func(t *testing.T) {
+		a, scheduleStore := setup(t)
+		scheduleStore.EXPECT().SaveCall(gomock.Any()).Return(utils.NewQuotaExceededError("app1 already has 100 scheduled calls"))
+
+		_, err := a.ScheduleCall("botid", &apps.ScheduleCallRequest{
+			Call:         apps.Call{Path: "/later"},
+			DelaySeconds: 60,
+		})
+		require.ErrorIs(t, err, utils.ErrQuotaExceeded)
+	})
+
+	for name, tc := range map[string]struct {
+		req           apps.ScheduleCallRequest
+		expectedError string
+	}{
+		"no time": {
+			req:           apps.ScheduleCallRequest{Call: apps.Call{Path: "/later"}},
+			expectedError: "run_at or delay_seconds must be set: invalid input",
+		},
+		"both times": {
+			req:           apps.ScheduleCallRequest{Call: apps.Call{Path: "/later"}, RunAt: 1, DelaySeconds: 1},
+			expectedError: "only one of run_at and delay_seconds may be set: invalid input",
+		},
+		"too far": {
+			req:           apps.ScheduleCallRequest{Call: apps.Call{Path: "/later"}, DelaySeconds: 31 * 24 * 60 * 60},
+			expectedError: "calls may only be scheduled up to 30 days ahead: invalid input",
+		},
+		"relative path": {
+			req:           apps.ScheduleCallRequest{Call: apps.Call{Path: "later"}, DelaySeconds: 1},
+			expectedError: `call path must start with a "/": "later": invalid input`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			a, _ := setup(t)
+			_, err := a.ScheduleCall("botid", &tc.req)
+			require.EqualError(t, err, tc.expectedError)
+		})
+	}
+
+	t.Run("cancel other app's call", func(t *testing.T) {
+		a, scheduleStore := setup(t)
+		scheduleStore.EXPECT().GetCall("id1").Return(&apps.ScheduledCall{ID: "id1", AppID: "app2"}, nil)
+
+		err := a.CancelScheduledCall("botid", "id1")
+		require.ErrorIs(t, err, utils.ErrNotFound)
+	})
+}
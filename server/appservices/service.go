@@ -4,6 +4,8 @@
 package appservices
 
 import (
+	"encoding/json"
+
 	"github.com/pkg/errors"
 
 	pluginapi "github.com/mattermost/mattermost-plugin-api"
@@ -21,15 +23,34 @@ var ErrIsABot = errors.New("is a bot")
 type Service interface {
 	// Subscriptions
 
-	Subscribe(actingUserID string, _ *apps.Subscription) error
-	Unsubscribe(actingUserID string, _ *apps.Subscription) error
+	Subscribe(botUserID string, _ *apps.Subscription) error
+	Unsubscribe(botUserID string, _ *apps.Subscription) error
+	GetSubscriptions(botUserID string) ([]*apps.Subscription, error)
+
+	// Scheduled calls
+
+	ScheduleCall(botUserID string, _ *apps.ScheduleCallRequest) (*apps.ScheduledCall, error)
+	ListScheduledCalls(botUserID string) ([]*apps.ScheduledCall, error)
+	CancelScheduledCall(botUserID, id string) error
 
 	// KV
 
 	// ref can be either a []byte for raw data, or anything else will be JSON marshaled.
 	KVSet(botUserID, prefix, id string, ref interface{}) (bool, error)
+	KVSetWithOptions(botUserID, prefix, id string, ref interface{}, opts apps.KVSetOptions) (bool, error)
 	KVGet(botUserID, prefix, id string, ref interface{}) error
 	KVDelete(botUserID, prefix, id string) error
+	KVList(botUserID, prefix, after string, perPage int) (*apps.KVListResponse, error)
+	KVGetBatch(botUserID string, req *apps.KVBatchGetRequest) (map[string]json.RawMessage, error)
+	KVSetBatch(botUserID string, req *apps.KVBatchSetRequest) error
+
+	// Per-user KV
+
+	// ref can be either a []byte for raw data, or anything else will be JSON marshaled.
+	UserKVSet(botUserID, userID, id string, ref interface{}) error
+	UserKVGet(botUserID, userID, id string, ref interface{}) error
+	UserKVDelete(botUserID, userID, id string) error
+	UserKVList(botUserID, userID string) ([]string, error)
 
 	// Remote (3rd party) OAuth2
 
@@ -82,3 +103,16 @@ func (a *AppServices) ensureFromUser(mattermostUserID string) error {
 	}
 	return nil
 }
+
+// appForBot returns the installed app that the bot user belongs to.
+func (a *AppServices) appForBot(botUserID string) (*apps.App, error) {
+	if botUserID == "" {
+		return nil, utils.NewUnauthorizedError("not logged in")
+	}
+	for _, app := range a.store.App.AsMap() {
+		if app.BotUserID == botUserID {
+			return app, nil
+		}
+	}
+	return nil, utils.NewForbiddenError("%s is not an app's bot user", botUserID)
+}
@@ -4,12 +4,29 @@
 package appservices
 
 import (
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+
 	"github.com/mattermost/mattermost-plugin-apps/apps"
 	"github.com/mattermost/mattermost-plugin-apps/utils"
 )
 
-func (a *AppServices) Subscribe(actingUserID string, sub *apps.Subscription) error {
-	err := utils.EnsureSysAdmin(a.mm, actingUserID)
+// Subscribe saves a subscription for the app that botUserID belongs to. The
+// subscription's AppID is always set from the bot, and the subscription is
+// checked against the app's granted permissions, and the bot's access to the
+// channel or team it is scoped to.
+func (a *AppServices) Subscribe(botUserID string, sub *apps.Subscription) error {
+	app, err := a.appForBot(botUserID)
+	if err != nil {
+		return err
+	}
+	sub.AppID = app.AppID
+
+	err = sub.Validate()
+	if err != nil {
+		return err
+	}
+	err = EnsureCanSubscribe(a.mm, app, sub)
 	if err != nil {
 		return err
 	}
@@ -17,11 +34,51 @@ func (a *AppServices) Subscribe(actingUserID string, sub *apps.Subscription) err
 	return a.store.Subscription.Save(sub)
 }
 
-func (a *AppServices) Unsubscribe(actingUserID string, sub *apps.Subscription) error {
-	err := utils.EnsureSysAdmin(a.mm, actingUserID)
+// Unsubscribe deletes a subscription of the app that botUserID belongs to.
+func (a *AppServices) Unsubscribe(botUserID string, sub *apps.Subscription) error {
+	app, err := a.appForBot(botUserID)
 	if err != nil {
 		return err
 	}
+	sub.AppID = app.AppID
 
 	return a.store.Subscription.Delete(sub)
 }
+
+// GetSubscriptions returns the subscriptions of the app that botUserID belongs
+// to.
+func (a *AppServices) GetSubscriptions(botUserID string) ([]*apps.Subscription, error) {
+	app, err := a.appForBot(botUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.store.Subscription.ListByApp(app.AppID)
+}
+
+// EnsureCanSubscribe checks a subscription against the app's granted
+// permissions, and the bot's access to the channel or team it is scoped to.
+// The global user subjects include full user objects of all users, so they
+// require act_as_admin.
+func EnsureCanSubscribe(mm *pluginapi.Client, app *apps.App, sub *apps.Subscription) error {
+	switch sub.Subject {
+	case apps.SubjectUserJoinedChannel:
+		if !app.GrantedPermissions.Contains(apps.PermissionUserJoinedChannelNotification) {
+			return utils.NewForbiddenError("%s is not allowed to %s", app.AppID, apps.PermissionUserJoinedChannelNotification)
+		}
+	case apps.SubjectUserCreated, apps.SubjectUserUpdated, apps.SubjectUserDeactivated:
+		if !app.GrantedPermissions.Contains(apps.PermissionActAsAdmin) {
+			return utils.NewForbiddenError("%s is not allowed to %s", app.AppID, apps.PermissionActAsAdmin)
+		}
+	}
+
+	if sub.ChannelID != "" &&
+		!mm.User.HasPermissionToChannel(app.BotUserID, sub.ChannelID, model.PERMISSION_READ_CHANNEL) {
+		return utils.NewForbiddenError("%s has no access to channel %s", app.AppID, sub.ChannelID)
+	}
+	if sub.TeamID != "" &&
+		!mm.User.HasPermissionToTeam(app.BotUserID, sub.TeamID, model.PERMISSION_VIEW_TEAM) {
+		return utils.NewForbiddenError("%s has no access to team %s", app.AppID, sub.TeamID)
+	}
+	return nil
+}
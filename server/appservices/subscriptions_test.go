@@ -0,0 +1,127 @@
+// +build !e2e
+
+package appservices
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/server/mocks/mock_store"
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestSubscribe(t *testing.T) {
+	app := &apps.App{
+		BotUserID: "botid",
+		Manifest: apps.Manifest{
+			AppID: "app1",
+		},
+		GrantedPermissions: apps.Permissions{apps.PermissionActAsBot},
+	}
+	privileged := *app
+	privileged.GrantedPermissions = apps.Permissions{apps.PermissionActAsBot, apps.PermissionActAsAdmin, apps.PermissionUserJoinedChannelNotification}
+
+	setup := func(t *testing.T, app *apps.App) (*AppServices, *plugintest.API, *mock_store.MockSubscriptionStore) {
+		testAPI := &plugintest.API{}
+		mm := pluginapi.NewClient(testAPI, &plugintest.Driver{})
+		ctrl := gomock.NewController(t)
+		appStore := mock_store.NewMockAppStore(ctrl)
+		appStore.EXPECT().AsMap().Return(map[apps.AppID]*apps.App{app.AppID: app}).AnyTimes()
+		subStore := mock_store.NewMockSubscriptionStore(ctrl)
+		s := &store.Service{
+			App:          appStore,
+			Subscription: subStore,
+		}
+		return NewService(mm, config.NewTestConfigurator(config.Config{}), s), testAPI, subStore
+	}
+
+	t.Run("app ID is set from the bot", func(t *testing.T) {
+		a, _, subStore := setup(t, &privileged)
+		subStore.EXPECT().Save(&apps.Subscription{
+			AppID:   "app1",
+			Subject: apps.SubjectUserCreated,
+		}).Return(nil)
+
+		err := a.Subscribe("botid", &apps.Subscription{
+			AppID:   "other-app",
+			Subject: apps.SubjectUserCreated,
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("not an app's bot", func(t *testing.T) {
+		a, _, _ := setup(t, app)
+
+		err := a.Subscribe("userid", &apps.Subscription{Subject: apps.SubjectUserCreated})
+		require.ErrorIs(t, err, utils.ErrForbidden)
+
+		err = a.Unsubscribe("userid", &apps.Subscription{Subject: apps.SubjectUserCreated})
+		require.ErrorIs(t, err, utils.ErrForbidden)
+	})
+
+	t.Run("permission is required for user_joined_channel", func(t *testing.T) {
+		a, _, _ := setup(t, app)
+
+		err := a.Subscribe("botid", &apps.Subscription{
+			Subject:   apps.SubjectUserJoinedChannel,
+			ChannelID: "channelid",
+		})
+		require.ErrorIs(t, err, utils.ErrForbidden)
+	})
+
+	t.Run("permission is required for the user subjects", func(t *testing.T) {
+		a, _, _ := setup(t, app)
+
+		for _, subject := range []apps.Subject{
+			apps.SubjectUserCreated,
+			apps.SubjectUserUpdated,
+			apps.SubjectUserDeactivated,
+		} {
+			err := a.Subscribe("botid", &apps.Subscription{Subject: subject})
+			require.ErrorIs(t, err, utils.ErrForbidden, subject)
+		}
+	})
+
+	t.Run("channel access is required", func(t *testing.T) {
+		a, testAPI, _ := setup(t, &privileged)
+		testAPI.On("HasPermissionToChannel", "botid", "channelid", model.PERMISSION_READ_CHANNEL).Return(false)
+
+		err := a.Subscribe("botid", &apps.Subscription{
+			Subject:   apps.SubjectUserJoinedChannel,
+			ChannelID: "channelid",
+		})
+		require.ErrorIs(t, err, utils.ErrForbidden)
+	})
+
+	t.Run("channel subscription", func(t *testing.T) {
+		a, testAPI, subStore := setup(t, &privileged)
+		testAPI.On("HasPermissionToChannel", "botid", "channelid", model.PERMISSION_READ_CHANNEL).Return(true)
+		subStore.EXPECT().Save(gomock.Any()).Return(nil)
+
+		err := a.Subscribe("botid", &apps.Subscription{
+			Subject:   apps.SubjectUserJoinedChannel,
+			ChannelID: "channelid",
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("team access is required", func(t *testing.T) {
+		a, testAPI, _ := setup(t, app)
+		testAPI.On("HasPermissionToTeam", "botid", "teamid", model.PERMISSION_VIEW_TEAM).Return(false)
+
+		err := a.Subscribe("botid", &apps.Subscription{
+			Subject: apps.SubjectChannelCreated,
+			TeamID:  "teamid",
+		})
+		require.ErrorIs(t, err, utils.ErrForbidden)
+	})
+}
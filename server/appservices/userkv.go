@@ -0,0 +1,73 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package appservices
+
+import (
+	"github.com/pkg/errors"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+// UserKVSet sets a value for a user, in the namespace of the app that
+// botUserID belongs to. Only the app can access its values, with its bot
+// user's token, so the users read and write them through the app's calls.
+func (a *AppServices) UserKVSet(botUserID, userID, id string, ref interface{}) error {
+	app, err := a.userKVApp(botUserID, userID)
+	if err != nil {
+		return err
+	}
+	return a.store.UserKV.Set(app.BotUserID, userID, id, ref)
+}
+
+func (a *AppServices) UserKVGet(botUserID, userID, id string, ref interface{}) error {
+	app, err := a.userKVApp(botUserID, userID)
+	if err != nil {
+		return err
+	}
+	return a.store.UserKV.Get(app.BotUserID, userID, id, ref)
+}
+
+func (a *AppServices) UserKVDelete(botUserID, userID, id string) error {
+	app, err := a.userKVApp(botUserID, userID)
+	if err != nil {
+		return err
+	}
+	return a.store.UserKV.Delete(app.BotUserID, userID, id)
+}
+
+// UserKVList returns the IDs that the app set for the user, sorted.
+func (a *AppServices) UserKVList(botUserID, userID string) ([]string, error) {
+	app, err := a.userKVApp(botUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.UserKV.List(app.BotUserID, userID)
+}
+
+// userKVApp returns the app that botUserID belongs to, if userID is an active
+// user. The values of the deactivated users are purged, and are not accessible
+// in the meantime.
+func (a *AppServices) userKVApp(botUserID, userID string) (*apps.App, error) {
+	app, err := a.appForBot(botUserID)
+	if err != nil {
+		return nil, err
+	}
+	if userID == "" {
+		return nil, utils.NewInvalidError("user ID must not be empty")
+	}
+	user, err := a.mm.User.Get(userID)
+	if errors.Is(err, pluginapi.ErrNotFound) {
+		return nil, utils.NewNotFoundError("user %s", userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.IsBot || user.DeleteAt != 0 {
+		return nil, utils.NewInvalidError("%s is not an active user", userID)
+	}
+	return app, nil
+}
@@ -0,0 +1,62 @@
+// +build !e2e
+
+package appservices
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/server/mocks/mock_store"
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestUserKV(t *testing.T) {
+	app := &apps.App{
+		BotUserID: "botid",
+		Manifest: apps.Manifest{
+			AppID: "app1",
+		},
+	}
+
+	testAPI := &plugintest.API{}
+	testAPI.On("GetUser", "user").Return(&model.User{Id: "user"}, nil)
+	testAPI.On("GetUser", "bot").Return(&model.User{Id: "bot", IsBot: true}, nil)
+	testAPI.On("GetUser", "deactivated").Return(&model.User{Id: "deactivated", DeleteAt: 1}, nil)
+	testAPI.On("GetUser", "missing").Return(nil, model.NewAppError("GetUser", "", nil, "", http.StatusNotFound))
+
+	ctrl := gomock.NewController(t)
+	appStore := mock_store.NewMockAppStore(ctrl)
+	appStore.EXPECT().AsMap().Return(map[apps.AppID]*apps.App{app.AppID: app}).AnyTimes()
+	userKVStore := mock_store.NewMockUserKVStore(ctrl)
+	a := NewService(pluginapi.NewClient(testAPI, &plugintest.Driver{}), config.NewTestConfigurator(config.Config{}), &store.Service{
+		App:    appStore,
+		UserKV: userKVStore,
+	})
+
+	userKVStore.EXPECT().Set("botid", "user", "id", []byte("1")).Return(nil)
+	require.NoError(t, a.UserKVSet("botid", "user", "id", []byte("1")))
+
+	// Only apps can access the values.
+	err := a.UserKVSet("user", "user", "id", []byte("1"))
+	require.ErrorIs(t, err, utils.ErrForbidden)
+
+	for userID, expected := range map[string]error{
+		"bot":         utils.ErrInvalid,
+		"deactivated": utils.ErrInvalid,
+		"missing":     utils.ErrNotFound,
+		"":            utils.ErrInvalid,
+	} {
+		err = a.UserKVGet("botid", userID, "id", nil)
+		require.ErrorIs(t, err, expected, userID)
+	}
+}
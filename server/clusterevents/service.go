@@ -0,0 +1,110 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package clusterevents
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+// Handler processes the data of an intra-cluster event.
+type Handler func(data []byte)
+
+// PublishAPI is the subset of the plugin API needed to publish intra-cluster
+// events.
+type PublishAPI interface {
+	PublishPluginClusterEvent(ev model.PluginClusterEvent, opts model.PluginClusterEventSendOptions) error
+}
+
+// Service delivers events to the local handlers, and to the other instances of
+// the plugin in the cluster. It is used to keep the per-node (in-memory)
+// caches consistent.
+type Service interface {
+	// Broadcast JSON-encodes v, runs the handlers for id on this node, and
+	// publishes the event to the other nodes.
+	Broadcast(id string, v interface{}) error
+
+	// Handle registers a handler for the events with id.
+	Handle(id string, h Handler)
+
+	// OnPluginClusterEvent should be called by the plugin when it receives an
+	// event from another node.
+	OnPluginClusterEvent(ev model.PluginClusterEvent)
+}
+
+type service struct {
+	api PublishAPI
+	log utils.Logger
+
+	mutex    sync.RWMutex
+	handlers map[string][]Handler
+}
+
+var _ Service = (*service)(nil)
+
+// NewService creates a new cluster events service. If api is nil, the events
+// are only delivered locally, which is sufficient for single-node
+// installations and tests.
+func NewService(api PublishAPI, log utils.Logger) Service {
+	return &service{
+		api:      api,
+		log:      log,
+		handlers: map[string][]Handler{},
+	}
+}
+
+func (s *service) Handle(id string, h Handler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handlers[id] = append(s.handlers[id], h)
+}
+
+func (s *service) Broadcast(id string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode cluster event %s", id)
+	}
+
+	s.dispatch(id, data)
+
+	if s.api == nil {
+		return nil
+	}
+	err = s.api.PublishPluginClusterEvent(
+		model.PluginClusterEvent{
+			Id:   id,
+			Data: data,
+		},
+		model.PluginClusterEventSendOptions{
+			SendType: model.PluginClusterEventSendTypeReliable,
+		})
+	if err != nil {
+		return errors.Wrapf(err, "failed to publish cluster event %s", id)
+	}
+	return nil
+}
+
+func (s *service) OnPluginClusterEvent(ev model.PluginClusterEvent) {
+	s.dispatch(ev.Id, ev.Data)
+}
+
+func (s *service) dispatch(id string, data []byte) {
+	s.mutex.RLock()
+	handlers := s.handlers[id]
+	s.mutex.RUnlock()
+
+	if len(handlers) == 0 {
+		s.log.Debugw("No handler for cluster event", "id", id)
+		return
+	}
+	for _, h := range handlers {
+		h(data)
+	}
+}
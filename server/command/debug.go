@@ -4,6 +4,9 @@
 package command
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
@@ -30,6 +33,31 @@ func (s *service) executeDebugBindings(params *commandParams) (*model.CommandRes
 	return out(params, utils.JSONBlock(bindings))
 }
 
+func (s *service) executeDebugCalls(params *commandParams) (*model.CommandResponse, error) {
+	if len(params.current) == 0 {
+		return errorOut(params, errors.New("you need to specify the app id"))
+	}
+	appID := apps.AppID(params.current[0])
+
+	records := s.proxy.GetCallHistory(appID)
+	if len(records) == 0 {
+		return out(params, fmt.Sprintf("No calls to `%s` recorded on this server.", appID))
+	}
+
+	txt := "| Time | Source | Path | Acting User | Duration | Response | Error |\n"
+	txt += "| :-- |:-- | :-- | :-- | :-- | :-- | :-- |\n"
+	for _, r := range records {
+		t := time.Unix(0, r.Time*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+		source := r.Source
+		if r.Subject != "" {
+			source += " " + string(r.Subject)
+		}
+		txt += fmt.Sprintf("|%s|%s|`%s`|%s|%v|%s|%s|\n",
+			t, source, r.Path, r.ActingUserID, r.Duration.Round(time.Millisecond), r.ResponseType, r.ErrorText)
+	}
+	return out(params, txt)
+}
+
 func (s *service) executeDebugAddManifest(params *commandParams) (*model.CommandResponse, error) {
 	manifestURL := ""
 	fs := pflag.NewFlagSet("", pflag.ContinueOnError)
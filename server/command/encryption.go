@@ -0,0 +1,25 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+func (s *service) executeRotateEncryptionKey(params *commandParams) (*model.CommandResponse, error) {
+	if s.conf.GetConfig().EncryptionKeys.Current == "" {
+		return errorOut(params, errors.New("no encryption key is configured"))
+	}
+
+	r, err := s.proxy.RotateEncryptionKey()
+	if err != nil {
+		return errorOut(params, err)
+	}
+	return out(params, fmt.Sprintf(
+		"Re-encrypted the secrets of %v apps, and %v OAuth2 user records, with the current key. "+
+			"The previous encryption key may now be removed.", r.Apps, r.Users))
+}
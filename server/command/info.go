@@ -5,13 +5,20 @@ package command
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
 
+	"github.com/mattermost/mattermost-plugin-apps/apps"
 	"github.com/mattermost/mattermost-plugin-apps/server/config"
 )
 
 func (s *service) executeInfo(params *commandParams) (*model.CommandResponse, error) {
+	if len(params.current) > 0 {
+		return s.checkSystemAdmin(s.executeAppInfo)(params)
+	}
+
 	conf := s.conf.GetConfig()
 	resp := fmt.Sprintf("Mattermost Apps plugin version: %s, "+
 		"[%s](https://github.com/mattermost/%s/commit/%s), built %s, Cloud Mode: %t, Developer Mode: %t\n",
@@ -24,5 +31,34 @@ func (s *service) executeInfo(params *commandParams) (*model.CommandResponse, er
 		conf.DeveloperMode,
 	)
 
+	stats := s.proxy.DispatcherStats()
+	resp += fmt.Sprintf("Notifications: %v sending, %v waiting (limit %v), %v rejected since started.\n",
+		stats.Running, stats.Queued, conf.MaxNotificationsQueued(), stats.Rejected)
+
+	return out(params, resp)
+}
+
+func (s *service) executeAppInfo(params *commandParams) (*model.CommandResponse, error) {
+	appID := apps.AppID(params.current[0])
+	app, err := s.proxy.GetInstalledApp(appID)
+	if err != nil {
+		return errorOut(params, errors.Wrapf(err, "failed to get app %s", appID))
+	}
+	usage, err := s.proxy.GetKVUsage(appID)
+	if err != nil {
+		return errorOut(params, errors.Wrapf(err, "failed to get the KV usage of %s", appID))
+	}
+
+	resp := fmt.Sprintf("%s (`%s`) version %s, type: %s, enabled: %t\n",
+		app.DisplayName, app.AppID, app.Version, app.AppType, s.proxy.AppIsEnabled(app))
+	resp += fmt.Sprintf("KV store: %v keys (limit %s), %v bytes (limit %s).\n",
+		usage.Keys, formatLimit(usage.Quota.MaxKeys), usage.Bytes, formatLimit(usage.Quota.MaxBytes))
 	return out(params, resp)
 }
+
+func formatLimit(limit int) string {
+	if limit == 0 {
+		return "none"
+	}
+	return strconv.Itoa(limit)
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+func (s *service) executeListJobs(params *commandParams) (*model.CommandResponse, error) {
+	jobs, err := s.proxy.ListJobs()
+	if err != nil {
+		return errorOut(params, err)
+	}
+	if len(jobs) == 0 {
+		return out(params, "No jobs.")
+	}
+
+	txt := "| ID | Type | Data | Status | Created | Attempts | Error |\n"
+	txt += "| :-- |:-- | :-- | :-- | :-- | :-- | :-- |\n"
+	for _, job := range jobs {
+		created := time.Unix(0, job.CreatedAt*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+		var keys []string
+		for k := range job.Data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		data := ""
+		for _, k := range keys {
+			data += fmt.Sprintf("%s: `%s` ", k, job.Data[k])
+		}
+		txt += fmt.Sprintf("|`%s`|%s|%s|%s|%s|%v|%s|\n",
+			job.ID, job.Type, data, job.Status, created, job.Attempts, job.Error)
+	}
+	return out(params, txt)
+}
+
+func (s *service) executeRetryJob(params *commandParams) (*model.CommandResponse, error) {
+	if len(params.current) == 0 {
+		return errorOut(params, errors.New("you need to specify the job ID"))
+	}
+
+	id := params.current[0]
+	err := s.proxy.RetryJob(id)
+	if err != nil {
+		return errorOut(params, errors.Wrapf(err, "failed to retry %s", id))
+	}
+	return out(params, fmt.Sprintf("Job %s will run again.", id))
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+func (s *service) executeListDeadLetters(params *commandParams) (*model.CommandResponse, error) {
+	deadLetters, err := s.proxy.ListDeadLetters()
+	if err != nil {
+		return errorOut(params, err)
+	}
+	if len(deadLetters) == 0 {
+		return out(params, "No failed notifications.")
+	}
+
+	txt := "| ID | App | Subject | Call | Created | Attempts | Error |\n"
+	txt += "| :-- |:-- | :-- | :-- | :-- | :-- | :-- |\n"
+	for _, n := range deadLetters {
+		created := time.Unix(0, n.CreatedAt*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+		txt += fmt.Sprintf("|`%s`|`%s`|%s|`%s`|%s|%v|%s|\n",
+			n.ID, n.AppID, n.Subject, n.Call.Path, created, n.Attempts, n.Error)
+	}
+	return out(params, txt)
+}
+
+func (s *service) executeReplayDeadLetter(params *commandParams) (*model.CommandResponse, error) {
+	if len(params.current) == 0 {
+		return errorOut(params, errors.New("you need to specify the notification ID, or `all`"))
+	}
+
+	ids := []string{params.current[0]}
+	if params.current[0] == "all" {
+		deadLetters, err := s.proxy.ListDeadLetters()
+		if err != nil {
+			return errorOut(params, err)
+		}
+		ids = nil
+		for _, n := range deadLetters {
+			ids = append(ids, n.ID)
+		}
+	}
+
+	for _, id := range ids {
+		err := s.proxy.ReplayDeadLetter(id)
+		if err != nil {
+			return errorOut(params, errors.Wrapf(err, "failed to replay %s", id))
+		}
+	}
+	return out(params, fmt.Sprintf("Queued %v notification(s) to be sent again.", len(ids)))
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+)
+
+func (s *service) executeRotateSecret(params *commandParams) (*model.CommandResponse, error) {
+	if len(params.current) == 0 {
+		return errorOut(params, errors.New("you need to specify the app id"))
+	}
+	appID := apps.AppID(params.current[0])
+
+	app, err := s.proxy.GetInstalledApp(appID)
+	if err != nil {
+		return errorOut(params, errors.Wrapf(err, "failed to get app %s", appID))
+	}
+	// With no type, all of the app's secrets are rotated.
+	var secretTypes []apps.SecretType
+	if len(params.current) > 1 {
+		secretTypes = []apps.SecretType{apps.SecretType(params.current[1])}
+	} else {
+		if app.AppType == apps.AppTypeHTTP {
+			secretTypes = append(secretTypes, apps.SecretTypeJWT)
+		}
+		if app.WebhookSecret != "" {
+			secretTypes = append(secretTypes, apps.SecretTypeWebhook)
+		}
+		if app.BotAccessTokenID != "" {
+			secretTypes = append(secretTypes, apps.SecretTypeBotToken)
+		}
+		if len(secretTypes) == 0 {
+			return errorOut(params, errors.Errorf("%s has no secrets to rotate", appID))
+		}
+	}
+
+	gracePeriod := config.DefaultSecretRotationGracePeriod
+	if len(params.current) > 2 {
+		gracePeriod, err = time.ParseDuration(params.current[2])
+		if err != nil {
+			return errorOut(params, errors.Wrap(err, "invalid grace period"))
+		}
+	}
+
+	cc := s.conf.GetConfig().SetContextDefaultsForApp(appID, s.newCommandContext(params.commandArgs))
+	txt := ""
+	for _, secretType := range secretTypes {
+		message, err := s.proxy.RotateSecret(params.commandArgs.Session.Id, cc, appID, secretType, gracePeriod)
+		if err != nil {
+			return errorOut(params, errors.Wrapf(err, "failed to rotate the %s secret", secretType))
+		}
+		txt += message + "\n"
+	}
+	return out(params, txt)
+}
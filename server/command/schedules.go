@@ -0,0 +1,80 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+)
+
+func (s *service) executeListSchedules(params *commandParams) (*model.CommandResponse, error) {
+	var appIDs []apps.AppID
+	if len(params.current) > 0 {
+		appIDs = append(appIDs, apps.AppID(params.current[0]))
+	} else {
+		for _, app := range s.proxy.GetInstalledApps() {
+			appIDs = append(appIDs, app.AppID)
+		}
+	}
+
+	txt := ""
+	for _, appID := range appIDs {
+		schedules, err := s.proxy.GetSchedules(appID)
+		if err != nil {
+			return errorOut(params, errors.Wrapf(err, "failed to get schedules for %s", appID))
+		}
+		if len(schedules) == 0 {
+			continue
+		}
+		if txt == "" {
+			txt += "| App | Schedule | Cron | Call | Last run | Next run |\n"
+			txt += "| :-- |:-- | :-- | :-- | :-- | :-- |\n"
+		}
+		for _, sched := range schedules {
+			path := ""
+			if sched.Call != nil {
+				path = "`" + sched.Call.Path + "`"
+			}
+			txt += fmt.Sprintf("|`%s`|%s|`%s`|%s|%s|%s|\n",
+				appID, sched.Name, sched.Cron, path, formatMillis(sched.LastRun), formatMillis(sched.NextRun))
+		}
+	}
+
+	var calls []*apps.ScheduledCall
+	for _, appID := range appIDs {
+		appCalls, err := s.proxy.ListScheduledCalls(appID)
+		if err != nil {
+			return errorOut(params, errors.Wrapf(err, "failed to get scheduled calls for %s", appID))
+		}
+		calls = append(calls, appCalls...)
+	}
+	if len(calls) > 0 {
+		if txt != "" {
+			txt += "\n"
+		}
+		txt += "| App | ID | Call | Run at |\n"
+		txt += "| :-- |:-- | :-- | :-- |\n"
+		for _, c := range calls {
+			txt += fmt.Sprintf("|`%s`|`%s`|`%s`|%s|\n",
+				c.AppID, c.ID, c.Call.Path, formatMillis(c.RunAt))
+		}
+	}
+
+	if txt == "" {
+		return out(params, "No schedules or scheduled calls.")
+	}
+	return out(params, txt)
+}
+
+func formatMillis(millis int64) string {
+	if millis == 0 {
+		return ""
+	}
+	return model.GetTimeForMillis(millis).UTC().Format(time.RFC3339)
+}
@@ -60,10 +60,13 @@ func (s *service) allSubCommands(conf config.Config) map[string]commandHandler {
 	disenableAC.AddTextArgument("ID of the app to disable", "appID", "")
 	disenableAC.RoleID = model.SYSTEM_ADMIN_ROLE_ID
 
+	infoAC := model.NewAutocompleteData("info", "", "Display debugging information")
+	infoAC.AddTextArgument("ID of an app, to display its information (admins only)", "[appID]", "")
+
 	all := map[string]commandHandler{
 		"info": {
 			f:            s.executeInfo,
-			autoComplete: model.NewAutocompleteData("info", "", "Display debugging information"),
+			autoComplete: infoAC,
 		},
 		"list": {
 			f:            s.executeList,
@@ -83,6 +86,14 @@ func (s *service) allSubCommands(conf config.Config) map[string]commandHandler {
 		},
 	}
 
+	debugCallsAC := model.NewAutocompleteData("debug-calls", "", "Display the latest calls made to an app")
+	debugCallsAC.AddTextArgument("ID of the app", "appID", "")
+	debugCallsAC.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+	all["debug-calls"] = commandHandler{
+		f:            s.checkSystemAdmin(s.executeDebugCalls),
+		autoComplete: debugCallsAC,
+	}
+
 	if conf.DeveloperMode {
 		debugAddManifestAC := model.NewAutocompleteData("debug-add-manifest", "", "Add a manifest to the local list of known apps")
 		debugAddManifestAC.AddNamedTextArgument("url", "URL of the manifest to add", "URL", "", true)
@@ -109,7 +120,40 @@ func (s *service) allSubCommands(conf config.Config) map[string]commandHandler {
 		}
 	}
 
+	subscriptionsAC := model.NewAutocompleteData("subscriptions", "", "List the subscriptions of apps")
+	subscriptionsAC.AddTextArgument("ID of the app, all apps if omitted", "[appID]", "")
+	subscriptionsAC.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+	all["subscriptions"] = commandHandler{
+		f:            s.checkSystemAdmin(s.executeListSubscriptions),
+		autoComplete: subscriptionsAC,
+	}
+
+	schedulesAC := model.NewAutocompleteData("schedules", "", "List the schedules and the scheduled calls of apps")
+	schedulesAC.AddTextArgument("ID of the app, all apps if omitted", "[appID]", "")
+	schedulesAC.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+	all["schedules"] = commandHandler{
+		f:            s.checkSystemAdmin(s.executeListSchedules),
+		autoComplete: schedulesAC,
+	}
+
+	rotateSecretAC := model.NewAutocompleteData("rotate-secret", "", "Replace an app's secrets with newly generated ones")
+	rotateSecretAC.AddTextArgument("ID of the app", "[appID]", "")
+	rotateSecretAC.AddStaticListArgument("Secret to rotate, all if omitted", false, []model.AutocompleteListItem{
+		{Item: string(apps.SecretTypeJWT), HelpText: "The secret that the JWTs sent to the app are issued with"},
+		{Item: string(apps.SecretTypeWebhook), HelpText: "The secret of the app's webhooks"},
+		{Item: string(apps.SecretTypeBotToken), HelpText: "The access token of the app's bot"},
+	})
+	rotateSecretAC.AddTextArgument("How long the previous secret remains valid, 24h if omitted", "[grace-period]", "")
+	rotateSecretAC.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+	all["rotate-secret"] = commandHandler{
+		f:            s.checkSystemAdmin(s.executeRotateSecret),
+		autoComplete: rotateSecretAC,
+	}
+
 	all["install"] = s.installCommand(conf)
+	all["dead-letters"] = s.deadLettersCommand()
+	all["jobs"] = s.jobsCommand()
+	all["encryption"] = s.encryptionCommand()
 
 	return all
 }
@@ -194,6 +238,79 @@ func (s *service) installCommand(conf config.Config) commandHandler {
 	return h
 }
 
+func (s *service) deadLettersCommand() commandHandler {
+	listAC := model.NewAutocompleteData("list", "", "List the notifications that apps failed to receive")
+	listAC.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+
+	replayAC := model.NewAutocompleteData("replay", "", "Send failed notifications again")
+	replayAC.AddTextArgument("ID of the notification to replay, or `all`", "[notificationID|all]", "")
+	replayAC.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+
+	return commandHandler{
+		autoComplete: &model.AutocompleteData{
+			Trigger:  "dead-letters",
+			HelpText: "Inspect and replay failed notifications.",
+			RoleID:   model.SYSTEM_ADMIN_ROLE_ID,
+		},
+		subCommands: map[string]commandHandler{
+			"list": {
+				f:            s.checkSystemAdmin(s.executeListDeadLetters),
+				autoComplete: listAC,
+			},
+			"replay": {
+				f:            s.checkSystemAdmin(s.executeReplayDeadLetter),
+				autoComplete: replayAC,
+			},
+		},
+	}
+}
+
+func (s *service) jobsCommand() commandHandler {
+	listAC := model.NewAutocompleteData("list", "", "List the one-shot jobs, and their status")
+	listAC.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+
+	retryAC := model.NewAutocompleteData("retry", "", "Run a failed job again")
+	retryAC.AddTextArgument("ID of the job to retry", "[jobID]", "")
+	retryAC.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+
+	return commandHandler{
+		autoComplete: &model.AutocompleteData{
+			Trigger:  "jobs",
+			HelpText: "Inspect and retry one-shot jobs.",
+			RoleID:   model.SYSTEM_ADMIN_ROLE_ID,
+		},
+		subCommands: map[string]commandHandler{
+			"list": {
+				f:            s.checkSystemAdmin(s.executeListJobs),
+				autoComplete: listAC,
+			},
+			"retry": {
+				f:            s.checkSystemAdmin(s.executeRetryJob),
+				autoComplete: retryAC,
+			},
+		},
+	}
+}
+
+func (s *service) encryptionCommand() commandHandler {
+	rotateAC := model.NewAutocompleteData("rotate", "", "Re-encrypt the stored app secrets and OAuth2 user records with the current encryption key")
+	rotateAC.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+
+	return commandHandler{
+		autoComplete: &model.AutocompleteData{
+			Trigger:  "encryption",
+			HelpText: "Manage the encryption of the stored secrets.",
+			RoleID:   model.SYSTEM_ADMIN_ROLE_ID,
+		},
+		subCommands: map[string]commandHandler{
+			"rotate": {
+				f:            s.checkSystemAdmin(s.executeRotateEncryptionKey),
+				autoComplete: rotateAC,
+			},
+		},
+	}
+}
+
 func MakeService(mm *pluginapi.Client, log utils.Logger, configService config.Service, proxy proxy.Service, httpOut httpout.Service) (Service, error) {
 	s := &service{
 		mm:      mm,
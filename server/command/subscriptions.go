@@ -0,0 +1,48 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+)
+
+func (s *service) executeListSubscriptions(params *commandParams) (*model.CommandResponse, error) {
+	var appIDs []apps.AppID
+	if len(params.current) > 0 {
+		appIDs = append(appIDs, apps.AppID(params.current[0]))
+	} else {
+		for _, app := range s.proxy.GetInstalledApps() {
+			appIDs = append(appIDs, app.AppID)
+		}
+	}
+
+	var subs []*apps.Subscription
+	for _, appID := range appIDs {
+		appSubs, err := s.proxy.GetSubscriptions(appID)
+		if err != nil {
+			return errorOut(params, errors.Wrapf(err, "failed to get subscriptions for %s", appID))
+		}
+		subs = append(subs, appSubs...)
+	}
+	if len(subs) == 0 {
+		return out(params, "No subscriptions.")
+	}
+
+	txt := "| App | Subject | Team | Channel | Call |\n"
+	txt += "| :-- |:-- | :-- | :-- | :-- |\n"
+	for _, sub := range subs {
+		path := ""
+		if sub.Call != nil {
+			path = "`" + sub.Call.Path + "`"
+		}
+		txt += fmt.Sprintf("|`%s`|%s|%s|%s|%s|\n",
+			sub.AppID, sub.Subject, sub.TeamID, sub.ChannelID, path)
+	}
+	return out(params, txt)
+}
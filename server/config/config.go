@@ -1,10 +1,12 @@
 package config
 
 import (
+	"encoding/base64"
 	"net/url"
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	pluginapi "github.com/mattermost/mattermost-plugin-api"
 	"github.com/mattermost/mattermost-server/v5/model"
@@ -34,6 +36,57 @@ type StoredConfig struct {
 	// added, and the Manifest struct is stored in KV under
 	// manifest_<sha1(Manifest)>. Implementation in `store.Manifest`.
 	LocalManifests map[string]string `json:"local_manifests,omitempty"`
+
+	// BindingsTimeoutMillis is how long GetBindings waits for each app to
+	// respond, before using the app's last known bindings. Defaults to
+	// DefaultBindingsTimeout. AppBindingsTimeoutMillis overrides it for
+	// specific apps, as a map of AppID -> milliseconds.
+	BindingsTimeoutMillis    int            `json:"bindings_timeout_millis,omitempty"`
+	AppBindingsTimeoutMillis map[string]int `json:"app_bindings_timeout_millis,omitempty"`
+
+	// NotificationMaxAttempts is how many times a subscription notification is
+	// sent to an app before it is moved to the dead-letter list. Defaults to
+	// DefaultNotificationMaxAttempts.
+	NotificationMaxAttempts int `json:"notification_max_attempts,omitempty"`
+
+	// NotificationWorkers limits how many notifications are sent to apps at
+	// the same time, and NotificationWorkersPerApp - how many of them can go
+	// to a single app. NotificationQueueSize limits how many notifications can
+	// wait to be sent, before they are diverted to the retry queue. Default
+	// to DefaultNotificationWorkers, DefaultNotificationWorkersPerApp and
+	// DefaultNotificationQueueSize.
+	NotificationWorkers       int `json:"notification_workers,omitempty"`
+	NotificationWorkersPerApp int `json:"notification_workers_per_app,omitempty"`
+	NotificationQueueSize     int `json:"notification_queue_size,omitempty"`
+
+	// CallRatePerUser and CallRatePerApp limit how many calls per minute a user
+	// can make, and an app can receive, through the Call API. CallBurstPerUser
+	// and CallBurstPerApp are how many calls can be made at once, after a
+	// period of inactivity. MaxConcurrentCallsPerApp limits how many calls to
+	// an app can be in flight at the same time. Zero values use the defaults,
+	// negative values disable the limit.
+	CallRatePerUser          int `json:"call_rate_per_user,omitempty"`
+	CallBurstPerUser         int `json:"call_burst_per_user,omitempty"`
+	CallRatePerApp           int `json:"call_rate_per_app,omitempty"`
+	CallBurstPerApp          int `json:"call_burst_per_app,omitempty"`
+	MaxConcurrentCallsPerApp int `json:"max_concurrent_calls_per_app,omitempty"`
+
+	// KVMaxKeysPerApp and KVMaxBytesPerApp limit how many keys, and how many
+	// bytes of keys and values, each app can store in the KV store, including
+	// its per-user values. Zero values use the defaults, negative values
+	// disable the limit.
+	KVMaxKeysPerApp  int `json:"kv_max_keys_per_app,omitempty"`
+	KVMaxBytesPerApp int `json:"kv_max_bytes_per_app,omitempty"`
+
+	// EncryptionKey is used to encrypt the apps' secrets, and the OAuth2 user
+	// records in the KV store. After it is changed, PreviousEncryptionKey is
+	// used to decrypt the records that have not been re-encrypted yet, see
+	// `/apps encryption rotate`. EncryptionKeyEnvVar and
+	// PreviousEncryptionKeyEnvVar override them. The keys are 32 random bytes,
+	// base64-encoded, e.g. the output of `openssl rand -base64 32`. If no key
+	// is set, the records are stored unencrypted.
+	EncryptionKey         string `json:"encryption_key,omitempty"`
+	PreviousEncryptionKey string `json:"previous_encryption_key,omitempty"`
 }
 
 type BuildConfig struct {
@@ -67,6 +120,133 @@ type Config struct {
 	AWSAccessKey string
 	AWSSecretKey string
 	AWSS3Bucket  string
+
+	EncryptionKeys EncryptionKeys
+}
+
+// EncryptionKeys are the keys that the secrets in the KV store are encrypted
+// with. Current is used to encrypt, and both are used to decrypt.
+type EncryptionKeys struct {
+	Current  string
+	Previous string
+}
+
+// EncryptionKeySize is the size of an encryption key, before it is
+// base64-encoded.
+const EncryptionKeySize = 32
+
+// DecodeEncryptionKey decodes a configured encryption key. An empty key
+// decodes to nil.
+func DecodeEncryptionKey(key string) ([]byte, error) {
+	if key == "" {
+		return nil, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "encryption key must be base64-encoded")
+	}
+	if len(decoded) != EncryptionKeySize {
+		return nil, errors.Errorf("encryption key must be %v bytes, got %v", EncryptionKeySize, len(decoded))
+	}
+	return decoded, nil
+}
+
+// BindingsTimeout returns how long to wait for an app's bindings.
+func (conf Config) BindingsTimeout(appID apps.AppID) time.Duration {
+	if millis := conf.AppBindingsTimeoutMillis[string(appID)]; millis > 0 {
+		return time.Duration(millis) * time.Millisecond
+	}
+	if conf.BindingsTimeoutMillis > 0 {
+		return time.Duration(conf.BindingsTimeoutMillis) * time.Millisecond
+	}
+	return DefaultBindingsTimeout
+}
+
+// MaxNotificationAttempts returns how many times to try sending a
+// notification.
+func (conf Config) MaxNotificationAttempts() int {
+	if conf.NotificationMaxAttempts > 0 {
+		return conf.NotificationMaxAttempts
+	}
+	return DefaultNotificationMaxAttempts
+}
+
+func (conf Config) MaxNotificationWorkers() int {
+	if conf.NotificationWorkers > 0 {
+		return conf.NotificationWorkers
+	}
+	return DefaultNotificationWorkers
+}
+
+func (conf Config) MaxNotificationWorkersPerApp() int {
+	if conf.NotificationWorkersPerApp > 0 {
+		return conf.NotificationWorkersPerApp
+	}
+	return DefaultNotificationWorkersPerApp
+}
+
+func (conf Config) MaxNotificationsQueued() int {
+	if conf.NotificationQueueSize > 0 {
+		return conf.NotificationQueueSize
+	}
+	return DefaultNotificationQueueSize
+}
+
+// RateLimit is a token bucket limit: PerMinute tokens are added to the bucket
+// every minute, up to Burst. A zero PerMinute means there is no limit.
+type RateLimit struct {
+	PerMinute int
+	Burst     int
+}
+
+func (conf Config) UserCallRateLimit() RateLimit {
+	return newRateLimit(conf.CallRatePerUser, DefaultCallRatePerUser, conf.CallBurstPerUser, DefaultCallBurstPerUser)
+}
+
+func (conf Config) AppCallRateLimit() RateLimit {
+	return newRateLimit(conf.CallRatePerApp, DefaultCallRatePerApp, conf.CallBurstPerApp, DefaultCallBurstPerApp)
+}
+
+// MaxConcurrentCalls returns how many calls to an app can be in flight, or 0
+// if there is no limit.
+func (conf Config) MaxConcurrentCalls() int {
+	return limitOrDefault(conf.MaxConcurrentCallsPerApp, DefaultMaxConcurrentCallsPerApp)
+}
+
+// KVQuota is how much each app can store in the KV store. A zero limit means
+// there is no limit.
+type KVQuota struct {
+	MaxKeys  int `json:"max_keys"`
+	MaxBytes int `json:"max_bytes"`
+}
+
+func (conf Config) KVQuota() KVQuota {
+	return KVQuota{
+		MaxKeys:  limitOrDefault(conf.KVMaxKeysPerApp, DefaultKVMaxKeysPerApp),
+		MaxBytes: limitOrDefault(conf.KVMaxBytesPerApp, DefaultKVMaxBytesPerApp),
+	}
+}
+
+func newRateLimit(perMinute, defaultPerMinute, burst, defaultBurst int) RateLimit {
+	l := RateLimit{
+		PerMinute: limitOrDefault(perMinute, defaultPerMinute),
+		Burst:     limitOrDefault(burst, defaultBurst),
+	}
+	if l.PerMinute > 0 && l.Burst == 0 {
+		l.Burst = 1
+	}
+	return l
+}
+
+func limitOrDefault(v, def int) int {
+	switch {
+	case v < 0:
+		return 0
+	case v == 0:
+		return def
+	default:
+		return v
+	}
 }
 
 func (conf Config) SetContextDefaults(cc *apps.Context) *apps.Context {
@@ -126,6 +306,23 @@ func (conf *Config) Reconfigure(stored StoredConfig, mmconf *model.Config, licen
 	conf.AWSRegion = upaws.Region()
 	conf.AWSS3Bucket = upaws.S3BucketName()
 
+	conf.EncryptionKeys = EncryptionKeys{
+		Current:  stored.EncryptionKey,
+		Previous: stored.PreviousEncryptionKey,
+	}
+	if key := os.Getenv(EncryptionKeyEnvVar); key != "" {
+		conf.EncryptionKeys.Current = key
+	}
+	if key := os.Getenv(PreviousEncryptionKeyEnvVar); key != "" {
+		conf.EncryptionKeys.Previous = key
+	}
+	if _, err = DecodeEncryptionKey(conf.EncryptionKeys.Current); err != nil {
+		return errors.Wrap(err, "invalid encryption key")
+	}
+	if _, err = DecodeEncryptionKey(conf.EncryptionKeys.Previous); err != nil {
+		return errors.Wrap(err, "invalid previous encryption key")
+	}
+
 	conf.MattermostCloudMode = license != nil &&
 		license.Features != nil &&
 		license.Features.Cloud != nil &&
@@ -3,7 +3,11 @@
 
 package config
 
-import "github.com/mattermost/mattermost-plugin-apps/apps"
+import (
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+)
 
 // Internal configuration apps.of mattermost-plugin-apps
 const (
@@ -43,9 +47,65 @@ const (
 	// Marketplace sub-paths.
 	PathMarketplace = "/marketplace"
 
+	// Dead-letter list of the failed notifications, followed by
+	// /{NotificationID}/replay to retry one.
+	PathDeadLetters = "/dead-letters"
+	PathReplay      = "/replay"
+
+	// Latest calls made to an app, in the /apps/{AppID} API namespace.
+	PathCallHistory = "/call-history"
+
+	// KV usage and quota of an app, in the /apps/{AppID} API namespace.
+	PathKVUsage = "/kv-usage"
+
+	// Proxy metrics, in the Prometheus text format.
+	PathMetrics = "/metrics"
+
 	WebSocketEventRefreshBindings = "refresh_bindings"
 )
 
+// DefaultBindingsTimeout is the default time to wait for an app to respond to
+// a bindings call.
+const DefaultBindingsTimeout = 3 * time.Second
+
+// DefaultSecretRotationGracePeriod is how long a rotated app secret remains
+// valid, unless specified.
+const DefaultSecretRotationGracePeriod = 24 * time.Hour
+
+// DefaultNotificationMaxAttempts is the default number of times a subscription
+// notification is sent to an app before it is moved to the dead-letter list.
+const DefaultNotificationMaxAttempts = 8
+
+// Default limits of the notification dispatcher.
+const (
+	DefaultNotificationWorkers       = 20
+	DefaultNotificationWorkersPerApp = 4
+	DefaultNotificationQueueSize     = 10000
+)
+
+// Default limits of the Call API. The rates are in calls per minute, and the
+// bursts are how many calls can be made at once.
+const (
+	DefaultCallRatePerUser          = 120
+	DefaultCallBurstPerUser         = 30
+	DefaultCallRatePerApp           = 1200
+	DefaultCallBurstPerApp          = 200
+	DefaultMaxConcurrentCallsPerApp = 100
+)
+
+// Environment variables with the keys to encrypt the secrets in the KV store
+// with, they override the ones in the plugin settings.
+const (
+	EncryptionKeyEnvVar         = "MM_APPS_ENCRYPTION_KEY"          // nolint:gosec
+	PreviousEncryptionKeyEnvVar = "MM_APPS_PREVIOUS_ENCRYPTION_KEY" // nolint:gosec
+)
+
+// Default KV quota of each app.
+const (
+	DefaultKVMaxKeysPerApp  = 100000
+	DefaultKVMaxBytesPerApp = 100 * 1024 * 1024
+)
+
 const (
 	PropTeamID    = "team_id"
 	PropChannelID = "channel_id"
@@ -71,16 +131,41 @@ const (
 	// KVAppPrefix is the Apps global namespace.
 	KVAppPrefix = ".k"
 
+	// KVAppKeyIndexPrefix is used to store the index of the IDs set by each
+	// app, by namespace, and KVAppNamespacesPrefix - the namespaces each app
+	// has an index for.
+	KVAppKeyIndexPrefix   = "kvi."
+	KVAppNamespacesPrefix = "kvn."
+
+	// KVAppUsagePrefix is used to store how much of the KV store each app
+	// uses, and KVAppUsageRecountJobKey to schedule the cluster job that
+	// recounts it.
+	KVAppUsagePrefix        = "kvq."
+	KVAppUsageRecountJobKey = "KVUsageRecountJob"
+
 	// KVUserPrefix is the global namespase used to store OAuth2 user
 	// records.
 	KVUserPrefix = ".u"
 
+	// KVUserKVPrefix is the global namespace used to store the apps' per-user
+	// values, KVUserKVIndexPrefix - the IDs that an app set for a user.
+	// KVUserKVUsersPrefix is used to store the users that an app has values
+	// for, and KVUserKVPurgeJobKey to schedule the cluster job that deletes
+	// the values of the deactivated users.
+	KVUserKVPrefix      = ".v"
+	KVUserKVIndexPrefix = ".w"
+	KVUserKVUsersPrefix = "kvu."
+	KVUserKVPurgeJobKey = "UserKVPurgeJob"
+
 	// KVOAuth2StatePrefix is the global namespase used to store OAuth2
 	// ephemeral state data.
 	KVOAuth2StatePrefix = ".o"
 
-	// KVSubPrefix is used for keys storing subscriptions.
-	KVSubPrefix = "sub."
+	// KVSubPrefix is used for keys storing subscriptions, KVSubAppIndexPrefix
+	// for the lists of the subscription keys that each app has subscriptions
+	// in.
+	KVSubPrefix         = "sub."
+	KVSubAppIndexPrefix = "subapp."
 
 	// KVInstalledAppPrefix is used to store App records.
 	KVInstalledAppPrefix = "app."
@@ -88,8 +173,55 @@ const (
 	// KVLocalManifestPrefix is used to store locally-listed manifests.
 	KVLocalManifestPrefix = "man."
 
-	// KVCallOnceKey and KVClusterMutexKey are used for invoking App Calls once,
-	// usually upon a Mattermost instance startup.
-	KVCallOnceKey     = "CallOnce"
-	KVClusterMutexKey = "Cluster_Mutex"
+	// KVNotificationQueuePrefix is used to store the notifications queued to
+	// be retried, KVNotificationDeadLetterPrefix - the ones that ran out of
+	// attempts.
+	KVNotificationQueuePrefix      = "nq."
+	KVNotificationDeadLetterPrefix = "nd."
+
+	// KVNotificationQueueIndexPrefix is used to store the IDs of each app's
+	// queued notifications, and KVNotificationDeadLetterIndexPrefix - of its
+	// dead letters, so that they are listed without scanning all keys.
+	// KVNotificationQueueAppsKey and KVNotificationDeadLetterAppsKey store the
+	// IDs of the apps that have the indexes.
+	KVNotificationQueueIndexPrefix      = "nqi."
+	KVNotificationDeadLetterIndexPrefix = "ndi."
+	KVNotificationQueueAppsKey          = "nqa"
+	KVNotificationDeadLetterAppsKey     = "nda"
+
+	// KVNotificationRetryJobKey is used to schedule the cluster job that
+	// retries the queued notifications.
+	KVNotificationRetryJobKey = "NotificationRetryJob"
+
+	// KVLifecycleSnapshotPrefix is used to store the last known state of the
+	// users and channels polled for lifecycle notifications, and
+	// KVLifecyclePollJobKey and KVLifecycleUsersPollJobKey to schedule the
+	// polling cluster jobs.
+	KVLifecycleSnapshotPrefix  = "lc."
+	KVLifecyclePollJobKey      = "LifecyclePollJob"
+	KVLifecycleUsersPollJobKey = "LifecycleUsersPollJob"
+
+	// KVScheduledCallPrefix is used to store the one-time calls scheduled by
+	// the apps, KVScheduledCallIndexPrefix - the per-app indexes of their IDs,
+	// KVScheduledCallAppsKey - the apps that have an index,
+	// KVScheduleLastRunsPrefix - when the apps' schedules last ran, and
+	// KVScheduleJobKey to schedule the cluster job that makes the calls.
+	KVScheduledCallPrefix      = "sc."
+	KVScheduledCallIndexPrefix = "sci."
+	KVScheduledCallAppsKey     = "sca"
+	KVScheduleLastRunsPrefix   = "sr."
+	KVScheduleJobKey           = "ScheduleJob"
+
+	// KVJobPrefix is used to store the one-shot jobs that must run once in the
+	// cluster, KVJobIndexKey - the index of their IDs, and KVJobRunnerJobKey
+	// to schedule the cluster job that runs them.
+	KVJobPrefix       = "job."
+	KVJobIndexKey     = "jobi"
+	KVJobRunnerJobKey = "JobRunnerJob"
+
+	// KVMigrationsKey is used to store the IDs of the completed data
+	// migrations, and KVMigrationsMutexKey for the cluster mutex that they run
+	// under.
+	KVMigrationsKey      = "migrations"
+	KVMigrationsMutexKey = "MigrationsMutex"
 )
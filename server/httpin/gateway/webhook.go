@@ -1,7 +1,6 @@
 package gateway
 
 import (
-	"crypto/subtle"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -27,7 +26,7 @@ func (g *gateway) handleWebhook(w http.ResponseWriter, req *http.Request) {
 		httputils.WriteError(w, err)
 		return
 	}
-	if subtle.ConstantTimeCompare([]byte(secret), []byte(app.WebhookSecret)) != 1 {
+	if !app.IsWebhookSecret(secret) {
 		httputils.WriteError(w, utils.NewInvalidError("webhook secret mismatched"))
 		return
 	}
@@ -37,6 +37,47 @@ func (a *restapi) handleGetApp(w http.ResponseWriter, r *http.Request, pluginID,
 	httputils.WriteJSON(w, app)
 }
 
+// handleGetCallHistory returns the latest calls made to an app, as seen by the
+// node that serves the request.
+func (a *restapi) handleGetCallHistory(w http.ResponseWriter, r *http.Request, _, actingUserID string) {
+	err := utils.EnsureSysAdmin(a.mm, actingUserID)
+	if err != nil {
+		httputils.WriteError(w, errors.Wrap(err, "only admins can get the call history"))
+		return
+	}
+
+	appID := appIDVar(r)
+	if appID == "" {
+		httputils.WriteError(w, errors.Wrap(utils.ErrInvalid, "app is required"))
+		return
+	}
+
+	httputils.WriteJSON(w, a.proxy.GetCallHistory(appID))
+}
+
+// handleGetKVUsage returns how much of the KV store an app uses, and its
+// quota.
+func (a *restapi) handleGetKVUsage(w http.ResponseWriter, r *http.Request, _, actingUserID string) {
+	err := utils.EnsureSysAdmin(a.mm, actingUserID)
+	if err != nil {
+		httputils.WriteError(w, errors.Wrap(err, "only admins can get the KV usage"))
+		return
+	}
+
+	appID := appIDVar(r)
+	if appID == "" {
+		httputils.WriteError(w, errors.Wrap(utils.ErrInvalid, "app is required"))
+		return
+	}
+
+	usage, err := a.proxy.GetKVUsage(appID)
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+	httputils.WriteJSON(w, usage)
+}
+
 func (a *restapi) handleEnableApp(w http.ResponseWriter, r *http.Request, pluginID, sessionID, actingUserID string) {
 	// Only check non-plugin requests
 	if pluginID == "" {
@@ -0,0 +1,70 @@
+package restapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/proxy"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+	"github.com/mattermost/mattermost-plugin-apps/utils/httputils"
+)
+
+// handleExportApp returns a backup of an app's data, as a JSON file.
+func (a *restapi) handleExportApp(w http.ResponseWriter, r *http.Request, pluginID, _, actingUserID string) {
+	// Only check non-plugin requests
+	if pluginID == "" {
+		err := utils.EnsureSysAdmin(a.mm, actingUserID)
+		if err != nil {
+			httputils.WriteError(w, errors.Wrap(err, "only admins can export apps"))
+			return
+		}
+	}
+
+	appID := appIDVar(r)
+	if appID == "" {
+		httputils.WriteError(w, errors.Wrap(utils.ErrInvalid, "app is required"))
+		return
+	}
+
+	backup, err := a.proxy.ExportApp(appID)
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", string(appID)+"-backup.json"))
+	httputils.WriteJSON(w, backup)
+}
+
+// handleImportApp restores a backup of an app's data to the installed app.
+func (a *restapi) handleImportApp(w http.ResponseWriter, r *http.Request, pluginID, _, actingUserID string) {
+	// Only check non-plugin requests
+	if pluginID == "" {
+		err := utils.EnsureSysAdmin(a.mm, actingUserID)
+		if err != nil {
+			httputils.WriteError(w, errors.Wrap(err, "only admins can import apps"))
+			return
+		}
+	}
+
+	appID := appIDVar(r)
+	if appID == "" {
+		httputils.WriteError(w, errors.Wrap(utils.ErrInvalid, "app is required"))
+		return
+	}
+
+	var backup proxy.AppBackup
+	err := json.NewDecoder(r.Body).Decode(&backup)
+	if err != nil {
+		httputils.WriteError(w, utils.NewInvalidError(errors.Wrap(err, "failed to unmarshal backup")))
+		return
+	}
+
+	err = a.proxy.ImportApp(appID, &backup)
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+}
@@ -1,10 +1,15 @@
 package restapi
 
 import (
+	"encoding/json"
 	"net/http"
 
+	"github.com/pkg/errors"
+
 	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/apps/mmclient"
 	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
 	"github.com/mattermost/mattermost-plugin-apps/utils/httputils"
 )
 
@@ -29,3 +34,24 @@ func (a *restapi) handleGetBindings(w http.ResponseWriter, req *http.Request, se
 
 	httputils.WriteJSON(w, bindings)
 }
+
+// handleRefreshBindings is used by apps (with their bot's token) to request
+// that their bindings be fetched again, for a specific user or for everyone.
+func (a *restapi) handleRefreshBindings(w http.ResponseWriter, req *http.Request, _, actingUserID string) {
+	app, err := a.appForBot(actingUserID)
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+
+	r := mmclient.RefreshBindingsRequest{}
+	if req.ContentLength != 0 {
+		err = json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			httputils.WriteError(w, utils.NewInvalidError(errors.Wrap(err, "failed to unmarshal refresh request")))
+			return
+		}
+	}
+
+	a.proxy.RefreshBindings(app.AppID, r.UserID)
+}
@@ -1,11 +1,18 @@
 package restapi
 
 import (
+	"encoding/json"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
 
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/apps/mmclient"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
 	"github.com/mattermost/mattermost-plugin-apps/utils/httputils"
 )
 
@@ -31,10 +38,18 @@ func (a *restapi) kvPut(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// <>/<> TODO: atomic support
-	// <>/<> TODO: TTL support
+	opts, err := kvSetOptions(r.URL.Query())
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
 
-	changed, err := a.appServices.KVSet(actingID(r), prefix, id, data)
+	var changed bool
+	if opts.ExpireInSeconds == 0 && !opts.IfAbsent && opts.OldValue == nil {
+		changed, err = a.appServices.KVSet(actingID(r), prefix, id, data)
+	} else {
+		changed, err = a.appServices.KVSetWithOptions(actingID(r), prefix, id, data, opts)
+	}
 	if err != nil {
 		httputils.WriteError(w, err)
 		return
@@ -44,6 +59,58 @@ func (a *restapi) kvPut(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// kvList returns a page of the IDs that the app set in a namespace, the ones
+// that follow the "after" ID.
+func (a *restapi) kvList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	perPage := apps.MaxKVListPerPage
+	var err error
+	if v := q.Get(mmclient.QueryPerPage); v != "" {
+		if perPage, err = strconv.Atoi(v); err != nil {
+			httputils.WriteError(w, utils.NewInvalidError("invalid %s: %q", mmclient.QueryPerPage, v))
+			return
+		}
+	}
+
+	list, err := a.appServices.KVList(actingID(r), q.Get(mmclient.QueryPrefix), q.Get(mmclient.QueryAfter), perPage)
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+	httputils.WriteJSON(w, list)
+}
+
+func (a *restapi) kvGetBatch(w http.ResponseWriter, r *http.Request) {
+	var req apps.KVBatchGetRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		httputils.WriteError(w, utils.NewInvalidError(errors.Wrap(err, "failed to unmarshal batch get request")))
+		return
+	}
+
+	values, err := a.appServices.KVGetBatch(actingID(r), &req)
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+	httputils.WriteJSON(w, values)
+}
+
+func (a *restapi) kvSetBatch(w http.ResponseWriter, r *http.Request) {
+	var req apps.KVBatchSetRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		httputils.WriteError(w, utils.NewInvalidError(errors.Wrap(err, "failed to unmarshal batch set request")))
+		return
+	}
+
+	err = a.appServices.KVSetBatch(actingID(r), &req)
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+}
+
 func (a *restapi) kvDelete(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["key"]
 	prefix := mux.Vars(r)["prefix"]
@@ -54,3 +121,27 @@ func (a *restapi) kvDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// kvSetOptions parses the expiry and the conditions of a KV set from the
+// query. The old value is passed as it would be stored, i.e. JSON-encoded.
+func kvSetOptions(q url.Values) (apps.KVSetOptions, error) {
+	opts := apps.KVSetOptions{}
+	if v := q.Get(mmclient.QueryExpireInSeconds); v != "" {
+		seconds, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return opts, utils.NewInvalidError("invalid %s: %q", mmclient.QueryExpireInSeconds, v)
+		}
+		opts.ExpireInSeconds = seconds
+	}
+	if v := q.Get(mmclient.QueryIfAbsent); v != "" {
+		ifAbsent, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, utils.NewInvalidError("invalid %s: %q", mmclient.QueryIfAbsent, v)
+		}
+		opts.IfAbsent = ifAbsent
+	}
+	if _, ok := q[mmclient.QueryOldValue]; ok {
+		opts.OldValue = []byte(q.Get(mmclient.QueryOldValue))
+	}
+	return opts, opts.IsValid()
+}
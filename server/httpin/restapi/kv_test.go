@@ -5,8 +5,10 @@ package restapi
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -19,6 +21,7 @@ import (
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
 
+	"github.com/mattermost/mattermost-plugin-apps/apps"
 	"github.com/mattermost/mattermost-plugin-apps/apps/mmclient"
 	"github.com/mattermost/mattermost-plugin-apps/server/appservices"
 	"github.com/mattermost/mattermost-plugin-apps/server/config"
@@ -93,3 +96,76 @@ func TestKV(t *testing.T) {
 	require.Equal(t, http.StatusOK, resp.StatusCode)
 	resp.Body.Close()
 }
+
+func TestKVSetWithOptions(t *testing.T) {
+	testAPI := &plugintest.API{}
+	testAPI.On("GetUser", mock.Anything).Return(
+		&model.User{
+			IsBot: true,
+		}, nil)
+	mm := pluginapi.NewClient(testAPI, &plugintest.Driver{})
+
+	ctrl := gomock.NewController(t)
+	conf := config.NewTestConfigurator(config.Config{})
+	mocked := mock_store.NewMockAppKVStore(ctrl)
+	appService := appservices.NewService(mm, conf, &store.Service{
+		AppKV: mocked,
+	})
+
+	r := mux.NewRouter()
+	Init(r, mm, utils.NewTestLogger(), conf, nil, appService)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	itemURL := strings.Join([]string{strings.TrimSuffix(server.URL, "/"), mmclient.PathAPI, mmclient.PathKV, "/test-id"}, "")
+	item := `{"locked_by":"node1"}`
+
+	for name, tc := range map[string]struct {
+		query          string
+		expectedOpts   apps.KVSetOptions
+		expectedStatus int
+	}{
+		"expiry": {
+			query:          "?expire_in_seconds=60",
+			expectedOpts:   apps.KVSetOptions{ExpireInSeconds: 60},
+			expectedStatus: http.StatusOK,
+		},
+		"if absent": {
+			query:          "?if_absent=true&expire_in_seconds=10",
+			expectedOpts:   apps.KVSetOptions{IfAbsent: true, ExpireInSeconds: 10},
+			expectedStatus: http.StatusOK,
+		},
+		"compare and set": {
+			query:          "?old_value=" + url.QueryEscape(`{"locked_by":"node2"}`),
+			expectedOpts:   apps.KVSetOptions{OldValue: []byte(`{"locked_by":"node2"}`)},
+			expectedStatus: http.StatusOK,
+		},
+		"both conditions": {
+			query:          "?if_absent=true&old_value=1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"invalid expiry": {
+			query:          "?expire_in_seconds=soon",
+			expectedStatus: http.StatusBadRequest,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if tc.expectedStatus == http.StatusOK {
+				mocked.EXPECT().SetWithOptions("01234567890123456789012345", "", "test-id", []byte(item), tc.expectedOpts).Return(false, nil)
+			}
+
+			req, err := http.NewRequest("PUT", itemURL+tc.query, strings.NewReader(item))
+			require.NoError(t, err)
+			req.Header.Set("Mattermost-User-Id", "01234567890123456789012345")
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, tc.expectedStatus, resp.StatusCode)
+			if tc.expectedStatus == http.StatusOK {
+				data, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				require.JSONEq(t, `{"changed":false}`, string(data))
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package restapi
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+	"github.com/mattermost/mattermost-plugin-apps/utils/httputils"
+)
+
+// handleGetMetrics serves the metrics of the node that receives the request,
+// in the Prometheus format. Scrapers should authenticate with a system
+// admin's personal access token.
+func (a *restapi) handleGetMetrics(w http.ResponseWriter, r *http.Request, _, actingUserID string) {
+	err := utils.EnsureSysAdmin(a.mm, actingUserID)
+	if err != nil {
+		httputils.WriteError(w, errors.Wrap(err, "only admins can get metrics"))
+		return
+	}
+
+	a.proxy.MetricsHandler().ServeHTTP(w, r)
+}
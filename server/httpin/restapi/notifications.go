@@ -0,0 +1,47 @@
+package restapi
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+	"github.com/mattermost/mattermost-plugin-apps/utils/httputils"
+)
+
+func (a *restapi) handleListDeadLetters(w http.ResponseWriter, r *http.Request, _, actingUserID string) {
+	err := utils.EnsureSysAdmin(a.mm, actingUserID)
+	if err != nil {
+		httputils.WriteError(w, errors.Wrap(err, "only admins can list failed notifications"))
+		return
+	}
+
+	deadLetters, err := a.proxy.ListDeadLetters()
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, deadLetters)
+}
+
+func (a *restapi) handleReplayDeadLetter(w http.ResponseWriter, r *http.Request, _, actingUserID string) {
+	err := utils.EnsureSysAdmin(a.mm, actingUserID)
+	if err != nil {
+		httputils.WriteError(w, errors.Wrap(err, "only admins can replay failed notifications"))
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		httputils.WriteError(w, errors.Wrap(utils.ErrInvalid, "notification ID is required"))
+		return
+	}
+
+	err = a.proxy.ReplayDeadLetter(id)
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+}
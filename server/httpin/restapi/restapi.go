@@ -37,12 +37,27 @@ func Init(router *mux.Router, mm *pluginapi.Client, log utils.Logger, conf confi
 
 	subrouter.HandleFunc(apps.DefaultBindings.Path,
 		httputils.CheckAuthorized(mm, a.handleGetBindings)).Methods("GET")
+	subrouter.HandleFunc(mmclient.PathRefreshBindings,
+		httputils.CheckAuthorized(mm, a.handleRefreshBindings)).Methods("POST")
 
 	subrouter.HandleFunc(config.PathCall,
 		httputils.CheckAuthorized(mm, a.handleCall)).Methods("POST")
 
+	subrouter.HandleFunc(config.PathMetrics,
+		httputils.CheckAuthorized(mm, a.handleGetMetrics)).Methods("GET")
+
+	subrouter.HandleFunc(config.PathDeadLetters,
+		httputils.CheckAuthorized(mm, a.handleListDeadLetters)).Methods("GET")
+	subrouter.HandleFunc(config.PathDeadLetters+"/{id}"+config.PathReplay,
+		httputils.CheckAuthorized(mm, a.handleReplayDeadLetter)).Methods("POST")
+
 	subrouter.HandleFunc(mmclient.PathSubscribe, a.handleSubscribe).Methods("POST")
 	subrouter.HandleFunc(mmclient.PathUnsubscribe, a.handleUnsubscribe).Methods("POST")
+	subrouter.HandleFunc(mmclient.PathSubscriptions, a.handleGetSubscriptions).Methods("GET")
+
+	subrouter.HandleFunc(mmclient.PathScheduledCalls, a.handleScheduleCall).Methods("POST")
+	subrouter.HandleFunc(mmclient.PathScheduledCalls, a.handleGetScheduledCalls).Methods("GET")
+	subrouter.HandleFunc(mmclient.PathScheduledCalls+"/{id}", a.handleCancelScheduledCall).Methods("DELETE")
 
 	// Bot and OAuthApps checks
 	subrouter.HandleFunc(mmclient.PathBotIDs,
@@ -51,6 +66,9 @@ func Init(router *mux.Router, mm *pluginapi.Client, log utils.Logger, conf confi
 		httputils.CheckAuthorized(mm, a.handleGetOAuthAppIDs)).Methods("GET")
 
 	// KV APIs
+	subrouter.HandleFunc(mmclient.PathKV, a.kvList).Methods("GET")
+	subrouter.HandleFunc(mmclient.PathKVBatchGet, a.kvGetBatch).Methods("POST")
+	subrouter.HandleFunc(mmclient.PathKVBatchSet, a.kvSetBatch).Methods("PUT", "POST")
 	subrouter.HandleFunc(mmclient.PathKV+"/{prefix}/{key}", a.kvGet).Methods("GET")
 	subrouter.HandleFunc(mmclient.PathKV+"/{key}", a.kvGet).Methods("GET")
 	subrouter.HandleFunc(mmclient.PathKV+"/{prefix}/{key}", a.kvPut).Methods("PUT", "POST")
@@ -59,6 +77,12 @@ func Init(router *mux.Router, mm *pluginapi.Client, log utils.Logger, conf confi
 	subrouter.HandleFunc(mmclient.PathKV+"/{key}", a.kvDelete).Methods("DELETE")
 	subrouter.HandleFunc(mmclient.PathKV+"/{prefix}/{key}", a.kvDelete).Methods("DELETE")
 
+	// Per-user KV APIs
+	subrouter.HandleFunc(mmclient.PathUserKV+"/{userid}", a.userKVList).Methods("GET")
+	subrouter.HandleFunc(mmclient.PathUserKV+"/{userid}/{key}", a.userKVGet).Methods("GET")
+	subrouter.HandleFunc(mmclient.PathUserKV+"/{userid}/{key}", a.userKVPut).Methods("PUT", "POST")
+	subrouter.HandleFunc(mmclient.PathUserKV+"/{userid}/{key}", a.userKVDelete).Methods("DELETE")
+
 	// TODO appid should come from OAuth2 user session, see
 	// https://mattermost.atlassian.net/browse/MM-34377
 	subrouter.HandleFunc(mmclient.PathOAuth2App+"/{appid}", a.oauth2StoreApp).Methods("PUT", "POST")
@@ -75,7 +99,12 @@ func Init(router *mux.Router, mm *pluginapi.Client, log utils.Logger, conf confi
 	appRouter.HandleFunc("", httputils.CheckPluginIDOrUserSession(a.handleGetApp)).Methods("GET")
 	appRouter.HandleFunc(mmclient.PathEnable, httputils.CheckPluginIDOrUserSession(a.handleEnableApp)).Methods("POST")
 	appRouter.HandleFunc(mmclient.PathDisable, httputils.CheckPluginIDOrUserSession(a.handleDisableApp)).Methods("POST")
+	appRouter.HandleFunc(config.PathCallHistory, httputils.CheckAuthorized(mm, a.handleGetCallHistory)).Methods("GET")
+	appRouter.HandleFunc(config.PathKVUsage, httputils.CheckAuthorized(mm, a.handleGetKVUsage)).Methods("GET")
 	appRouter.HandleFunc(mmclient.PathUninstall, httputils.CheckPluginIDOrUserSession(a.handleUninstallApp)).Methods("DELETE")
+	appRouter.HandleFunc(mmclient.PathBackup, httputils.CheckPluginIDOrUserSession(a.handleExportApp)).Methods("GET")
+	appRouter.HandleFunc(mmclient.PathRestore, httputils.CheckPluginIDOrUserSession(a.handleImportApp)).Methods("POST")
+	appRouter.HandleFunc(mmclient.PathRotateSecret, httputils.CheckPluginIDOrUserSession(a.handleRotateSecret)).Methods("POST")
 }
 
 func actingID(r *http.Request) string {
@@ -89,3 +118,16 @@ func appIDVar(r *http.Request) apps.AppID {
 	}
 	return ""
 }
+
+// appForBot returns the installed app that the bot user belongs to.
+func (a *restapi) appForBot(botUserID string) (*apps.App, error) {
+	if botUserID == "" {
+		return nil, utils.NewUnauthorizedError("not logged in")
+	}
+	for _, app := range a.proxy.GetInstalledApps() {
+		if app.BotUserID == botUserID {
+			return app, nil
+		}
+	}
+	return nil, utils.NewForbiddenError("%s is not an app's bot user", botUserID)
+}
@@ -0,0 +1,57 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/apps/mmclient"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+	"github.com/mattermost/mattermost-plugin-apps/utils/httputils"
+)
+
+// handleRotateSecret replaces one of an app's secrets with a newly generated
+// one.
+func (a *restapi) handleRotateSecret(w http.ResponseWriter, r *http.Request, pluginID, sessionID, actingUserID string) {
+	// Only check non-plugin requests
+	if pluginID == "" {
+		err := utils.EnsureSysAdmin(a.mm, actingUserID)
+		if err != nil {
+			httputils.WriteError(w, errors.Wrap(err, "only admins can rotate app secrets"))
+			return
+		}
+	}
+
+	appID := appIDVar(r)
+	if appID == "" {
+		httputils.WriteError(w, errors.Wrap(utils.ErrInvalid, "app is required"))
+		return
+	}
+
+	var req mmclient.RotateSecretRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		httputils.WriteError(w, utils.NewInvalidError(errors.Wrap(err, "failed to unmarshal request")))
+		return
+	}
+	gracePeriod := config.DefaultSecretRotationGracePeriod
+	if req.GracePeriodSeconds != nil {
+		gracePeriod = time.Duration(*req.GracePeriodSeconds) * time.Second
+	}
+
+	cc := &apps.Context{
+		ActingUserID: actingUserID,
+		UserID:       actingUserID,
+	}
+	cc = a.conf.GetConfig().SetContextDefaultsForApp(appID, cc)
+
+	_, err = a.proxy.RotateSecret(sessionID, cc, appID, req.SecretType, gracePeriod)
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+}
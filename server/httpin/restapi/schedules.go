@@ -0,0 +1,55 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+	"github.com/mattermost/mattermost-plugin-apps/utils/httputils"
+)
+
+// handleScheduleCall is called by apps, with their bot user's token. It
+// schedules a one-time call to the app.
+func (a *restapi) handleScheduleCall(w http.ResponseWriter, r *http.Request) {
+	var req apps.ScheduleCallRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		httputils.WriteError(w, utils.NewInvalidError(errors.Wrap(err, "failed to unmarshal scheduled call")))
+		return
+	}
+
+	sc, err := a.appServices.ScheduleCall(actingID(r), &req)
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+	httputils.WriteJSONStatus(w, http.StatusCreated, sc)
+}
+
+// handleGetScheduledCalls is called by apps, with their bot user's token. It
+// returns the app's pending one-time calls.
+func (a *restapi) handleGetScheduledCalls(w http.ResponseWriter, r *http.Request) {
+	calls, err := a.appServices.ListScheduledCalls(actingID(r))
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+	httputils.WriteJSON(w, calls)
+}
+
+// handleCancelScheduledCall is called by apps, with their bot user's token. It
+// cancels one of the app's pending one-time calls.
+func (a *restapi) handleCancelScheduledCall(w http.ResponseWriter, r *http.Request) {
+	err := a.appServices.CancelScheduledCall(actingID(r), mux.Vars(r)["id"])
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+}
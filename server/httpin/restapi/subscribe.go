@@ -7,6 +7,8 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+	"github.com/mattermost/mattermost-plugin-apps/utils/httputils"
 )
 
 func (a *restapi) handleSubscribe(w http.ResponseWriter, r *http.Request) {
@@ -17,47 +19,47 @@ func (a *restapi) handleUnsubscribe(w http.ResponseWriter, r *http.Request) {
 	a.handleSubscribeCore(w, r, false)
 }
 
+// handleGetSubscriptions is called by apps, with their bot user's token. It
+// returns the app's subscriptions.
+func (a *restapi) handleGetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := a.appServices.GetSubscriptions(actingID(r))
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+	httputils.WriteJSON(w, subs)
+}
+
+// handleSubscribeCore is called by apps, with their bot user's token. The
+// subscription is made for the app the bot belongs to.
 func (a *restapi) handleSubscribeCore(w http.ResponseWriter, r *http.Request, isSubscribe bool) {
 	var err error
-	actingUserID := ""
-	// logMessage := ""
 	status := http.StatusOK
 
 	defer func() {
 		resp := apps.SubscriptionResponse{}
 		if err != nil {
 			resp.Error = errors.Wrap(err, "failed operation").Error()
-			status = http.StatusInternalServerError
-			// logMessage = "Error: " + resp.Error
+			status = httputils.ErrorStatus(err)
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(status)
-		_, _ = w.Write(resp.ToJSON())
+		httputils.WriteJSONStatus(w, status, resp)
 	}()
 
-	actingUserID = actingID(r)
-	if actingUserID == "" {
-		err = errors.New("user not logged in")
-		status = http.StatusUnauthorized
+	botUserID := actingID(r)
+	if botUserID == "" {
+		err = utils.NewUnauthorizedError("user not logged in")
 		return
 	}
 
 	var sub apps.Subscription
 	if err = json.NewDecoder(r.Body).Decode(&sub); err != nil {
-		status = http.StatusUnauthorized
+		err = utils.NewInvalidError(errors.Wrap(err, "failed to unmarshal subscription"))
 		return
 	}
 
-	// TODO replace with an appropriate API-level call that would validate,
-	// deduplicate, etc.
 	if isSubscribe {
-		err = a.appServices.Subscribe(actingUserID, &sub)
+		err = a.appServices.Subscribe(botUserID, &sub)
 	} else {
-		err = a.appServices.Unsubscribe(actingUserID, &sub)
-	}
-
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		err = a.appServices.Unsubscribe(botUserID, &sub)
 	}
 }
@@ -0,0 +1,58 @@
+package restapi
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/mattermost/mattermost-plugin-apps/utils/httputils"
+)
+
+// The per-user KV APIs are called by apps, with their bot user's token, for
+// the user in the path.
+
+func (a *restapi) userKVGet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var out interface{}
+	err := a.appServices.UserKVGet(actingID(r), vars["userid"], vars["key"], &out)
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+	httputils.WriteJSON(w, out)
+}
+
+func (a *restapi) userKVPut(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+
+	err = a.appServices.UserKVSet(actingID(r), vars["userid"], vars["key"], data)
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+}
+
+func (a *restapi) userKVDelete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	err := a.appServices.UserKVDelete(actingID(r), vars["userid"], vars["key"])
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+}
+
+// userKVList returns the IDs that the app set for the user.
+func (a *restapi) userKVList(w http.ResponseWriter, r *http.Request) {
+	ids, err := a.appServices.UserKVList(actingID(r), mux.Vars(r)["userid"])
+	if err != nil {
+		httputils.WriteError(w, err)
+		return
+	}
+	httputils.WriteJSON(w, ids)
+}
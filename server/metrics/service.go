@@ -0,0 +1,164 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+// Package metrics collects the apps proxy metrics, and exposes them in the
+// Prometheus format. The metrics are per node.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+)
+
+// Namespace is the prefix of all metric names.
+const Namespace = "mattermost_apps"
+
+// DefaultDurationBuckets are the histogram buckets for call durations, in
+// seconds.
+var DefaultDurationBuckets = []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+// Results of notification and webhook deliveries. Notifications that do not
+// match the subscription's filter are counted as ResultFiltered, and those
+// dropped because the app lost access to the subscription's channel or team
+// as ResultForbidden.
+const (
+	ResultDelivered = "delivered"
+	ResultFailed    = "failed"
+	ResultFiltered  = "filtered"
+	ResultForbidden = "forbidden"
+)
+
+type Service interface {
+	// ObserveCall records a call made to an app. callType is the
+	// apps.CallType, empty for the calls that have none, e.g. notifications.
+	// source is what made the call, e.g. "call" or "notification".
+	ObserveCall(appID apps.AppID, upstreamType apps.AppType, source, callType string, d time.Duration, failed bool)
+	ObserveBindings(appID apps.AppID, d time.Duration, timedOut bool)
+	ObserveNotification(appID apps.AppID, subject apps.Subject, result string)
+	ObserveWebhook(appID apps.AppID, result string)
+	ObserveStatic(appID apps.AppID, upstreamType apps.AppType, status int, d time.Duration)
+
+	// MustRegister adds collectors that are read at the time of the scrape,
+	// e.g. the notification dispatcher's load.
+	MustRegister(...prometheus.Collector)
+	Handler() http.Handler
+}
+
+type service struct {
+	registry *prometheus.Registry
+
+	calls            *prometheus.CounterVec
+	callDuration     *prometheus.HistogramVec
+	bindingsDuration *prometheus.HistogramVec
+	bindingsTimeouts *prometheus.CounterVec
+	notifications    *prometheus.CounterVec
+	webhooks         *prometheus.CounterVec
+	static           *prometheus.CounterVec
+	staticDuration   *prometheus.HistogramVec
+}
+
+var _ Service = (*service)(nil)
+
+func NewService() Service {
+	s := &service{
+		registry: prometheus.NewRegistry(),
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "calls_total",
+			Help:      "Calls made to apps.",
+		}, []string{"app_id", "upstream", "source", "call_type", "result"}),
+		callDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "call_duration_seconds",
+			Help:      "Duration of the calls made to apps, including the upstream roundtrip.",
+			Buckets:   DefaultDurationBuckets,
+		}, []string{"app_id", "upstream", "source", "call_type"}),
+		bindingsDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "bindings_duration_seconds",
+			Help:      "Time to fetch an app's bindings, as seen by GetBindings.",
+			Buckets:   DefaultDurationBuckets,
+		}, []string{"app_id"}),
+		bindingsTimeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "bindings_timeouts_total",
+			Help:      "Bindings fetches that timed out, and used the last known bindings.",
+		}, []string{"app_id"}),
+		notifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "notifications_total",
+			Help:      "Subscription notifications, by the result of each attempt to send them, or filtered if the post did not match the subscription's filter.",
+		}, []string{"app_id", "subject", "result"}),
+		webhooks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "webhooks_total",
+			Help:      "Remote webhooks forwarded to apps.",
+		}, []string{"app_id", "result"}),
+		static: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "static_assets_total",
+			Help:      "Static asset requests made to the upstream, by the HTTP status of the response.",
+		}, []string{"app_id", "upstream", "status"}),
+		staticDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "static_asset_duration_seconds",
+			Help:      "Time to get a static asset from the upstream.",
+			Buckets:   DefaultDurationBuckets,
+		}, []string{"app_id", "upstream"}),
+	}
+
+	s.registry.MustRegister(
+		s.calls,
+		s.callDuration,
+		s.bindingsDuration,
+		s.bindingsTimeouts,
+		s.notifications,
+		s.webhooks,
+		s.static,
+		s.staticDuration,
+	)
+	return s
+}
+
+func (s *service) ObserveCall(appID apps.AppID, upstreamType apps.AppType, source, callType string, d time.Duration, failed bool) {
+	result := "ok"
+	if failed {
+		result = "error"
+	}
+	s.calls.WithLabelValues(string(appID), string(upstreamType), source, callType, result).Inc()
+	s.callDuration.WithLabelValues(string(appID), string(upstreamType), source, callType).Observe(d.Seconds())
+}
+
+func (s *service) ObserveBindings(appID apps.AppID, d time.Duration, timedOut bool) {
+	if timedOut {
+		s.bindingsTimeouts.WithLabelValues(string(appID)).Inc()
+	}
+	s.bindingsDuration.WithLabelValues(string(appID)).Observe(d.Seconds())
+}
+
+func (s *service) ObserveNotification(appID apps.AppID, subject apps.Subject, result string) {
+	s.notifications.WithLabelValues(string(appID), string(subject), result).Inc()
+}
+
+func (s *service) ObserveWebhook(appID apps.AppID, result string) {
+	s.webhooks.WithLabelValues(string(appID), result).Inc()
+}
+
+func (s *service) ObserveStatic(appID apps.AppID, upstreamType apps.AppType, status int, d time.Duration) {
+	s.static.WithLabelValues(string(appID), string(upstreamType), strconv.Itoa(status)).Inc()
+	s.staticDuration.WithLabelValues(string(appID), string(upstreamType)).Observe(d.Seconds())
+}
+
+func (s *service) MustRegister(collectors ...prometheus.Collector) {
+	s.registry.MustRegister(collectors...)
+}
+
+func (s *service) Handler() http.Handler {
+	return promhttp.HandlerFor(s.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,34 @@
+// +build !e2e
+
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	s := NewService()
+	s.ObserveCall("app1", "aws_lambda", "call", "submit", 200*time.Millisecond, false)
+	s.ObserveCall("app1", "aws_lambda", "call", "submit", time.Second, true)
+	s.ObserveCall("app1", "aws_lambda", "notification", "", time.Second, false)
+	s.ObserveBindings("app1", 4*time.Second, true)
+	s.ObserveNotification("app1", "user_joined_channel", ResultDelivered)
+	s.ObserveStatic("app1", "aws_lambda", 404, 10*time.Millisecond)
+
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	out := w.Body.String()
+	require.Contains(t, out, `mattermost_apps_calls_total{app_id="app1",call_type="submit",result="ok",source="call",upstream="aws_lambda"} 1`)
+	require.Contains(t, out, `mattermost_apps_calls_total{app_id="app1",call_type="submit",result="error",source="call",upstream="aws_lambda"} 1`)
+	require.Contains(t, out, `mattermost_apps_calls_total{app_id="app1",call_type="",result="ok",source="notification",upstream="aws_lambda"} 1`)
+	require.Contains(t, out, `mattermost_apps_call_duration_seconds_count{app_id="app1",call_type="submit",source="call",upstream="aws_lambda"} 2`)
+	require.Contains(t, out, `mattermost_apps_bindings_timeouts_total{app_id="app1"} 1`)
+	require.Contains(t, out, `mattermost_apps_notifications_total{app_id="app1",result="delivered",subject="user_joined_channel"} 1`)
+	require.Contains(t, out, `mattermost_apps_static_assets_total{app_id="app1",status="404",upstream="aws_lambda"} 1`)
+}
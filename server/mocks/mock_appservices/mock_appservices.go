@@ -5,6 +5,7 @@
 package mock_appservices
 
 import (
+	jsontext "encoding/json/jsontext"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,6 +35,20 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// CancelScheduledCall mocks base method.
+func (m *MockService) CancelScheduledCall(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelScheduledCall", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelScheduledCall indicates an expected call of CancelScheduledCall.
+func (mr *MockServiceMockRecorder) CancelScheduledCall(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScheduledCall", reflect.TypeOf((*MockService)(nil).CancelScheduledCall), arg0, arg1)
+}
+
 // GetOAuth2User mocks base method.
 func (m *MockService) GetOAuth2User(arg0 apps.AppID, arg1 string, arg2 interface{}) error {
 	m.ctrl.T.Helper()
@@ -48,6 +63,21 @@ func (mr *MockServiceMockRecorder) GetOAuth2User(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOAuth2User", reflect.TypeOf((*MockService)(nil).GetOAuth2User), arg0, arg1, arg2)
 }
 
+// GetSubscriptions mocks base method.
+func (m *MockService) GetSubscriptions(arg0 string) ([]*apps.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscriptions", arg0)
+	ret0, _ := ret[0].([]*apps.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscriptions indicates an expected call of GetSubscriptions.
+func (mr *MockServiceMockRecorder) GetSubscriptions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptions", reflect.TypeOf((*MockService)(nil).GetSubscriptions), arg0)
+}
+
 // KVDelete mocks base method.
 func (m *MockService) KVDelete(arg0, arg1, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -76,6 +106,36 @@ func (mr *MockServiceMockRecorder) KVGet(arg0, arg1, arg2, arg3 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KVGet", reflect.TypeOf((*MockService)(nil).KVGet), arg0, arg1, arg2, arg3)
 }
 
+// KVGetBatch mocks base method.
+func (m *MockService) KVGetBatch(arg0 string, arg1 *apps.KVBatchGetRequest) (map[string]jsontext.Value, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KVGetBatch", arg0, arg1)
+	ret0, _ := ret[0].(map[string]jsontext.Value)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KVGetBatch indicates an expected call of KVGetBatch.
+func (mr *MockServiceMockRecorder) KVGetBatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KVGetBatch", reflect.TypeOf((*MockService)(nil).KVGetBatch), arg0, arg1)
+}
+
+// KVList mocks base method.
+func (m *MockService) KVList(arg0, arg1, arg2 string, arg3 int) (*apps.KVListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KVList", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*apps.KVListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KVList indicates an expected call of KVList.
+func (mr *MockServiceMockRecorder) KVList(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KVList", reflect.TypeOf((*MockService)(nil).KVList), arg0, arg1, arg2, arg3)
+}
+
 // KVSet mocks base method.
 func (m *MockService) KVSet(arg0, arg1, arg2 string, arg3 interface{}) (bool, error) {
 	m.ctrl.T.Helper()
@@ -91,6 +151,65 @@ func (mr *MockServiceMockRecorder) KVSet(arg0, arg1, arg2, arg3 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KVSet", reflect.TypeOf((*MockService)(nil).KVSet), arg0, arg1, arg2, arg3)
 }
 
+// KVSetBatch mocks base method.
+func (m *MockService) KVSetBatch(arg0 string, arg1 *apps.KVBatchSetRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KVSetBatch", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// KVSetBatch indicates an expected call of KVSetBatch.
+func (mr *MockServiceMockRecorder) KVSetBatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KVSetBatch", reflect.TypeOf((*MockService)(nil).KVSetBatch), arg0, arg1)
+}
+
+// KVSetWithOptions mocks base method.
+func (m *MockService) KVSetWithOptions(arg0, arg1, arg2 string, arg3 interface{}, arg4 apps.KVSetOptions) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KVSetWithOptions", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KVSetWithOptions indicates an expected call of KVSetWithOptions.
+func (mr *MockServiceMockRecorder) KVSetWithOptions(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KVSetWithOptions", reflect.TypeOf((*MockService)(nil).KVSetWithOptions), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ListScheduledCalls mocks base method.
+func (m *MockService) ListScheduledCalls(arg0 string) ([]*apps.ScheduledCall, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledCalls", arg0)
+	ret0, _ := ret[0].([]*apps.ScheduledCall)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledCalls indicates an expected call of ListScheduledCalls.
+func (mr *MockServiceMockRecorder) ListScheduledCalls(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledCalls", reflect.TypeOf((*MockService)(nil).ListScheduledCalls), arg0)
+}
+
+// ScheduleCall mocks base method.
+func (m *MockService) ScheduleCall(arg0 string, arg1 *apps.ScheduleCallRequest) (*apps.ScheduledCall, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleCall", arg0, arg1)
+	ret0, _ := ret[0].(*apps.ScheduledCall)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScheduleCall indicates an expected call of ScheduleCall.
+func (mr *MockServiceMockRecorder) ScheduleCall(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleCall", reflect.TypeOf((*MockService)(nil).ScheduleCall), arg0, arg1)
+}
+
 // StoreOAuth2App mocks base method.
 func (m *MockService) StoreOAuth2App(arg0 apps.AppID, arg1 string, arg2 apps.OAuth2App) error {
 	m.ctrl.T.Helper()
@@ -146,3 +265,60 @@ func (mr *MockServiceMockRecorder) Unsubscribe(arg0, arg1 interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsubscribe", reflect.TypeOf((*MockService)(nil).Unsubscribe), arg0, arg1)
 }
+
+// UserKVDelete mocks base method.
+func (m *MockService) UserKVDelete(arg0, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserKVDelete", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UserKVDelete indicates an expected call of UserKVDelete.
+func (mr *MockServiceMockRecorder) UserKVDelete(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserKVDelete", reflect.TypeOf((*MockService)(nil).UserKVDelete), arg0, arg1, arg2)
+}
+
+// UserKVGet mocks base method.
+func (m *MockService) UserKVGet(arg0, arg1, arg2 string, arg3 interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserKVGet", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UserKVGet indicates an expected call of UserKVGet.
+func (mr *MockServiceMockRecorder) UserKVGet(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserKVGet", reflect.TypeOf((*MockService)(nil).UserKVGet), arg0, arg1, arg2, arg3)
+}
+
+// UserKVList mocks base method.
+func (m *MockService) UserKVList(arg0, arg1 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserKVList", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserKVList indicates an expected call of UserKVList.
+func (mr *MockServiceMockRecorder) UserKVList(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserKVList", reflect.TypeOf((*MockService)(nil).UserKVList), arg0, arg1)
+}
+
+// UserKVSet mocks base method.
+func (m *MockService) UserKVSet(arg0, arg1, arg2 string, arg3 interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserKVSet", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UserKVSet indicates an expected call of UserKVSet.
+func (mr *MockServiceMockRecorder) UserKVSet(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserKVSet", reflect.TypeOf((*MockService)(nil).UserKVSet), arg0, arg1, arg2, arg3)
+}
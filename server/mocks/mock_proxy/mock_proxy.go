@@ -6,11 +6,15 @@ package mock_proxy
 
 import (
 	io "io"
+	http "net/http"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	apps "github.com/mattermost/mattermost-plugin-apps/apps"
 	mmclient "github.com/mattermost/mattermost-plugin-apps/mmclient"
+	proxy "github.com/mattermost/mattermost-plugin-apps/server/proxy"
+	store "github.com/mattermost/mattermost-plugin-apps/server/store"
 	upstream "github.com/mattermost/mattermost-plugin-apps/upstream"
 )
 
@@ -121,6 +125,20 @@ func (mr *MockServiceMockRecorder) DisableApp(arg0, arg1, arg2, arg3 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableApp", reflect.TypeOf((*MockService)(nil).DisableApp), arg0, arg1, arg2, arg3)
 }
 
+// DispatcherStats mocks base method.
+func (m *MockService) DispatcherStats() proxy.DispatcherStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DispatcherStats")
+	ret0, _ := ret[0].(proxy.DispatcherStats)
+	return ret0
+}
+
+// DispatcherStats indicates an expected call of DispatcherStats.
+func (mr *MockServiceMockRecorder) DispatcherStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DispatcherStats", reflect.TypeOf((*MockService)(nil).DispatcherStats))
+}
+
 // EnableApp mocks base method.
 func (m *MockService) EnableApp(arg0 mmclient.Client, arg1 string, arg2 *apps.Context, arg3 apps.AppID) (string, error) {
 	m.ctrl.T.Helper()
@@ -136,6 +154,21 @@ func (mr *MockServiceMockRecorder) EnableApp(arg0, arg1, arg2, arg3 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableApp", reflect.TypeOf((*MockService)(nil).EnableApp), arg0, arg1, arg2, arg3)
 }
 
+// ExportApp mocks base method.
+func (m *MockService) ExportApp(arg0 apps.AppID) (*proxy.AppBackup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportApp", arg0)
+	ret0, _ := ret[0].(*proxy.AppBackup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportApp indicates an expected call of ExportApp.
+func (mr *MockServiceMockRecorder) ExportApp(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportApp", reflect.TypeOf((*MockService)(nil).ExportApp), arg0)
+}
+
 // GetBindings mocks base method.
 func (m *MockService) GetBindings(arg0, arg1 string, arg2 *apps.Context) ([]*apps.Binding, error) {
 	m.ctrl.T.Helper()
@@ -151,6 +184,20 @@ func (mr *MockServiceMockRecorder) GetBindings(arg0, arg1, arg2 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBindings", reflect.TypeOf((*MockService)(nil).GetBindings), arg0, arg1, arg2)
 }
 
+// GetCallHistory mocks base method.
+func (m *MockService) GetCallHistory(arg0 apps.AppID) []proxy.CallRecord {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCallHistory", arg0)
+	ret0, _ := ret[0].([]proxy.CallRecord)
+	return ret0
+}
+
+// GetCallHistory indicates an expected call of GetCallHistory.
+func (mr *MockServiceMockRecorder) GetCallHistory(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCallHistory", reflect.TypeOf((*MockService)(nil).GetCallHistory), arg0)
+}
+
 // GetInstalledApp mocks base method.
 func (m *MockService) GetInstalledApp(arg0 apps.AppID) (*apps.App, error) {
 	m.ctrl.T.Helper()
@@ -180,6 +227,21 @@ func (mr *MockServiceMockRecorder) GetInstalledApps() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstalledApps", reflect.TypeOf((*MockService)(nil).GetInstalledApps))
 }
 
+// GetKVUsage mocks base method.
+func (m *MockService) GetKVUsage(arg0 apps.AppID) (*proxy.KVUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKVUsage", arg0)
+	ret0, _ := ret[0].(*proxy.KVUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKVUsage indicates an expected call of GetKVUsage.
+func (mr *MockServiceMockRecorder) GetKVUsage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKVUsage", reflect.TypeOf((*MockService)(nil).GetKVUsage), arg0)
+}
+
 // GetListedApps mocks base method.
 func (m *MockService) GetListedApps(arg0 string, arg1 bool) []*apps.ListedApp {
 	m.ctrl.T.Helper()
@@ -239,6 +301,21 @@ func (mr *MockServiceMockRecorder) GetRemoteOAuth2ConnectURL(arg0, arg1, arg2 in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRemoteOAuth2ConnectURL", reflect.TypeOf((*MockService)(nil).GetRemoteOAuth2ConnectURL), arg0, arg1, arg2)
 }
 
+// GetSchedules mocks base method.
+func (m *MockService) GetSchedules(arg0 apps.AppID) ([]proxy.ScheduleStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedules", arg0)
+	ret0, _ := ret[0].([]proxy.ScheduleStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchedules indicates an expected call of GetSchedules.
+func (mr *MockServiceMockRecorder) GetSchedules(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedules", reflect.TypeOf((*MockService)(nil).GetSchedules), arg0)
+}
+
 // GetStatic mocks base method.
 func (m *MockService) GetStatic(arg0 apps.AppID, arg1 string) (io.ReadCloser, int, error) {
 	m.ctrl.T.Helper()
@@ -255,6 +332,35 @@ func (mr *MockServiceMockRecorder) GetStatic(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatic", reflect.TypeOf((*MockService)(nil).GetStatic), arg0, arg1)
 }
 
+// GetSubscriptions mocks base method.
+func (m *MockService) GetSubscriptions(arg0 apps.AppID) ([]*apps.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscriptions", arg0)
+	ret0, _ := ret[0].([]*apps.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscriptions indicates an expected call of GetSubscriptions.
+func (mr *MockServiceMockRecorder) GetSubscriptions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptions", reflect.TypeOf((*MockService)(nil).GetSubscriptions), arg0)
+}
+
+// ImportApp mocks base method.
+func (m *MockService) ImportApp(arg0 apps.AppID, arg1 *proxy.AppBackup) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportApp", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ImportApp indicates an expected call of ImportApp.
+func (mr *MockServiceMockRecorder) ImportApp(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportApp", reflect.TypeOf((*MockService)(nil).ImportApp), arg0, arg1)
+}
+
 // InstallApp mocks base method.
 func (m *MockService) InstallApp(arg0 mmclient.Client, arg1 string, arg2 *apps.Context, arg3 bool, arg4, arg5 string) (*apps.App, string, error) {
 	m.ctrl.T.Helper()
@@ -271,6 +377,65 @@ func (mr *MockServiceMockRecorder) InstallApp(arg0, arg1, arg2, arg3, arg4, arg5
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallApp", reflect.TypeOf((*MockService)(nil).InstallApp), arg0, arg1, arg2, arg3, arg4, arg5)
 }
 
+// ListDeadLetters mocks base method.
+func (m *MockService) ListDeadLetters() ([]*store.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeadLetters")
+	ret0, _ := ret[0].([]*store.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeadLetters indicates an expected call of ListDeadLetters.
+func (mr *MockServiceMockRecorder) ListDeadLetters() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeadLetters", reflect.TypeOf((*MockService)(nil).ListDeadLetters))
+}
+
+// ListJobs mocks base method.
+func (m *MockService) ListJobs() ([]*store.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListJobs")
+	ret0, _ := ret[0].([]*store.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListJobs indicates an expected call of ListJobs.
+func (mr *MockServiceMockRecorder) ListJobs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListJobs", reflect.TypeOf((*MockService)(nil).ListJobs))
+}
+
+// ListScheduledCalls mocks base method.
+func (m *MockService) ListScheduledCalls(arg0 apps.AppID) ([]*apps.ScheduledCall, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledCalls", arg0)
+	ret0, _ := ret[0].([]*apps.ScheduledCall)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledCalls indicates an expected call of ListScheduledCalls.
+func (mr *MockServiceMockRecorder) ListScheduledCalls(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledCalls", reflect.TypeOf((*MockService)(nil).ListScheduledCalls), arg0)
+}
+
+// MetricsHandler mocks base method.
+func (m *MockService) MetricsHandler() http.Handler {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MetricsHandler")
+	ret0, _ := ret[0].(http.Handler)
+	return ret0
+}
+
+// MetricsHandler indicates an expected call of MetricsHandler.
+func (mr *MockServiceMockRecorder) MetricsHandler() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MetricsHandler", reflect.TypeOf((*MockService)(nil).MetricsHandler))
+}
+
 // Notify mocks base method.
 func (m *MockService) Notify(arg0 *apps.Context, arg1 apps.Subject) error {
 	m.ctrl.T.Helper()
@@ -299,6 +464,160 @@ func (mr *MockServiceMockRecorder) NotifyRemoteWebhook(arg0, arg1, arg2 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NotifyRemoteWebhook", reflect.TypeOf((*MockService)(nil).NotifyRemoteWebhook), arg0, arg1, arg2)
 }
 
+// PollLifecycleEvents mocks base method.
+func (m *MockService) PollLifecycleEvents() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PollLifecycleEvents")
+}
+
+// PollLifecycleEvents indicates an expected call of PollLifecycleEvents.
+func (mr *MockServiceMockRecorder) PollLifecycleEvents() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PollLifecycleEvents", reflect.TypeOf((*MockService)(nil).PollLifecycleEvents))
+}
+
+// PollUserLifecycleEvents mocks base method.
+func (m *MockService) PollUserLifecycleEvents() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PollUserLifecycleEvents")
+}
+
+// PollUserLifecycleEvents indicates an expected call of PollUserLifecycleEvents.
+func (mr *MockServiceMockRecorder) PollUserLifecycleEvents() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PollUserLifecycleEvents", reflect.TypeOf((*MockService)(nil).PollUserLifecycleEvents))
+}
+
+// PurgeInactiveUserKV mocks base method.
+func (m *MockService) PurgeInactiveUserKV() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PurgeInactiveUserKV")
+}
+
+// PurgeInactiveUserKV indicates an expected call of PurgeInactiveUserKV.
+func (mr *MockServiceMockRecorder) PurgeInactiveUserKV() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeInactiveUserKV", reflect.TypeOf((*MockService)(nil).PurgeInactiveUserKV))
+}
+
+// RecountKVUsage mocks base method.
+func (m *MockService) RecountKVUsage() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecountKVUsage")
+}
+
+// RecountKVUsage indicates an expected call of RecountKVUsage.
+func (mr *MockServiceMockRecorder) RecountKVUsage() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecountKVUsage", reflect.TypeOf((*MockService)(nil).RecountKVUsage))
+}
+
+// RefreshBindings mocks base method.
+func (m *MockService) RefreshBindings(arg0 apps.AppID, arg1 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RefreshBindings", arg0, arg1)
+}
+
+// RefreshBindings indicates an expected call of RefreshBindings.
+func (mr *MockServiceMockRecorder) RefreshBindings(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshBindings", reflect.TypeOf((*MockService)(nil).RefreshBindings), arg0, arg1)
+}
+
+// ReplayDeadLetter mocks base method.
+func (m *MockService) ReplayDeadLetter(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayDeadLetter", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplayDeadLetter indicates an expected call of ReplayDeadLetter.
+func (mr *MockServiceMockRecorder) ReplayDeadLetter(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayDeadLetter", reflect.TypeOf((*MockService)(nil).ReplayDeadLetter), arg0)
+}
+
+// RetryJob mocks base method.
+func (m *MockService) RetryJob(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryJob", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryJob indicates an expected call of RetryJob.
+func (mr *MockServiceMockRecorder) RetryJob(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryJob", reflect.TypeOf((*MockService)(nil).RetryJob), arg0)
+}
+
+// RetryNotifications mocks base method.
+func (m *MockService) RetryNotifications() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RetryNotifications")
+}
+
+// RetryNotifications indicates an expected call of RetryNotifications.
+func (mr *MockServiceMockRecorder) RetryNotifications() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryNotifications", reflect.TypeOf((*MockService)(nil).RetryNotifications))
+}
+
+// RotateEncryptionKey mocks base method.
+func (m *MockService) RotateEncryptionKey() (*proxy.EncryptionRotation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateEncryptionKey")
+	ret0, _ := ret[0].(*proxy.EncryptionRotation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateEncryptionKey indicates an expected call of RotateEncryptionKey.
+func (mr *MockServiceMockRecorder) RotateEncryptionKey() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateEncryptionKey", reflect.TypeOf((*MockService)(nil).RotateEncryptionKey))
+}
+
+// RotateSecret mocks base method.
+func (m *MockService) RotateSecret(arg0 string, arg1 *apps.Context, arg2 apps.AppID, arg3 apps.SecretType, arg4 time.Duration) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSecret", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateSecret indicates an expected call of RotateSecret.
+func (mr *MockServiceMockRecorder) RotateSecret(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSecret", reflect.TypeOf((*MockService)(nil).RotateSecret), arg0, arg1, arg2, arg3, arg4)
+}
+
+// RunJobs mocks base method.
+func (m *MockService) RunJobs() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RunJobs")
+}
+
+// RunJobs indicates an expected call of RunJobs.
+func (mr *MockServiceMockRecorder) RunJobs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunJobs", reflect.TypeOf((*MockService)(nil).RunJobs))
+}
+
+// RunSchedules mocks base method.
+func (m *MockService) RunSchedules() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RunSchedules")
+}
+
+// RunSchedules indicates an expected call of RunSchedules.
+func (mr *MockServiceMockRecorder) RunSchedules() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunSchedules", reflect.TypeOf((*MockService)(nil).RunSchedules))
+}
+
 // SynchronizeInstalledApps mocks base method.
 func (m *MockService) SynchronizeInstalledApps() error {
 	m.ctrl.T.Helper()
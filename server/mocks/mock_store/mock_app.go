@@ -106,6 +106,21 @@ func (mr *MockAppStoreMockRecorder) InitBuiltin(arg0 ...interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitBuiltin", reflect.TypeOf((*MockAppStore)(nil).InitBuiltin), arg0...)
 }
 
+// ReencryptSecrets mocks base method.
+func (m *MockAppStore) ReencryptSecrets() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReencryptSecrets")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReencryptSecrets indicates an expected call of ReencryptSecrets.
+func (mr *MockAppStoreMockRecorder) ReencryptSecrets() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReencryptSecrets", reflect.TypeOf((*MockAppStore)(nil).ReencryptSecrets))
+}
+
 // Save mocks base method.
 func (m *MockAppStore) Save(arg0 *apps.App) error {
 	m.ctrl.T.Helper()
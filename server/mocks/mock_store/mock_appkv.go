@@ -5,9 +5,12 @@
 package mock_store
 
 import (
+	jsontext "encoding/json/jsontext"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	apps "github.com/mattermost/mattermost-plugin-apps/apps"
+	store "github.com/mattermost/mattermost-plugin-apps/server/store"
 )
 
 // MockAppKVStore is a mock of AppKVStore interface.
@@ -75,6 +78,79 @@ func (mr *MockAppKVStoreMockRecorder) Get(arg0, arg1, arg2, arg3 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockAppKVStore)(nil).Get), arg0, arg1, arg2, arg3)
 }
 
+// GetBatch mocks base method.
+func (m *MockAppKVStore) GetBatch(arg0, arg1 string, arg2 []string) (map[string]jsontext.Value, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBatch", arg0, arg1, arg2)
+	ret0, _ := ret[0].(map[string]jsontext.Value)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBatch indicates an expected call of GetBatch.
+func (mr *MockAppKVStoreMockRecorder) GetBatch(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBatch", reflect.TypeOf((*MockAppKVStore)(nil).GetBatch), arg0, arg1, arg2)
+}
+
+// GetUsage mocks base method.
+func (m *MockAppKVStore) GetUsage(arg0 string) (*store.KVUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsage", arg0)
+	ret0, _ := ret[0].(*store.KVUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsage indicates an expected call of GetUsage.
+func (mr *MockAppKVStoreMockRecorder) GetUsage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsage", reflect.TypeOf((*MockAppKVStore)(nil).GetUsage), arg0)
+}
+
+// InitIndex mocks base method.
+func (m *MockAppKVStore) InitIndex(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InitIndex", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InitIndex indicates an expected call of InitIndex.
+func (mr *MockAppKVStoreMockRecorder) InitIndex(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitIndex", reflect.TypeOf((*MockAppKVStore)(nil).InitIndex), arg0)
+}
+
+// ListKeys mocks base method.
+func (m *MockAppKVStore) ListKeys(arg0, arg1, arg2 string, arg3 int) (*apps.KVListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListKeys", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*apps.KVListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListKeys indicates an expected call of ListKeys.
+func (mr *MockAppKVStoreMockRecorder) ListKeys(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKeys", reflect.TypeOf((*MockAppKVStore)(nil).ListKeys), arg0, arg1, arg2, arg3)
+}
+
+// RecountUsage mocks base method.
+func (m *MockAppKVStore) RecountUsage(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecountUsage", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecountUsage indicates an expected call of RecountUsage.
+func (mr *MockAppKVStoreMockRecorder) RecountUsage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecountUsage", reflect.TypeOf((*MockAppKVStore)(nil).RecountUsage), arg0)
+}
+
 // Set mocks base method.
 func (m *MockAppKVStore) Set(arg0, arg1, arg2 string, arg3 interface{}) (bool, error) {
 	m.ctrl.T.Helper()
@@ -89,3 +165,32 @@ func (mr *MockAppKVStoreMockRecorder) Set(arg0, arg1, arg2, arg3 interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockAppKVStore)(nil).Set), arg0, arg1, arg2, arg3)
 }
+
+// SetBatch mocks base method.
+func (m *MockAppKVStore) SetBatch(arg0, arg1 string, arg2 map[string]interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBatch", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBatch indicates an expected call of SetBatch.
+func (mr *MockAppKVStoreMockRecorder) SetBatch(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBatch", reflect.TypeOf((*MockAppKVStore)(nil).SetBatch), arg0, arg1, arg2)
+}
+
+// SetWithOptions mocks base method.
+func (m *MockAppKVStore) SetWithOptions(arg0, arg1, arg2 string, arg3 interface{}, arg4 apps.KVSetOptions) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWithOptions", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetWithOptions indicates an expected call of SetWithOptions.
+func (mr *MockAppKVStoreMockRecorder) SetWithOptions(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWithOptions", reflect.TypeOf((*MockAppKVStore)(nil).SetWithOptions), arg0, arg1, arg2, arg3, arg4)
+}
@@ -0,0 +1,64 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mattermost/mattermost-plugin-apps/server/store (interfaces: BackupStore)
+
+// Package mock_store is a generated GoMock package.
+package mock_store
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	store "github.com/mattermost/mattermost-plugin-apps/server/store"
+)
+
+// MockBackupStore is a mock of BackupStore interface.
+type MockBackupStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockBackupStoreMockRecorder
+}
+
+// MockBackupStoreMockRecorder is the mock recorder for MockBackupStore.
+type MockBackupStoreMockRecorder struct {
+	mock *MockBackupStore
+}
+
+// NewMockBackupStore creates a new mock instance.
+func NewMockBackupStore(ctrl *gomock.Controller) *MockBackupStore {
+	mock := &MockBackupStore{ctrl: ctrl}
+	mock.recorder = &MockBackupStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBackupStore) EXPECT() *MockBackupStoreMockRecorder {
+	return m.recorder
+}
+
+// ExportKV mocks base method.
+func (m *MockBackupStore) ExportKV(arg0 string) ([]store.KVRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportKV", arg0)
+	ret0, _ := ret[0].([]store.KVRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportKV indicates an expected call of ExportKV.
+func (mr *MockBackupStoreMockRecorder) ExportKV(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportKV", reflect.TypeOf((*MockBackupStore)(nil).ExportKV), arg0)
+}
+
+// ImportKV mocks base method.
+func (m *MockBackupStore) ImportKV(arg0, arg1 string, arg2 []store.KVRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportKV", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ImportKV indicates an expected call of ImportKV.
+func (mr *MockBackupStoreMockRecorder) ImportKV(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportKV", reflect.TypeOf((*MockBackupStore)(nil).ImportKV), arg0, arg1, arg2)
+}
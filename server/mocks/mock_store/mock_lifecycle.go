@@ -0,0 +1,78 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mattermost/mattermost-plugin-apps/server/store (interfaces: LifecycleStore)
+
+// Package mock_store is a generated GoMock package.
+package mock_store
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	store "github.com/mattermost/mattermost-plugin-apps/server/store"
+)
+
+// MockLifecycleStore is a mock of LifecycleStore interface.
+type MockLifecycleStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockLifecycleStoreMockRecorder
+}
+
+// MockLifecycleStoreMockRecorder is the mock recorder for MockLifecycleStore.
+type MockLifecycleStoreMockRecorder struct {
+	mock *MockLifecycleStore
+}
+
+// NewMockLifecycleStore creates a new mock instance.
+func NewMockLifecycleStore(ctrl *gomock.Controller) *MockLifecycleStore {
+	mock := &MockLifecycleStore{ctrl: ctrl}
+	mock.recorder = &MockLifecycleStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLifecycleStore) EXPECT() *MockLifecycleStoreMockRecorder {
+	return m.recorder
+}
+
+// DeleteSnapshot mocks base method.
+func (m *MockLifecycleStore) DeleteSnapshot(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSnapshot", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSnapshot indicates an expected call of DeleteSnapshot.
+func (mr *MockLifecycleStoreMockRecorder) DeleteSnapshot(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSnapshot", reflect.TypeOf((*MockLifecycleStore)(nil).DeleteSnapshot), arg0)
+}
+
+// GetSnapshot mocks base method.
+func (m *MockLifecycleStore) GetSnapshot(arg0 string) (map[string]store.EntityState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSnapshot", arg0)
+	ret0, _ := ret[0].(map[string]store.EntityState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSnapshot indicates an expected call of GetSnapshot.
+func (mr *MockLifecycleStoreMockRecorder) GetSnapshot(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSnapshot", reflect.TypeOf((*MockLifecycleStore)(nil).GetSnapshot), arg0)
+}
+
+// SaveSnapshot mocks base method.
+func (m *MockLifecycleStore) SaveSnapshot(arg0 string, arg1, arg2 map[string]store.EntityState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSnapshot", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSnapshot indicates an expected call of SaveSnapshot.
+func (mr *MockLifecycleStoreMockRecorder) SaveSnapshot(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSnapshot", reflect.TypeOf((*MockLifecycleStore)(nil).SaveSnapshot), arg0, arg1, arg2)
+}
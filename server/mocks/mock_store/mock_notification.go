@@ -0,0 +1,137 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mattermost/mattermost-plugin-apps/server/store (interfaces: NotificationStore)
+
+// Package mock_store is a generated GoMock package.
+package mock_store
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	apps "github.com/mattermost/mattermost-plugin-apps/apps"
+	store "github.com/mattermost/mattermost-plugin-apps/server/store"
+)
+
+// MockNotificationStore is a mock of NotificationStore interface.
+type MockNotificationStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationStoreMockRecorder
+}
+
+// MockNotificationStoreMockRecorder is the mock recorder for MockNotificationStore.
+type MockNotificationStoreMockRecorder struct {
+	mock *MockNotificationStore
+}
+
+// NewMockNotificationStore creates a new mock instance.
+func NewMockNotificationStore(ctrl *gomock.Controller) *MockNotificationStore {
+	mock := &MockNotificationStore{ctrl: ctrl}
+	mock.recorder = &MockNotificationStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationStore) EXPECT() *MockNotificationStoreMockRecorder {
+	return m.recorder
+}
+
+// DeleteDeadLetter mocks base method.
+func (m *MockNotificationStore) DeleteDeadLetter(arg0 apps.AppID, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeadLetter", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDeadLetter indicates an expected call of DeleteDeadLetter.
+func (mr *MockNotificationStoreMockRecorder) DeleteDeadLetter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeadLetter", reflect.TypeOf((*MockNotificationStore)(nil).DeleteDeadLetter), arg0, arg1)
+}
+
+// DeleteQueued mocks base method.
+func (m *MockNotificationStore) DeleteQueued(arg0 apps.AppID, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteQueued", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteQueued indicates an expected call of DeleteQueued.
+func (mr *MockNotificationStoreMockRecorder) DeleteQueued(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteQueued", reflect.TypeOf((*MockNotificationStore)(nil).DeleteQueued), arg0, arg1)
+}
+
+// GetDeadLetter mocks base method.
+func (m *MockNotificationStore) GetDeadLetter(arg0 string) (*store.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeadLetter", arg0)
+	ret0, _ := ret[0].(*store.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeadLetter indicates an expected call of GetDeadLetter.
+func (mr *MockNotificationStoreMockRecorder) GetDeadLetter(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeadLetter", reflect.TypeOf((*MockNotificationStore)(nil).GetDeadLetter), arg0)
+}
+
+// ListDeadLetters mocks base method.
+func (m *MockNotificationStore) ListDeadLetters() ([]*store.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeadLetters")
+	ret0, _ := ret[0].([]*store.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeadLetters indicates an expected call of ListDeadLetters.
+func (mr *MockNotificationStoreMockRecorder) ListDeadLetters() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeadLetters", reflect.TypeOf((*MockNotificationStore)(nil).ListDeadLetters))
+}
+
+// ListQueued mocks base method.
+func (m *MockNotificationStore) ListQueued() ([]*store.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListQueued")
+	ret0, _ := ret[0].([]*store.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListQueued indicates an expected call of ListQueued.
+func (mr *MockNotificationStoreMockRecorder) ListQueued() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListQueued", reflect.TypeOf((*MockNotificationStore)(nil).ListQueued))
+}
+
+// Queue mocks base method.
+func (m *MockNotificationStore) Queue(arg0 *store.Notification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Queue", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Queue indicates an expected call of Queue.
+func (mr *MockNotificationStoreMockRecorder) Queue(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Queue", reflect.TypeOf((*MockNotificationStore)(nil).Queue), arg0)
+}
+
+// SaveDeadLetter mocks base method.
+func (m *MockNotificationStore) SaveDeadLetter(arg0 *store.Notification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveDeadLetter", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveDeadLetter indicates an expected call of SaveDeadLetter.
+func (mr *MockNotificationStoreMockRecorder) SaveDeadLetter(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDeadLetter", reflect.TypeOf((*MockNotificationStore)(nil).SaveDeadLetter), arg0)
+}
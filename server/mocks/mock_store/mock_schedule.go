@@ -0,0 +1,136 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mattermost/mattermost-plugin-apps/server/store (interfaces: ScheduleStore)
+
+// Package mock_store is a generated GoMock package.
+package mock_store
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	apps "github.com/mattermost/mattermost-plugin-apps/apps"
+)
+
+// MockScheduleStore is a mock of ScheduleStore interface.
+type MockScheduleStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduleStoreMockRecorder
+}
+
+// MockScheduleStoreMockRecorder is the mock recorder for MockScheduleStore.
+type MockScheduleStoreMockRecorder struct {
+	mock *MockScheduleStore
+}
+
+// NewMockScheduleStore creates a new mock instance.
+func NewMockScheduleStore(ctrl *gomock.Controller) *MockScheduleStore {
+	mock := &MockScheduleStore{ctrl: ctrl}
+	mock.recorder = &MockScheduleStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduleStore) EXPECT() *MockScheduleStoreMockRecorder {
+	return m.recorder
+}
+
+// DeleteCall mocks base method.
+func (m *MockScheduleStore) DeleteCall(arg0 apps.AppID, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCall", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCall indicates an expected call of DeleteCall.
+func (mr *MockScheduleStoreMockRecorder) DeleteCall(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCall", reflect.TypeOf((*MockScheduleStore)(nil).DeleteCall), arg0, arg1)
+}
+
+// DeleteLastRuns mocks base method.
+func (m *MockScheduleStore) DeleteLastRuns(arg0 apps.AppID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLastRuns", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLastRuns indicates an expected call of DeleteLastRuns.
+func (mr *MockScheduleStoreMockRecorder) DeleteLastRuns(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLastRuns", reflect.TypeOf((*MockScheduleStore)(nil).DeleteLastRuns), arg0)
+}
+
+// GetCall mocks base method.
+func (m *MockScheduleStore) GetCall(arg0 string) (*apps.ScheduledCall, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCall", arg0)
+	ret0, _ := ret[0].(*apps.ScheduledCall)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCall indicates an expected call of GetCall.
+func (mr *MockScheduleStoreMockRecorder) GetCall(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCall", reflect.TypeOf((*MockScheduleStore)(nil).GetCall), arg0)
+}
+
+// GetLastRuns mocks base method.
+func (m *MockScheduleStore) GetLastRuns(arg0 apps.AppID) (map[string]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastRuns", arg0)
+	ret0, _ := ret[0].(map[string]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastRuns indicates an expected call of GetLastRuns.
+func (mr *MockScheduleStoreMockRecorder) GetLastRuns(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastRuns", reflect.TypeOf((*MockScheduleStore)(nil).GetLastRuns), arg0)
+}
+
+// ListCalls mocks base method.
+func (m *MockScheduleStore) ListCalls(arg0 apps.AppID) ([]*apps.ScheduledCall, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCalls", arg0)
+	ret0, _ := ret[0].([]*apps.ScheduledCall)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCalls indicates an expected call of ListCalls.
+func (mr *MockScheduleStoreMockRecorder) ListCalls(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCalls", reflect.TypeOf((*MockScheduleStore)(nil).ListCalls), arg0)
+}
+
+// SaveCall mocks base method.
+func (m *MockScheduleStore) SaveCall(arg0 *apps.ScheduledCall) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCall", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCall indicates an expected call of SaveCall.
+func (mr *MockScheduleStoreMockRecorder) SaveCall(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCall", reflect.TypeOf((*MockScheduleStore)(nil).SaveCall), arg0)
+}
+
+// SaveLastRuns mocks base method.
+func (m *MockScheduleStore) SaveLastRuns(arg0 apps.AppID, arg1 map[string]int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveLastRuns", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveLastRuns indicates an expected call of SaveLastRuns.
+func (mr *MockScheduleStoreMockRecorder) SaveLastRuns(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveLastRuns", reflect.TypeOf((*MockScheduleStore)(nil).SaveLastRuns), arg0, arg1)
+}
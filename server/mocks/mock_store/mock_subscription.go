@@ -0,0 +1,122 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mattermost/mattermost-plugin-apps/server/store (interfaces: SubscriptionStore)
+
+// Package mock_store is a generated GoMock package.
+package mock_store
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	apps "github.com/mattermost/mattermost-plugin-apps/apps"
+)
+
+// MockSubscriptionStore is a mock of SubscriptionStore interface.
+type MockSubscriptionStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockSubscriptionStoreMockRecorder
+}
+
+// MockSubscriptionStoreMockRecorder is the mock recorder for MockSubscriptionStore.
+type MockSubscriptionStoreMockRecorder struct {
+	mock *MockSubscriptionStore
+}
+
+// NewMockSubscriptionStore creates a new mock instance.
+func NewMockSubscriptionStore(ctrl *gomock.Controller) *MockSubscriptionStore {
+	mock := &MockSubscriptionStore{ctrl: ctrl}
+	mock.recorder = &MockSubscriptionStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSubscriptionStore) EXPECT() *MockSubscriptionStoreMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockSubscriptionStore) Delete(arg0 *apps.Subscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockSubscriptionStoreMockRecorder) Delete(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSubscriptionStore)(nil).Delete), arg0)
+}
+
+// DeleteByApp mocks base method.
+func (m *MockSubscriptionStore) DeleteByApp(arg0 apps.AppID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByApp", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByApp indicates an expected call of DeleteByApp.
+func (mr *MockSubscriptionStoreMockRecorder) DeleteByApp(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByApp", reflect.TypeOf((*MockSubscriptionStore)(nil).DeleteByApp), arg0)
+}
+
+// Get mocks base method.
+func (m *MockSubscriptionStore) Get(arg0 apps.Subject, arg1, arg2 string) ([]*apps.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*apps.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockSubscriptionStoreMockRecorder) Get(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockSubscriptionStore)(nil).Get), arg0, arg1, arg2)
+}
+
+// ListByApp mocks base method.
+func (m *MockSubscriptionStore) ListByApp(arg0 apps.AppID) ([]*apps.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByApp", arg0)
+	ret0, _ := ret[0].([]*apps.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByApp indicates an expected call of ListByApp.
+func (mr *MockSubscriptionStoreMockRecorder) ListByApp(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByApp", reflect.TypeOf((*MockSubscriptionStore)(nil).ListByApp), arg0)
+}
+
+// ListSubscribedChannels mocks base method.
+func (m *MockSubscriptionStore) ListSubscribedChannels(arg0 apps.Subject) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubscribedChannels", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscribedChannels indicates an expected call of ListSubscribedChannels.
+func (mr *MockSubscriptionStoreMockRecorder) ListSubscribedChannels(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscribedChannels", reflect.TypeOf((*MockSubscriptionStore)(nil).ListSubscribedChannels), arg0)
+}
+
+// Save mocks base method.
+func (m *MockSubscriptionStore) Save(arg0 *apps.Subscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockSubscriptionStoreMockRecorder) Save(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockSubscriptionStore)(nil).Save), arg0)
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/mattermost/mattermost-plugin-apps/apps"
 	"github.com/mattermost/mattermost-plugin-apps/examples/go/hello/http_hello"
 	"github.com/mattermost/mattermost-plugin-apps/server/appservices"
+	"github.com/mattermost/mattermost-plugin-apps/server/clusterevents"
 	"github.com/mattermost/mattermost-plugin-apps/server/command"
 	"github.com/mattermost/mattermost-plugin-apps/server/config"
 	"github.com/mattermost/mattermost-plugin-apps/server/httpin"
@@ -24,6 +25,7 @@ import (
 	"github.com/mattermost/mattermost-plugin-apps/server/httpin/gateway"
 	"github.com/mattermost/mattermost-plugin-apps/server/httpin/restapi"
 	"github.com/mattermost/mattermost-plugin-apps/server/httpout"
+	"github.com/mattermost/mattermost-plugin-apps/server/metrics"
 	"github.com/mattermost/mattermost-plugin-apps/server/proxy"
 	"github.com/mattermost/mattermost-plugin-apps/server/store"
 	"github.com/mattermost/mattermost-plugin-apps/upstream/upaws"
@@ -34,10 +36,12 @@ type Plugin struct {
 	plugin.MattermostPlugin
 	config.BuildConfig
 
-	mm   *pluginapi.Client
-	conf config.Service
-	log  utils.Logger
-	aws  upaws.Client
+	mm            *pluginapi.Client
+	conf          config.Service
+	log           utils.Logger
+	aws           upaws.Client
+	clusterEvents clusterevents.Service
+	metrics       metrics.Service
 
 	store       *store.Service
 	appservices appservices.Service
@@ -45,6 +49,14 @@ type Plugin struct {
 
 	command command.Service
 
+	notificationRetryJob *cluster.Job
+	lifecyclePollJob     *cluster.Job
+	lifecycleUsersJob    *cluster.Job
+	userKVPurgeJob       *cluster.Job
+	kvUsageRecountJob    *cluster.Job
+	scheduleJob          *cluster.Job
+	jobRunnerJob         *cluster.Job
+
 	httpIn  httpin.Service
 	httpOut httpout.Service
 }
@@ -98,7 +110,12 @@ func (p *Plugin) OnActivate() error {
 	p.httpOut = httpout.NewService(p.conf)
 	p.log.Debugf("Initialized outgoing HTTP")
 
-	p.store = store.NewService(p.mm, p.log, p.conf, p.aws, conf.AWSS3Bucket)
+	p.clusterEvents = clusterevents.NewService(p.API, p.log)
+	p.log.Debugf("Initialized cluster events")
+
+	p.metrics = metrics.NewService()
+
+	p.store = store.NewService(p.mm, p.log, p.conf, p.aws, conf.AWSS3Bucket, p.clusterEvents)
 	// manifest store
 	mstore := p.store.Manifest
 	mstore.Configure(conf)
@@ -108,17 +125,64 @@ func (p *Plugin) OnActivate() error {
 			return errors.Wrap(err, "failed to initialize the global manifest list from marketplace")
 		}
 	}
+	err = p.migrate()
+	if err != nil {
+		return err
+	}
 	// app store
 	appstore := p.store.App
 	appstore.Configure(conf)
 	p.log.Debugf("Initialized the persistent store")
 
-	mutex, err := cluster.NewMutex(p.API, config.KVClusterMutexKey)
+	p.proxy = proxy.NewService(p.mm, p.log, p.conf, p.aws, conf.AWSS3Bucket, p.store, p.httpOut, p.clusterEvents, p.metrics)
+	p.log.Debugf("Initialized the app proxy")
+
+	p.notificationRetryJob, err = cluster.Schedule(p.API, config.KVNotificationRetryJobKey,
+		cluster.MakeWaitForInterval(proxy.NotificationRetryInterval), p.proxy.RetryNotifications)
 	if err != nil {
-		return errors.Wrapf(err, "failed creating cluster mutex")
+		return errors.Wrap(err, "failed to schedule the notification retry job")
 	}
-	p.proxy = proxy.NewService(p.mm, p.log, p.conf, p.aws, conf.AWSS3Bucket, p.store, mutex, p.httpOut)
-	p.log.Debugf("Initialized the app proxy")
+	p.log.Debugf("Scheduled the notification retry job")
+
+	p.lifecyclePollJob, err = cluster.Schedule(p.API, config.KVLifecyclePollJobKey,
+		cluster.MakeWaitForInterval(proxy.LifecyclePollInterval), p.proxy.PollLifecycleEvents)
+	if err != nil {
+		return errors.Wrap(err, "failed to schedule the lifecycle polling job")
+	}
+	p.lifecycleUsersJob, err = cluster.Schedule(p.API, config.KVLifecycleUsersPollJobKey,
+		cluster.MakeWaitForInterval(proxy.LifecycleUsersPollInterval), p.proxy.PollUserLifecycleEvents)
+	if err != nil {
+		return errors.Wrap(err, "failed to schedule the user lifecycle polling job")
+	}
+	p.log.Debugf("Scheduled the lifecycle polling jobs")
+
+	p.userKVPurgeJob, err = cluster.Schedule(p.API, config.KVUserKVPurgeJobKey,
+		cluster.MakeWaitForInterval(proxy.UserKVPurgeInterval), p.proxy.PurgeInactiveUserKV)
+	if err != nil {
+		return errors.Wrap(err, "failed to schedule the user KV purge job")
+	}
+	p.log.Debugf("Scheduled the user KV purge job")
+
+	p.kvUsageRecountJob, err = cluster.Schedule(p.API, config.KVAppUsageRecountJobKey,
+		cluster.MakeWaitForInterval(proxy.KVUsageRecountInterval), p.proxy.RecountKVUsage)
+	if err != nil {
+		return errors.Wrap(err, "failed to schedule the KV usage recount job")
+	}
+	p.log.Debugf("Scheduled the KV usage recount job")
+
+	p.scheduleJob, err = cluster.Schedule(p.API, config.KVScheduleJobKey,
+		cluster.MakeWaitForInterval(proxy.ScheduleInterval), p.proxy.RunSchedules)
+	if err != nil {
+		return errors.Wrap(err, "failed to schedule the app schedules job")
+	}
+	p.log.Debugf("Scheduled the app schedules job")
+
+	p.jobRunnerJob, err = cluster.Schedule(p.API, config.KVJobRunnerJobKey,
+		cluster.MakeWaitForInterval(proxy.JobRunInterval), p.proxy.RunJobs)
+	if err != nil {
+		return errors.Wrap(err, "failed to schedule the job runner")
+	}
+	p.log.Debugf("Scheduled the job runner")
 
 	p.appservices = appservices.NewService(p.mm, p.conf, p.store)
 	p.log.Debugf("Initialized the app REST APIs")
@@ -149,6 +213,47 @@ func (p *Plugin) OnActivate() error {
 	return nil
 }
 
+// migrate runs the store's data migrations, on one node at a time.
+func (p *Plugin) migrate() error {
+	mutex, err := cluster.NewMutex(p.API, config.KVMigrationsMutexKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the migrations mutex")
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	err = p.store.Migrate()
+	if err != nil {
+		return errors.Wrap(err, "failed to migrate the stored data")
+	}
+	return nil
+}
+
+func (p *Plugin) OnDeactivate() error {
+	if p.notificationRetryJob != nil {
+		_ = p.notificationRetryJob.Close()
+	}
+	if p.lifecyclePollJob != nil {
+		_ = p.lifecyclePollJob.Close()
+	}
+	if p.lifecycleUsersJob != nil {
+		_ = p.lifecycleUsersJob.Close()
+	}
+	if p.userKVPurgeJob != nil {
+		_ = p.userKVPurgeJob.Close()
+	}
+	if p.kvUsageRecountJob != nil {
+		_ = p.kvUsageRecountJob.Close()
+	}
+	if p.scheduleJob != nil {
+		_ = p.scheduleJob.Close()
+	}
+	if p.jobRunnerJob != nil {
+		_ = p.jobRunnerJob.Close()
+	}
+	return nil
+}
+
 func (p *Plugin) OnConfigurationChange() error {
 	if p.conf == nil {
 		// pre-activate, nothing to do.
@@ -166,6 +271,14 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 	return resp, nil
 }
 
+func (p *Plugin) OnPluginClusterEvent(pluginContext *plugin.Context, ev model.PluginClusterEvent) {
+	if p.clusterEvents == nil {
+		// pre-activate, nothing to do.
+		return
+	}
+	p.clusterEvents.OnPluginClusterEvent(ev)
+}
+
 func (p *Plugin) ServeHTTP(c *plugin.Context, w gohttp.ResponseWriter, req *gohttp.Request) {
 	p.httpIn.ServeHTTP(c, w, req)
 }
@@ -197,18 +310,58 @@ func (p *Plugin) UserHasLeftTeam(pluginContext *plugin.Context, tm *model.TeamMe
 }
 
 func (p *Plugin) MessageHasBeenPosted(pluginContext *plugin.Context, post *model.Post) {
-	shouldProcessMessage, err := p.Helpers.ShouldProcessMessage(post, plugin.BotID(p.conf.GetConfig().BotUserID))
-	if err != nil {
-		p.log.WithError(err).Errorf("Error while checking if the message should be processed")
+	if !p.shouldProcessMessage(post) {
 		return
 	}
 
-	if !shouldProcessMessage {
+	_ = p.proxy.Notify(
+		p.newPostContext(post), apps.SubjectPostCreated)
+}
+
+func (p *Plugin) MessageHasBeenUpdated(pluginContext *plugin.Context, newPost, oldPost *model.Post) {
+	if !p.shouldProcessMessage(newPost) {
 		return
 	}
 
 	_ = p.proxy.Notify(
-		p.newPostCreatedContext(post), apps.SubjectPostCreated)
+		p.newPostContext(newPost), apps.SubjectPostUpdated)
+}
+
+func (p *Plugin) ReactionHasBeenAdded(pluginContext *plugin.Context, reaction *model.Reaction) {
+	p.notifyReaction(reaction, apps.SubjectReactionAdded)
+}
+
+func (p *Plugin) ReactionHasBeenRemoved(pluginContext *plugin.Context, reaction *model.Reaction) {
+	p.notifyReaction(reaction, apps.SubjectReactionRemoved)
+}
+
+func (p *Plugin) notifyReaction(reaction *model.Reaction, subject apps.Subject) {
+	if reaction.UserId == p.conf.GetConfig().BotUserID {
+		return
+	}
+
+	// Reactions do not include the channel, it is needed to find the
+	// subscriptions.
+	post, err := p.mm.Post.GetPost(reaction.PostId)
+	if err != nil {
+		p.log.WithError(err).Warnw("Failed to get the post of a reaction",
+			"post_id", reaction.PostId, "subject", subject)
+		return
+	}
+
+	cc := p.newPostContext(post)
+	cc.UserID = reaction.UserId
+	cc.Reaction = reaction
+	_ = p.proxy.Notify(cc, subject)
+}
+
+func (p *Plugin) shouldProcessMessage(post *model.Post) bool {
+	shouldProcessMessage, err := p.Helpers.ShouldProcessMessage(post, plugin.BotID(p.conf.GetConfig().BotUserID))
+	if err != nil {
+		p.log.WithError(err).Errorf("Error while checking if the message should be processed")
+		return false
+	}
+	return shouldProcessMessage
 }
 
 func (p *Plugin) ChannelHasBeenCreated(pluginContext *plugin.Context, ch *model.Channel) {
@@ -225,7 +378,7 @@ func (p *Plugin) ChannelHasBeenCreated(pluginContext *plugin.Context, ch *model.
 	_ = p.proxy.Notify(cc, apps.SubjectChannelCreated)
 }
 
-func (p *Plugin) newPostCreatedContext(post *model.Post) *apps.Context {
+func (p *Plugin) newPostContext(post *model.Post) *apps.Context {
 	return p.conf.GetConfig().SetContextDefaults(&apps.Context{
 		UserAgentContext: apps.UserAgentContext{
 			PostID:     post.Id,
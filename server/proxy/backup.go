@@ -0,0 +1,146 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package proxy
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/appservices"
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+// AppBackupVersion is the version of the AppBackup format.
+const AppBackupVersion = 1
+
+// AppBackup is the data that the proxy keeps for an app, to move it to
+// another server, or to restore it.
+type AppBackup struct {
+	Version   int   `json:"version"`
+	CreatedAt int64 `json:"created_at"`
+
+	// App is the app's record, without its credentials. It is not restored:
+	// the app must be installed before its backup is restored, and the
+	// installation creates the bot and the credentials. The app's remote
+	// OAuth2 app needs to be stored again.
+	App apps.App `json:"app"`
+
+	// KV has the app's values, per-user values, and its users' OAuth2
+	// records. The keys include the app's bot user ID, they are remapped to
+	// the bot of the app that the backup is restored to.
+	KV []store.KVRecord `json:"kv,omitempty"`
+
+	// Subscriptions are checked as if the app subscribed again: the ones that
+	// the app can not make on this server, e.g. to the channels and teams of
+	// another server, are dropped.
+	Subscriptions []*apps.Subscription `json:"subscriptions,omitempty"`
+}
+
+// ExportApp returns a backup of an app's data.
+func (p *Proxy) ExportApp(appID apps.AppID) (*AppBackup, error) {
+	app, err := p.store.App.Get(appID)
+	if err != nil {
+		return nil, err
+	}
+	kv, err := p.store.Backup.ExportKV(app.BotUserID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to export KV data")
+	}
+	subs, err := p.store.Subscription.ListByApp(appID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to export subscriptions")
+	}
+
+	p.log.Infow("Exported app data", "app_id", appID, "kv_records", len(kv), "subscriptions", len(subs))
+	return &AppBackup{
+		Version:       AppBackupVersion,
+		CreatedAt:     model.GetMillis(),
+		App:           withoutCredentials(*app),
+		KV:            kv,
+		Subscriptions: subs,
+	}, nil
+}
+
+// ImportApp restores a backup of an app's data to the installed app, replacing
+// its KV data and subscriptions. The imported data is saved before the app's
+// other data is deleted, so a failed import does not lose the app's data.
+func (p *Proxy) ImportApp(appID apps.AppID, backup *AppBackup) error {
+	if backup.Version != AppBackupVersion {
+		return utils.NewInvalidError("unsupported backup version %v", backup.Version)
+	}
+	if backup.App.AppID != appID {
+		return utils.NewInvalidError("backup is for app %s, not %s", backup.App.AppID, appID)
+	}
+	app, err := p.store.App.Get(appID)
+	if err != nil {
+		return err
+	}
+
+	err = p.store.Backup.ImportKV(backup.App.BotUserID, app.BotUserID, backup.KV)
+	if err != nil {
+		return errors.Wrap(err, "failed to import KV data")
+	}
+
+	existing, err := p.store.Subscription.ListByApp(appID)
+	if err != nil {
+		return errors.Wrap(err, "failed to list subscriptions")
+	}
+	var imported []*apps.Subscription
+	for _, sub := range backup.Subscriptions {
+		sub.AppID = appID
+		err = sub.Validate()
+		if err == nil {
+			err = appservices.EnsureCanSubscribe(p.mm, app, sub)
+		}
+		if err != nil {
+			p.log.WithError(err).Warnw("Dropped an imported subscription", "app_id", appID,
+				"subject", sub.Subject, "channel_id", sub.ChannelID, "team_id", sub.TeamID)
+			continue
+		}
+		err = p.store.Subscription.Save(sub)
+		if err != nil {
+			return errors.Wrapf(err, "failed to import subscription to %s", sub.Subject)
+		}
+		imported = append(imported, sub)
+	}
+	for _, sub := range existing {
+		if containsSubscriptionScope(imported, sub) {
+			continue
+		}
+		err = p.store.Subscription.Delete(sub)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete subscription to %s", sub.Subject)
+		}
+	}
+
+	p.log.Infow("Imported app data", "app_id", appID,
+		"from_bot_user_id", backup.App.BotUserID, "kv_records", len(backup.KV),
+		"subscriptions", len(imported), "dropped_subscriptions", len(backup.Subscriptions)-len(imported))
+	return nil
+}
+
+func containsSubscriptionScope(subs []*apps.Subscription, sub *apps.Subscription) bool {
+	for _, s := range subs {
+		if s.EqualScope(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func withoutCredentials(app apps.App) apps.App {
+	app.Secret = ""
+	app.WebhookSecret = ""
+	app.BotAccessToken = ""
+	app.BotAccessTokenID = ""
+	app.MattermostOAuth2 = apps.OAuth2App{}
+	app.RemoteOAuth2 = apps.OAuth2App{}
+	app.PreviousSecret = apps.RotatedSecret{}
+	app.PreviousWebhookSecret = apps.RotatedSecret{}
+	app.PreviousBotAccessToken = apps.RotatedSecret{}
+	return app
+}
@@ -0,0 +1,122 @@
+// +build !e2e
+
+package proxy
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/mocks/mock_store"
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestBackupApp(t *testing.T) {
+	app := &apps.App{
+		Manifest:       apps.Manifest{AppID: "app1"},
+		BotUserID:      "botid",
+		Secret:         "secret",
+		WebhookSecret:  "webhook-secret",
+		BotAccessToken: "token",
+		RemoteOAuth2:   apps.OAuth2App{ClientID: "id", ClientSecret: "secret"},
+		GrantedPermissions: apps.Permissions{
+			apps.PermissionActAsAdmin,
+			apps.PermissionUserJoinedChannelNotification,
+		},
+	}
+	records := []store.KVRecord{{Key: ".kbotid", Value: []byte("1")}}
+	subs := []*apps.Subscription{{AppID: "app1", Subject: apps.SubjectUserCreated}}
+
+	setup := func(t *testing.T) (*Proxy, *mock_store.MockBackupStore, *mock_store.MockSubscriptionStore) {
+		ctrl := gomock.NewController(t)
+		testAPI := &plugintest.API{}
+		testAPI.On("HasPermissionToChannel", "botid", "channel1", model.PERMISSION_READ_CHANNEL).Return(true)
+		testAPI.On("HasPermissionToChannel", "botid", "foreign", model.PERMISSION_READ_CHANNEL).Return(false)
+		appStore := mock_store.NewMockAppStore(ctrl)
+		appStore.EXPECT().Get(apps.AppID("app1")).Return(app, nil).AnyTimes()
+		backupStore := mock_store.NewMockBackupStore(ctrl)
+		subStore := mock_store.NewMockSubscriptionStore(ctrl)
+		return &Proxy{
+			mm:  pluginapi.NewClient(testAPI, &plugintest.Driver{}),
+			log: utils.NewTestLogger(),
+			store: &store.Service{
+				App:          appStore,
+				Backup:       backupStore,
+				Subscription: subStore,
+			},
+		}, backupStore, subStore
+	}
+
+	t.Run("export", func(t *testing.T) {
+		p, backupStore, subStore := setup(t)
+		backupStore.EXPECT().ExportKV("botid").Return(records, nil)
+		subStore.EXPECT().ListByApp(apps.AppID("app1")).Return(subs, nil)
+
+		backup, err := p.ExportApp("app1")
+		require.NoError(t, err)
+		require.Equal(t, AppBackupVersion, backup.Version)
+		require.Equal(t, records, backup.KV)
+		require.Equal(t, subs, backup.Subscriptions)
+		require.Equal(t, "botid", backup.App.BotUserID)
+		require.Empty(t, backup.App.Secret)
+		require.Empty(t, backup.App.WebhookSecret)
+		require.Empty(t, backup.App.BotAccessToken)
+		require.Empty(t, backup.App.RemoteOAuth2)
+		require.Equal(t, "secret", app.Secret)
+	})
+
+	t.Run("import", func(t *testing.T) {
+		p, backupStore, subStore := setup(t)
+		backupStore.EXPECT().ImportKV("oldbotid", "botid", records).Return(nil)
+		subStore.EXPECT().ListByApp(apps.AppID("app1")).Return([]*apps.Subscription{
+			{AppID: "app1", Subject: apps.SubjectUserCreated, Call: apps.NewCall("/old")},
+			{AppID: "app1", Subject: apps.SubjectChannelCreated},
+		}, nil)
+		gomock.InOrder(
+			subStore.EXPECT().Save(&apps.Subscription{AppID: "app1", Subject: apps.SubjectUserCreated}).Return(nil),
+			subStore.EXPECT().Save(&apps.Subscription{AppID: "app1", Subject: apps.SubjectUserJoinedChannel, ChannelID: "channel1"}).Return(nil),
+			// Saved before the subscriptions that were not imported are
+			// deleted.
+			subStore.EXPECT().Delete(&apps.Subscription{AppID: "app1", Subject: apps.SubjectChannelCreated}).Return(nil),
+		)
+
+		err := p.ImportApp("app1", &AppBackup{
+			Version: AppBackupVersion,
+			App: apps.App{
+				Manifest:  apps.Manifest{AppID: "app1"},
+				BotUserID: "oldbotid",
+			},
+			KV: records,
+			Subscriptions: []*apps.Subscription{
+				{Subject: apps.SubjectUserCreated},
+				{Subject: apps.SubjectUserJoinedChannel, ChannelID: "channel1"},
+				// A channel of another server.
+				{Subject: apps.SubjectUserJoinedChannel, ChannelID: "foreign"},
+			},
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("import mismatch", func(t *testing.T) {
+		p, _, _ := setup(t)
+		err := p.ImportApp("app1", &AppBackup{
+			Version: AppBackupVersion,
+			App:     apps.App{Manifest: apps.Manifest{AppID: "app2"}},
+		})
+		require.Equal(t, utils.ErrInvalid, errors.Cause(err))
+
+		err = p.ImportApp("app1", &AppBackup{
+			Version: AppBackupVersion + 1,
+			App:     apps.App{Manifest: apps.Manifest{AppID: "app1"}},
+		})
+		require.Equal(t, utils.ErrInvalid, errors.Cause(err))
+	})
+}
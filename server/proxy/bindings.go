@@ -3,6 +3,7 @@ package proxy
 import (
 	"encoding/json"
 	"sync"
+	"time"
 
 	"github.com/mattermost/mattermost-server/v5/model"
 
@@ -20,12 +21,13 @@ func mergeBindings(bb1, bb2 []*apps.Binding) []*apps.Binding {
 			if b2.AppID == o.AppID && b2.Location == o.Location {
 				found = true
 
-				// b2 overrides b1, if b1 and b2 have Bindings, they are merged
-				merged := b2
+				// b2 overrides b1, if b1 and b2 have Bindings, they are merged.
+				// The merged binding is a copy, b1 and b2 may be cached.
+				merged := *b2
 				if len(o.Bindings) != 0 && b2.Call == nil {
 					merged.Bindings = mergeBindings(o.Bindings, b2.Bindings)
 				}
-				out[i] = merged
+				out[i] = &merged
 			}
 		}
 		if !found {
@@ -40,13 +42,14 @@ func mergeBindings(bb1, bb2 []*apps.Binding) []*apps.Binding {
 func (p *Proxy) GetBindings(sessionID, actingUserID string, cc *apps.Context) ([]*apps.Binding, error) {
 	allApps := store.SortApps(p.store.App.AsMap())
 	all := make([][]*apps.Binding, len(allApps))
+	conf := p.conf.GetConfig()
 
 	var wg sync.WaitGroup
 	for i, app := range allApps {
 		wg.Add(1)
 		go func(app *apps.App, i int) {
 			defer wg.Done()
-			all[i] = p.GetBindingsForApp(sessionID, actingUserID, cc, app)
+			all[i] = p.getBindingsWithTimeout(sessionID, actingUserID, cc, app, conf.BindingsTimeout(app.AppID))
 		}(app, i)
 	}
 	wg.Wait()
@@ -59,6 +62,41 @@ func (p *Proxy) GetBindings(sessionID, actingUserID string, cc *apps.Context) ([
 	return ret, nil
 }
 
+// getBindingsWithTimeout fetches the app's bindings, and if the app does not
+// respond within timeout, returns the last known bindings for the user and
+// context. The fetch is allowed to complete in the background, and will update
+// the cache for subsequent requests. While a fetch is in flight, the requests
+// for the same user and context wait for it instead of calling the app again.
+func (p *Proxy) getBindingsWithTimeout(sessionID, actingUserID string, cc *apps.Context, app *apps.App, timeout time.Duration) []*apps.Binding {
+	start := time.Now()
+	fetch, started := p.bindingsCache.startFetch(app.AppID, actingUserID, cc)
+	if started {
+		go func() {
+			p.bindingsCache.finishFetch(fetch, p.GetBindingsForApp(sessionID, actingUserID, cc, app))
+		}()
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-fetch.done:
+		p.metrics.ObserveBindings(app.AppID, time.Since(start), false)
+		return fetch.bindings
+	case <-timer.C:
+	}
+	p.metrics.ObserveBindings(app.AppID, timeout, true)
+
+	count, shouldLog := p.bindingsCache.recordTimeout(app.AppID)
+	if shouldLog {
+		p.log.Warnw("Timed out fetching bindings, using the last known",
+			"app_id", app.AppID,
+			"timeout", timeout.String(),
+			"timeouts", count)
+	}
+	bindings, _ := p.bindingsCache.lastKnown(app.AppID, actingUserID, cc)
+	return bindings
+}
+
 // GetBindingsForApp fetches bindings for a specific apps.
 // We should avoid unnecessary logging here as this route is called very often.
 func (p *Proxy) GetBindingsForApp(sessionID, actingUserID string, cc *apps.Context, app *apps.App) []*apps.Binding {
@@ -68,19 +106,22 @@ func (p *Proxy) GetBindingsForApp(sessionID, actingUserID string, cc *apps.Conte
 
 	log := p.log.With("app_id", app.AppID)
 
+	if cached, ok := p.bindingsCache.get(app.AppID, actingUserID, cc); ok {
+		return cached
+	}
+
 	appID := app.AppID
 	appCC := *cc
 	appCC.AppID = appID
 	appCC.BotAccessToken = app.BotAccessToken
 
-	// TODO PERF: Add caching
 	bindingsCall := apps.DefaultBindings.WithOverrides(app.Bindings)
 	bindingsRequest := &apps.CallRequest{
 		Call:    *bindingsCall,
 		Context: &appCC,
 	}
 
-	resp := p.Call(sessionID, actingUserID, bindingsRequest)
+	resp := p.callFromSource(CallSourceBindings, sessionID, actingUserID, bindingsRequest)
 	if resp == nil || (resp.Type != apps.CallResponseTypeError && resp.Type != apps.CallResponseTypeOK) {
 		log.Debugf("Bindings response is nil or unexpected type.")
 		return nil
@@ -102,6 +143,7 @@ func (p *Proxy) GetBindingsForApp(sessionID, actingUserID string, cc *apps.Conte
 
 	bindings = p.scanAppBindings(app, bindings, "")
 
+	p.bindingsCache.put(app.AppID, actingUserID, cc, bindings)
 	return bindings
 }
 
@@ -0,0 +1,338 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package proxy
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+)
+
+const (
+	// BindingsCacheTTL is how long fetched bindings are reused before the app
+	// is asked for them again, in absence of an explicit invalidation.
+	BindingsCacheTTL = 15 * time.Minute
+
+	// maxBindingsCacheEntriesPerApp limits the memory used by the cache.
+	maxBindingsCacheEntriesPerApp = 10000
+
+	// bindingsTimeoutLogInterval limits how often the bindings timeouts are
+	// logged for an app.
+	bindingsTimeoutLogInterval = time.Minute
+
+	clusterEventInvalidateBindings = "invalidate_bindings"
+)
+
+// bindingsScope is the combination of the DependsOn... flags of an app's
+// bindings. It determines what parts of the context the cached bindings are
+// keyed by. The bindings are always cached per user.
+type bindingsScope struct {
+	Team    bool
+	Channel bool
+	Post    bool
+}
+
+// fullBindingsScope keys the cached bindings by the whole context. It is used
+// for the apps that do not set any of the DependsOn... flags, so that their
+// bindings are never served in another team, channel or post.
+var fullBindingsScope = bindingsScope{
+	Team:    true,
+	Channel: true,
+	Post:    true,
+}
+
+func (s bindingsScope) union(other bindingsScope) bindingsScope {
+	return bindingsScope{
+		Team:    s.Team || other.Team,
+		Channel: s.Channel || other.Channel,
+		Post:    s.Post || other.Post,
+	}
+}
+
+// scopeOfBindings returns the scope of an app's bindings. If none of them set
+// a DependsOn... flag, the app has not opted into sharing its bindings across
+// contexts, and they are cached in fullBindingsScope.
+func scopeOfBindings(bindings []*apps.Binding) bindingsScope {
+	scope, declared := declaredScopeOfBindings(bindings)
+	if !declared {
+		return fullBindingsScope
+	}
+	return scope
+}
+
+func declaredScopeOfBindings(bindings []*apps.Binding) (scope bindingsScope, declared bool) {
+	for _, b := range bindings {
+		scope = scope.union(bindingsScope{
+			Team:    b.DependsOnTeam,
+			Channel: b.DependsOnChannel,
+			Post:    b.DependsOnPost,
+		})
+		declared = declared || b.DependsOnTeam || b.DependsOnChannel || b.DependsOnUser || b.DependsOnPost
+		subScope, subDeclared := declaredScopeOfBindings(b.Bindings)
+		scope = scope.union(subScope)
+		declared = declared || subDeclared
+	}
+	return scope, declared
+}
+
+func (s bindingsScope) key(userID string, cc *apps.Context) string {
+	parts := []string{userID, cc.UserAgent}
+	if s.Team {
+		parts = append(parts, "t:"+cc.TeamID)
+	}
+	if s.Channel {
+		parts = append(parts, "c:"+cc.ChannelID)
+	}
+	if s.Post {
+		parts = append(parts, "p:"+cc.PostID)
+	}
+	return strings.Join(parts, "/")
+}
+
+type bindingsCacheEntry struct {
+	userID    string
+	bindings  []*apps.Binding
+	expiresAt time.Time
+}
+
+type appBindingsCache struct {
+	// scope accumulates the DependsOn... flags of all responses since the
+	// app's cache was last invalidated. The key can only get more specific,
+	// so a response that depends on a channel is never served in another
+	// one.
+	scope   bindingsScope
+	entries map[string]*bindingsCacheEntry
+}
+
+// bindingsTimeouts records the timeouts of an app's bindings calls.
+type bindingsTimeouts struct {
+	count      int
+	last       time.Time
+	lastLogged time.Time
+}
+
+// bindingsFetch is a bindings call in flight. The requests for the same app,
+// user and context wait for it, rather than calling the app again, so that a
+// hung app gets one call at a time for each of them.
+type bindingsFetch struct {
+	key      string
+	done     chan struct{}
+	bindings []*apps.Binding
+}
+
+// bindingsCache is the per-node cache of the apps' bindings. Invalidations are
+// propagated to the other nodes with cluster events.
+type bindingsCache struct {
+	mutex    sync.Mutex
+	apps     map[apps.AppID]*appBindingsCache
+	timeouts map[apps.AppID]*bindingsTimeouts
+	fetches  map[string]*bindingsFetch
+	now      func() time.Time
+}
+
+func newBindingsCache() *bindingsCache {
+	return &bindingsCache{
+		apps:     map[apps.AppID]*appBindingsCache{},
+		timeouts: map[apps.AppID]*bindingsTimeouts{},
+		fetches:  map[string]*bindingsFetch{},
+		now:      time.Now,
+	}
+}
+
+// startFetch returns the fetch in flight for the app, user and context. If
+// there is none, it starts a new one and returns true, then the caller must
+// call the app, and finishFetch.
+func (c *bindingsCache) startFetch(appID apps.AppID, userID string, cc *apps.Context) (*bindingsFetch, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	scope := bindingsScope{}
+	if appCache := c.apps[appID]; appCache != nil {
+		scope = appCache.scope
+	}
+	key := string(appID) + "/" + scope.key(userID, cc)
+	if f := c.fetches[key]; f != nil {
+		return f, false
+	}
+	f := &bindingsFetch{
+		key:  key,
+		done: make(chan struct{}),
+	}
+	c.fetches[key] = f
+	return f, true
+}
+
+// finishFetch completes a fetch, and releases the requests waiting for it.
+func (c *bindingsCache) finishFetch(f *bindingsFetch, bindings []*apps.Binding) {
+	c.mutex.Lock()
+	delete(c.fetches, f.key)
+	c.mutex.Unlock()
+
+	f.bindings = bindings
+	close(f.done)
+}
+
+func (c *bindingsCache) get(appID apps.AppID, userID string, cc *apps.Context) ([]*apps.Binding, bool) {
+	entry := c.getEntry(appID, userID, cc)
+	if entry == nil || c.now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.bindings, true
+}
+
+// lastKnown returns the cached bindings even if they have expired.
+func (c *bindingsCache) lastKnown(appID apps.AppID, userID string, cc *apps.Context) ([]*apps.Binding, bool) {
+	entry := c.getEntry(appID, userID, cc)
+	if entry == nil {
+		return nil, false
+	}
+	return entry.bindings, true
+}
+
+func (c *bindingsCache) getEntry(appID apps.AppID, userID string, cc *apps.Context) *bindingsCacheEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	appCache := c.apps[appID]
+	if appCache == nil {
+		return nil
+	}
+	return appCache.entries[appCache.scope.key(userID, cc)]
+}
+
+// recordTimeout records a timed out bindings call, and returns the total
+// number of timeouts for the app, and whether it should be logged.
+func (c *bindingsCache) recordTimeout(appID apps.AppID) (int, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	t := c.timeouts[appID]
+	if t == nil {
+		t = &bindingsTimeouts{}
+		c.timeouts[appID] = t
+	}
+	now := c.now()
+	t.count++
+	t.last = now
+	if now.Sub(t.lastLogged) < bindingsTimeoutLogInterval {
+		return t.count, false
+	}
+	t.lastLogged = now
+	return t.count, true
+}
+
+func (c *bindingsCache) put(appID apps.AppID, userID string, cc *apps.Context, bindings []*apps.Binding) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	appCache := c.apps[appID]
+	if appCache == nil {
+		appCache = &appBindingsCache{
+			entries: map[string]*bindingsCacheEntry{},
+		}
+		c.apps[appID] = appCache
+	}
+
+	now := c.now()
+	if len(appCache.entries) >= maxBindingsCacheEntriesPerApp {
+		for key, entry := range appCache.entries {
+			if now.After(entry.expiresAt) {
+				delete(appCache.entries, key)
+			}
+		}
+		if len(appCache.entries) >= maxBindingsCacheEntriesPerApp {
+			appCache.entries = map[string]*bindingsCacheEntry{}
+		}
+	}
+
+	appCache.scope = appCache.scope.union(scopeOfBindings(bindings))
+	appCache.entries[appCache.scope.key(userID, cc)] = &bindingsCacheEntry{
+		userID:    userID,
+		bindings:  bindings,
+		expiresAt: now.Add(BindingsCacheTTL),
+	}
+}
+
+// invalidate removes the cached bindings of an app, for a specific user, or
+// for all users if userID is empty. If appID is empty, the bindings of all
+// apps are invalidated.
+func (c *bindingsCache) invalidate(appID apps.AppID, userID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if appID == "" {
+		if userID == "" {
+			c.apps = map[apps.AppID]*appBindingsCache{}
+			return
+		}
+		for _, appCache := range c.apps {
+			appCache.invalidateUser(userID)
+		}
+		return
+	}
+
+	appCache := c.apps[appID]
+	if appCache == nil {
+		return
+	}
+	if userID == "" {
+		delete(c.apps, appID)
+		return
+	}
+	appCache.invalidateUser(userID)
+}
+
+func (c *appBindingsCache) invalidateUser(userID string) {
+	for key, entry := range c.entries {
+		if entry.userID == userID {
+			delete(c.entries, key)
+		}
+	}
+}
+
+type invalidateBindingsEvent struct {
+	AppID  apps.AppID `json:"app_id,omitempty"`
+	UserID string     `json:"user_id,omitempty"`
+}
+
+func (p *Proxy) onInvalidateBindingsEvent(data []byte) {
+	ev := invalidateBindingsEvent{}
+	err := json.Unmarshal(data, &ev)
+	if err != nil {
+		p.log.WithError(err).Warnf("Failed to decode bindings invalidation event")
+		return
+	}
+	p.bindingsCache.invalidate(ev.AppID, ev.UserID)
+}
+
+// invalidateBindings drops the cached bindings of an app on all nodes in the
+// cluster, for a specific user, or for all users if userID is empty.
+func (p *Proxy) invalidateBindings(appID apps.AppID, userID string) {
+	err := p.clusterEvents.Broadcast(clusterEventInvalidateBindings, invalidateBindingsEvent{
+		AppID:  appID,
+		UserID: userID,
+	})
+	if err != nil {
+		p.log.WithError(err).Warnw("Failed to invalidate cached bindings on other nodes",
+			"app_id", appID)
+	}
+}
+
+// RefreshBindings invalidates the cached bindings of an app, and asks the
+// affected user-agents to fetch the bindings again. If userID is empty, the
+// bindings are refreshed for all users.
+func (p *Proxy) RefreshBindings(appID apps.AppID, userID string) {
+	p.invalidateBindings(appID, userID)
+	if userID != "" {
+		p.dispatchRefreshBindingsEvent(userID)
+		return
+	}
+	p.mm.Frontend.PublishWebSocketEvent(config.WebSocketEventRefreshBindings, map[string]interface{}{}, &model.WebsocketBroadcast{})
+}
@@ -0,0 +1,201 @@
+// +build !e2e
+
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+)
+
+func TestBindingsCache(t *testing.T) {
+	ccIn := func(teamID, channelID string) *apps.Context {
+		return &apps.Context{
+			UserAgentContext: apps.UserAgentContext{
+				TeamID:    teamID,
+				ChannelID: channelID,
+			},
+		}
+	}
+	global := []*apps.Binding{{
+		Location:      apps.LocationCommand,
+		DependsOnUser: true,
+	}}
+	perChannel := []*apps.Binding{{
+		Location: apps.LocationChannelHeader,
+		Bindings: []*apps.Binding{{
+			Location:         "button",
+			DependsOnChannel: true,
+		}},
+	}}
+
+	t.Run("no flags", func(t *testing.T) {
+		c := newBindingsCache()
+		bindings := []*apps.Binding{{Location: apps.LocationCommand}}
+		c.put("app1", "user1", ccIn("team1", "channel1"), bindings)
+
+		b, ok := c.get("app1", "user1", ccIn("team1", "channel1"))
+		require.True(t, ok)
+		require.Equal(t, bindings, b)
+		_, ok = c.get("app1", "user1", ccIn("team1", "channel2"))
+		require.False(t, ok, "bindings without DependsOn flags must not be shared between channels")
+		_, ok = c.get("app1", "user1", ccIn("team2", "channel1"))
+		require.False(t, ok)
+	})
+
+	t.Run("depends on user only", func(t *testing.T) {
+		c := newBindingsCache()
+		c.put("app1", "user1", ccIn("team1", "channel1"), global)
+
+		b, ok := c.get("app1", "user1", ccIn("team2", "channel2"))
+		require.True(t, ok)
+		require.Equal(t, global, b)
+
+		_, ok = c.get("app1", "user2", ccIn("team1", "channel1"))
+		require.False(t, ok, "bindings must not be shared between users")
+		_, ok = c.get("app2", "user1", ccIn("team1", "channel1"))
+		require.False(t, ok)
+	})
+
+	t.Run("depends on channel", func(t *testing.T) {
+		c := newBindingsCache()
+		c.put("app1", "user1", ccIn("team1", "channel1"), perChannel)
+
+		b, ok := c.get("app1", "user1", ccIn("team2", "channel1"))
+		require.True(t, ok)
+		require.Equal(t, perChannel, b)
+		_, ok = c.get("app1", "user1", ccIn("team1", "channel2"))
+		require.False(t, ok)
+	})
+
+	t.Run("scope only gets more specific", func(t *testing.T) {
+		c := newBindingsCache()
+		c.put("app1", "user1", ccIn("team1", "channel1"), perChannel)
+		c.put("app1", "user2", ccIn("team1", "channel1"), global)
+
+		_, ok := c.get("app1", "user2", ccIn("team1", "channel2"))
+		require.False(t, ok)
+		_, ok = c.get("app1", "user2", ccIn("team1", "channel1"))
+		require.True(t, ok)
+	})
+
+	t.Run("merging does not change the cache", func(t *testing.T) {
+		newBindings := func(appID apps.AppID) []*apps.Binding {
+			return []*apps.Binding{{
+				Location: apps.LocationChannelHeader,
+				Bindings: []*apps.Binding{{
+					AppID:         appID,
+					Location:      "button",
+					Call:          apps.NewCall("/" + string(appID)),
+					DependsOnUser: true,
+				}},
+			}}
+		}
+		c := newBindingsCache()
+		cc := ccIn("team1", "channel1")
+		c.put("app1", "user1", cc, newBindings("app1"))
+		c.put("app2", "user1", cc, newBindings("app2"))
+
+		for i := 0; i < 2; i++ {
+			merged := []*apps.Binding{}
+			for _, appID := range []apps.AppID{"app1", "app2"} {
+				b, ok := c.get(appID, "user1", cc)
+				require.True(t, ok)
+				merged = mergeBindings(merged, b)
+			}
+			require.Len(t, merged, 1)
+			require.Len(t, merged[0].Bindings, 2)
+		}
+
+		for _, appID := range []apps.AppID{"app1", "app2"} {
+			b, ok := c.get(appID, "user1", cc)
+			require.True(t, ok)
+			require.Equal(t, newBindings(appID), b)
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		now := time.Now()
+		c := newBindingsCache()
+		c.now = func() time.Time { return now }
+		c.put("app1", "user1", ccIn("team1", "channel1"), global)
+
+		now = now.Add(BindingsCacheTTL + time.Second)
+		_, ok := c.get("app1", "user1", ccIn("team1", "channel1"))
+		require.False(t, ok)
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		c := newBindingsCache()
+		cc := ccIn("team1", "channel1")
+		c.put("app1", "user1", cc, global)
+		c.put("app1", "user2", cc, global)
+		c.put("app2", "user1", cc, global)
+
+		c.invalidate("app1", "user1")
+		_, ok := c.get("app1", "user1", cc)
+		require.False(t, ok)
+		_, ok = c.get("app1", "user2", cc)
+		require.True(t, ok)
+
+		c.invalidate("app1", "")
+		_, ok = c.get("app1", "user2", cc)
+		require.False(t, ok)
+		_, ok = c.get("app2", "user1", cc)
+		require.True(t, ok)
+
+		c.invalidate("", "user1")
+		_, ok = c.get("app2", "user1", cc)
+		require.False(t, ok)
+	})
+
+	t.Run("last known and timeouts", func(t *testing.T) {
+		now := time.Now()
+		c := newBindingsCache()
+		c.now = func() time.Time { return now }
+		cc := ccIn("team1", "channel1")
+		c.put("app1", "user1", cc, global)
+
+		now = now.Add(BindingsCacheTTL + time.Second)
+		_, ok := c.get("app1", "user1", cc)
+		require.False(t, ok)
+		bb, ok := c.lastKnown("app1", "user1", cc)
+		require.True(t, ok)
+		require.Equal(t, global, bb)
+
+		count, shouldLog := c.recordTimeout("app1")
+		require.Equal(t, 1, count)
+		require.True(t, shouldLog)
+		count, shouldLog = c.recordTimeout("app1")
+		require.Equal(t, 2, count)
+		require.False(t, shouldLog)
+
+		now = now.Add(bindingsTimeoutLogInterval)
+		_, shouldLog = c.recordTimeout("app1")
+		require.True(t, shouldLog)
+	})
+
+	t.Run("one fetch in flight", func(t *testing.T) {
+		c := newBindingsCache()
+		cc := ccIn("team1", "channel1")
+
+		f1, started := c.startFetch("app1", "user1", cc)
+		require.True(t, started)
+		f2, started := c.startFetch("app1", "user1", cc)
+		require.False(t, started)
+		require.Equal(t, f1, f2)
+		_, started = c.startFetch("app1", "user2", cc)
+		require.True(t, started)
+
+		c.finishFetch(f1, global)
+		<-f2.done
+		require.Equal(t, global, f2.bindings)
+
+		f3, started := c.startFetch("app1", "user1", cc)
+		require.True(t, started)
+		require.NotEqual(t, f1, f3)
+	})
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/mattermost/mattermost-plugin-apps/apps"
 	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/server/metrics"
 	"github.com/mattermost/mattermost-plugin-apps/server/mocks/mock_store"
 	"github.com/mattermost/mattermost-plugin-apps/server/mocks/mock_upstream"
 	"github.com/mattermost/mattermost-plugin-apps/server/store"
@@ -599,7 +600,7 @@ func newTestProxyForBindings(testData []bindingTestData, ctrl *gomock.Controller
 		},
 	})
 
-	s := store.NewService(mm, utils.NewTestLogger(), confService, nil, "")
+	s := store.NewService(mm, utils.NewTestLogger(), confService, nil, "", nil)
 	appStore := mock_store.NewMockAppStore(ctrl)
 	s.App = appStore
 
@@ -629,6 +630,9 @@ func newTestProxyForBindings(testData []bindingTestData, ctrl *gomock.Controller
 		log:              utils.NewTestLogger(),
 		store:            s,
 		builtinUpstreams: upstreams,
+		bindingsCache:    newBindingsCache(),
+		callHistory:      newCallHistory(),
+		metrics:          metrics.NewService(),
 		conf:             confService,
 	}
 
@@ -0,0 +1,184 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package proxy
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/metrics"
+)
+
+const (
+	// CallHistorySize is how many of the latest calls are kept for each app.
+	CallHistorySize = 100
+
+	maxCallRecordErrorLength = 1024
+
+	redacted = "***"
+)
+
+// Sources of the calls in the call history.
+const (
+	CallSourceCall         = "call"
+	CallSourceNotification = "notification"
+	CallSourceWebhook      = "webhook"
+	CallSourceSchedule     = "schedule"
+
+	// CallSourceBindings is the source of the bindings calls. They are made
+	// too often to be kept in the call history, they would push the other
+	// calls out of it.
+	CallSourceBindings = "bindings"
+)
+
+// CallRecord describes a call that the proxy made to an app. It does not
+// include the call's values, nor its context.
+type CallRecord struct {
+	// Time is when the call was made, in milliseconds since the epoch.
+	Time int64 `json:"time"`
+
+	// Source is one of CallSourceCall, CallSourceNotification,
+	// CallSourceWebhook or CallSourceSchedule. Type is derived from the path, and is only set for
+	// the calls that follow the .../{type} convention.
+	Source       string                `json:"source"`
+	Path         string                `json:"path"`
+	Type         apps.CallType         `json:"type,omitempty"`
+	Subject      apps.Subject          `json:"subject,omitempty"`
+	ActingUserID string                `json:"acting_user_id,omitempty"`
+	Duration     time.Duration         `json:"duration"`
+	ResponseType apps.CallResponseType `json:"response_type,omitempty"`
+	ErrorText    string                `json:"error,omitempty"`
+}
+
+// callHistory keeps the latest calls made to each app, on this node.
+type callHistory struct {
+	mutex sync.Mutex
+	apps  map[apps.AppID]*callRecords
+}
+
+// callRecords is a ring buffer of CallHistorySize records.
+type callRecords struct {
+	records []CallRecord
+	next    int
+}
+
+func newCallHistory() *callHistory {
+	return &callHistory{
+		apps: map[apps.AppID]*callRecords{},
+	}
+}
+
+func (h *callHistory) add(app *apps.App, r CallRecord) {
+	r.ErrorText = redactAppSecrets(app, r.ErrorText)
+	if len(r.ErrorText) > maxCallRecordErrorLength {
+		r.ErrorText = r.ErrorText[:maxCallRecordErrorLength] + "..."
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	rr := h.apps[app.AppID]
+	if rr == nil {
+		rr = &callRecords{}
+		h.apps[app.AppID] = rr
+	}
+	if len(rr.records) < CallHistorySize {
+		rr.records = append(rr.records, r)
+		return
+	}
+	rr.records[rr.next] = r
+	rr.next = (rr.next + 1) % CallHistorySize
+}
+
+// get returns the app's call records, the most recent first.
+func (h *callHistory) get(appID apps.AppID) []CallRecord {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	rr := h.apps[appID]
+	if rr == nil {
+		return []CallRecord{}
+	}
+	n := len(rr.records)
+	out := make([]CallRecord, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, rr.records[(rr.next-i+n)%n])
+	}
+	return out
+}
+
+func (h *callHistory) delete(appID apps.AppID) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.apps, appID)
+}
+
+// callTypeOfPath returns the call type that a path ends with, following the
+// convention that apps use, e.g. "/send/form". It returns "" if the path does
+// not end with a call type.
+func callTypeOfPath(path string) apps.CallType {
+	t := apps.CallType(path[strings.LastIndex(path, "/")+1:])
+	switch t {
+	case apps.CallTypeSubmit, apps.CallTypeForm, apps.CallTypeCancel, apps.CallTypeLookup:
+		return t
+	}
+	return ""
+}
+
+// redactAppSecrets removes the app's credentials from a text, in case they
+// are echoed in an error message.
+func redactAppSecrets(app *apps.App, text string) string {
+	if text == "" {
+		return text
+	}
+	for _, secret := range []string{
+		app.Secret,
+		app.WebhookSecret,
+		app.BotAccessToken,
+		app.MattermostOAuth2.ClientSecret,
+		app.RemoteOAuth2.ClientSecret,
+		app.PreviousSecret.Secret,
+		app.PreviousWebhookSecret.Secret,
+		app.PreviousBotAccessToken.Secret,
+	} {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, redacted)
+		}
+	}
+	return text
+}
+
+// recordCall adds a call to the app's call history, and to the metrics.
+func (p *Proxy) recordCall(app *apps.App, r CallRecord, start time.Time, err error) {
+	r.Time = start.UnixNano() / int64(time.Millisecond)
+	r.Duration = time.Since(start)
+	if err != nil {
+		r.ErrorText = err.Error()
+	}
+	if r.Source != CallSourceBindings {
+		p.callHistory.add(app, r)
+	}
+
+	failed := r.ErrorText != ""
+	p.metrics.ObserveCall(app.AppID, app.AppType, r.Source, string(r.Type), r.Duration, failed)
+
+	result := metrics.ResultDelivered
+	if failed {
+		result = metrics.ResultFailed
+	}
+	switch r.Source {
+	case CallSourceNotification:
+		p.metrics.ObserveNotification(app.AppID, r.Subject, result)
+	case CallSourceWebhook:
+		p.metrics.ObserveWebhook(app.AppID, result)
+	}
+}
+
+// GetCallHistory returns the latest calls made to an app from this node, the
+// most recent first.
+func (p *Proxy) GetCallHistory(appID apps.AppID) []CallRecord {
+	return p.callHistory.get(appID)
+}
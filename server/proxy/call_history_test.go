@@ -0,0 +1,70 @@
+package proxy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/metrics"
+)
+
+func TestCallHistory(t *testing.T) {
+	app := &apps.App{
+		Manifest: apps.Manifest{
+			AppID: "app1",
+		},
+		Secret:         "jwt-secret",
+		BotAccessToken: "bot-token",
+	}
+
+	t.Run("ring", func(t *testing.T) {
+		h := newCallHistory()
+		for i := 0; i < CallHistorySize+5; i++ {
+			h.add(app, CallRecord{Path: fmt.Sprintf("/%v", i)})
+		}
+		records := h.get("app1")
+		require.Len(t, records, CallHistorySize)
+		require.Equal(t, fmt.Sprintf("/%v", CallHistorySize+4), records[0].Path)
+		require.Equal(t, "/5", records[CallHistorySize-1].Path)
+
+		require.Empty(t, h.get("app2"))
+		h.delete("app1")
+		require.Empty(t, h.get("app1"))
+	})
+
+	t.Run("redacted", func(t *testing.T) {
+		h := newCallHistory()
+		h.add(app, CallRecord{ErrorText: "invalid token bot-token, signed with jwt-secret"})
+		require.Equal(t, "invalid token ***, signed with ***", h.get("app1")[0].ErrorText)
+	})
+}
+
+func TestRecordCallSkipsBindings(t *testing.T) {
+	app := &apps.App{
+		Manifest: apps.Manifest{
+			AppID: "app1",
+		},
+	}
+	p := &Proxy{
+		callHistory: newCallHistory(),
+		metrics:     metrics.NewService(),
+	}
+
+	p.recordCall(app, CallRecord{Source: CallSourceBindings, Path: "/bindings"}, time.Now(), nil)
+	require.Empty(t, p.GetCallHistory("app1"))
+
+	p.recordCall(app, CallRecord{Source: CallSourceCall, Path: "/send"}, time.Now(), nil)
+	records := p.GetCallHistory("app1")
+	require.Len(t, records, 1)
+	require.Equal(t, "/send", records[0].Path)
+}
+
+func TestCallTypeOfPath(t *testing.T) {
+	require.Equal(t, apps.CallTypeForm, callTypeOfPath("/send/form"))
+	require.Equal(t, apps.CallTypeSubmit, callTypeOfPath("/submit"))
+	require.Equal(t, apps.CallType(""), callTypeOfPath("/send"))
+	require.Equal(t, apps.CallType(""), callTypeOfPath("/"))
+}
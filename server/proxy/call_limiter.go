@@ -0,0 +1,150 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package proxy
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+// callLimiterSweepInterval is how often the buckets that have refilled are
+// removed, to keep the memory use proportional to the number of active users.
+const callLimiterSweepInterval = time.Minute
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens accumulated since the bucket was last used, and
+// returns true if the bucket is full.
+func (b *tokenBucket) refill(l config.RateLimit, now time.Time) bool {
+	b.tokens += now.Sub(b.last).Minutes() * float64(l.PerMinute)
+	b.last = now
+	if b.tokens >= float64(l.Burst) {
+		b.tokens = float64(l.Burst)
+		return true
+	}
+	return false
+}
+
+// callLimiter enforces the Call API limits, on this node: a token bucket per
+// user (across all apps), a token bucket per app, and a maximum number of
+// calls in flight to each app.
+type callLimiter struct {
+	conf config.Service
+	now  func() time.Time
+
+	mutex     sync.Mutex
+	users     map[string]*tokenBucket
+	apps      map[string]*tokenBucket
+	inFlight  map[apps.AppID]int
+	lastSweep time.Time
+}
+
+func newCallLimiter(conf config.Service) *callLimiter {
+	return &callLimiter{
+		conf:     conf,
+		now:      time.Now,
+		users:    map[string]*tokenBucket{},
+		apps:     map[string]*tokenBucket{},
+		inFlight: map[apps.AppID]int{},
+	}
+}
+
+// acquire reserves a call by a user to an app. If the call is allowed, it
+// returns a function to be called once the call is complete. Otherwise, it
+// returns an ErrTooManyRequests error, and no tokens are used up.
+func (l *callLimiter) acquire(appID apps.AppID, userID string) (func(), error) {
+	conf := l.conf.GetConfig()
+	userLimit := conf.UserCallRateLimit()
+	appLimit := conf.AppCallRateLimit()
+	maxInFlight := conf.MaxConcurrentCalls()
+	now := l.now()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.sweep(now, userLimit, appLimit)
+
+	if maxInFlight > 0 && l.inFlight[appID] >= maxInFlight {
+		return nil, utils.NewTooManyRequestsError("%s is busy: %v calls in progress, please try again later", appID, maxInFlight)
+	}
+
+	var userBucket, appBucket *tokenBucket
+	if userLimit.PerMinute > 0 && userID != "" {
+		userBucket = bucket(l.users, userID, userLimit, now)
+		if userBucket.tokens < 1 {
+			return nil, utils.NewTooManyRequestsError("too many calls, please wait %v before trying again", wait(userBucket, userLimit))
+		}
+	}
+	if appLimit.PerMinute > 0 {
+		appBucket = bucket(l.apps, string(appID), appLimit, now)
+		if appBucket.tokens < 1 {
+			return nil, utils.NewTooManyRequestsError("too many calls to %s, please wait %v before trying again", appID, wait(appBucket, appLimit))
+		}
+	}
+
+	if userBucket != nil {
+		userBucket.tokens--
+	}
+	if appBucket != nil {
+		appBucket.tokens--
+	}
+	l.inFlight[appID]++
+
+	released := false
+	return func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		if released {
+			return
+		}
+		released = true
+		l.inFlight[appID]--
+		if l.inFlight[appID] <= 0 {
+			delete(l.inFlight, appID)
+		}
+	}, nil
+}
+
+// sweep removes the buckets that are full, since they are equivalent to new
+// ones. l.mutex must be held by the caller.
+func (l *callLimiter) sweep(now time.Time, userLimit, appLimit config.RateLimit) {
+	if now.Sub(l.lastSweep) < callLimiterSweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for userID, b := range l.users {
+		if b.refill(userLimit, now) {
+			delete(l.users, userID)
+		}
+	}
+	for appID, b := range l.apps {
+		if b.refill(appLimit, now) {
+			delete(l.apps, appID)
+		}
+	}
+}
+
+func bucket(buckets map[string]*tokenBucket, key string, limit config.RateLimit, now time.Time) *tokenBucket {
+	b := buckets[key]
+	if b == nil {
+		b = &tokenBucket{tokens: float64(limit.Burst), last: now}
+		buckets[key] = b
+	}
+	b.refill(limit, now)
+	return b
+}
+
+// wait returns how long until the bucket has a token, rounded up to a second.
+func wait(b *tokenBucket, l config.RateLimit) time.Duration {
+	d := time.Duration((1 - b.tokens) / float64(l.PerMinute) * float64(time.Minute))
+	return (d + time.Second - 1).Truncate(time.Second)
+}
@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestCallLimiter(t *testing.T) {
+	newLimiter := func(sc config.StoredConfig) (*callLimiter, *time.Time) {
+		now := time.Unix(1600000000, 0)
+		l := newCallLimiter(config.NewTestConfigurator(config.Config{StoredConfig: sc}))
+		l.now = func() time.Time { return now }
+		return l, &now
+	}
+
+	t.Run("per user", func(t *testing.T) {
+		l, now := newLimiter(config.StoredConfig{
+			CallRatePerUser:          60,
+			CallBurstPerUser:         2,
+			CallRatePerApp:           -1,
+			MaxConcurrentCallsPerApp: -1,
+		})
+
+		for i := 0; i < 2; i++ {
+			release, err := l.acquire("app1", "user1")
+			require.NoError(t, err)
+			release()
+		}
+		_, err := l.acquire("app2", "user1")
+		require.Equal(t, utils.ErrTooManyRequests, errors.Cause(err))
+		require.Contains(t, err.Error(), "wait 1s")
+
+		// Other users are not affected.
+		_, err = l.acquire("app1", "user2")
+		require.NoError(t, err)
+
+		// A token is added every second.
+		*now = now.Add(time.Second)
+		_, err = l.acquire("app1", "user1")
+		require.NoError(t, err)
+		_, err = l.acquire("app1", "user1")
+		require.Error(t, err)
+	})
+
+	t.Run("per app", func(t *testing.T) {
+		l, _ := newLimiter(config.StoredConfig{
+			CallRatePerUser:          -1,
+			CallRatePerApp:           60,
+			CallBurstPerApp:          3,
+			MaxConcurrentCallsPerApp: -1,
+		})
+
+		for _, userID := range []string{"user1", "user2", "user3"} {
+			_, err := l.acquire("app1", userID)
+			require.NoError(t, err)
+		}
+		_, err := l.acquire("app1", "user4")
+		require.Equal(t, utils.ErrTooManyRequests, errors.Cause(err))
+
+		_, err = l.acquire("app2", "user4")
+		require.NoError(t, err)
+	})
+
+	t.Run("in flight", func(t *testing.T) {
+		l, _ := newLimiter(config.StoredConfig{
+			CallRatePerUser:          -1,
+			CallRatePerApp:           -1,
+			MaxConcurrentCallsPerApp: 2,
+		})
+
+		release1, err := l.acquire("app1", "user1")
+		require.NoError(t, err)
+		release2, err := l.acquire("app1", "user1")
+		require.NoError(t, err)
+		_, err = l.acquire("app1", "user1")
+		require.Equal(t, utils.ErrTooManyRequests, errors.Cause(err))
+
+		release1()
+		release1()
+		_, err = l.acquire("app1", "user1")
+		require.NoError(t, err)
+		_, err = l.acquire("app1", "user1")
+		require.Error(t, err)
+
+		release2()
+		require.Equal(t, 1, l.inFlight["app1"])
+	})
+
+	t.Run("rejected calls use no tokens", func(t *testing.T) {
+		l, _ := newLimiter(config.StoredConfig{
+			CallRatePerUser:          60,
+			CallBurstPerUser:         5,
+			CallRatePerApp:           60,
+			CallBurstPerApp:          1,
+			MaxConcurrentCallsPerApp: -1,
+		})
+
+		_, err := l.acquire("app1", "user1")
+		require.NoError(t, err)
+		_, err = l.acquire("app1", "user1")
+		require.Error(t, err)
+		require.Equal(t, float64(4), l.users["user1"].tokens)
+	})
+
+	t.Run("sweep", func(t *testing.T) {
+		l, now := newLimiter(config.StoredConfig{})
+
+		_, err := l.acquire("app1", "user1")
+		require.NoError(t, err)
+		require.Len(t, l.users, 1)
+		require.Len(t, l.apps, 1)
+
+		*now = now.Add(time.Hour)
+		_, err = l.acquire("app1", "user2")
+		require.NoError(t, err)
+		require.Len(t, l.users, 1)
+		require.NotNil(t, l.users["user2"])
+	})
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package proxy
+
+import (
+	"sync"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+)
+
+// DispatcherStats is a snapshot of the notification dispatcher's load.
+type DispatcherStats struct {
+	// Running and Queued are the current numbers of tasks.
+	Running int `json:"running"`
+	Queued  int `json:"queued"`
+
+	// Dispatched, Completed and Rejected are running totals. Tasks are
+	// rejected when the queue is full.
+	Dispatched int64 `json:"dispatched"`
+	Completed  int64 `json:"completed"`
+	Rejected   int64 `json:"rejected"`
+
+	Apps map[apps.AppID]AppDispatcherStats `json:"apps,omitempty"`
+}
+
+type AppDispatcherStats struct {
+	Running  int   `json:"running"`
+	Queued   int   `json:"queued"`
+	Rejected int64 `json:"rejected"`
+}
+
+type appDispatchQueue struct {
+	pending  []func()
+	running  int
+	rejected int64
+}
+
+// dispatcher runs the notification tasks (sending subscription notifications
+// and webhooks to apps) on a bounded number of goroutines, shared by all apps.
+// Each app is also limited in how many of its tasks can run at the same time,
+// so that a slow app does not take over the dispatcher. Tasks that can not be
+// run right away are queued, up to a limit; beyond that they are rejected and
+// the caller is expected to deal with it, e.g. by using the retry queue.
+type dispatcher struct {
+	conf config.Service
+
+	mutex      sync.Mutex
+	apps       map[apps.AppID]*appDispatchQueue
+	running    int
+	queued     int
+	dispatched int64
+	completed  int64
+	rejected   int64
+}
+
+func newDispatcher(conf config.Service) *dispatcher {
+	return &dispatcher{
+		conf: conf,
+		apps: map[apps.AppID]*appDispatchQueue{},
+	}
+}
+
+// dispatch submits a task to be run for an app. It returns false if the task
+// was rejected because the dispatcher's queue is full.
+func (d *dispatcher) dispatch(appID apps.AppID, task func()) bool {
+	conf := d.conf.GetConfig()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	q := d.apps[appID]
+	if q == nil {
+		q = &appDispatchQueue{}
+		d.apps[appID] = q
+	}
+
+	if d.queued >= conf.MaxNotificationsQueued() {
+		d.rejected++
+		q.rejected++
+		return false
+	}
+
+	d.dispatched++
+	q.pending = append(q.pending, task)
+	d.queued++
+	d.startTasks(conf.MaxNotificationWorkers(), conf.MaxNotificationWorkersPerApp())
+	return true
+}
+
+// startTasks starts the pending tasks, as the limits allow. d.mutex must be
+// held by the caller.
+func (d *dispatcher) startTasks(maxWorkers, maxPerApp int) {
+	for d.running < maxWorkers && d.queued > 0 {
+		var appID apps.AppID
+		var q *appDispatchQueue
+		for id, candidate := range d.apps {
+			if len(candidate.pending) > 0 && candidate.running < maxPerApp {
+				appID, q = id, candidate
+				break
+			}
+		}
+		if q == nil {
+			// Everything pending belongs to apps that are at their limit.
+			return
+		}
+
+		task := q.pending[0]
+		q.pending[0] = nil
+		q.pending = q.pending[1:]
+		q.running++
+		d.queued--
+		d.running++
+		go d.run(appID, task)
+	}
+}
+
+func (d *dispatcher) run(appID apps.AppID, task func()) {
+	defer func() {
+		conf := d.conf.GetConfig()
+
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+
+		q := d.apps[appID]
+		q.running--
+		if q.running == 0 && len(q.pending) == 0 && q.rejected == 0 {
+			delete(d.apps, appID)
+		}
+		d.running--
+		d.completed++
+		d.startTasks(conf.MaxNotificationWorkers(), conf.MaxNotificationWorkersPerApp())
+	}()
+
+	task()
+}
+
+func (d *dispatcher) stats() DispatcherStats {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	stats := DispatcherStats{
+		Running:    d.running,
+		Queued:     d.queued,
+		Dispatched: d.dispatched,
+		Completed:  d.completed,
+		Rejected:   d.rejected,
+		Apps:       map[apps.AppID]AppDispatcherStats{},
+	}
+	for appID, q := range d.apps {
+		stats.Apps[appID] = AppDispatcherStats{
+			Running:  q.running,
+			Queued:   len(q.pending),
+			Rejected: q.rejected,
+		}
+	}
+	return stats
+}
+
+func (p *Proxy) DispatcherStats() DispatcherStats {
+	return p.dispatcher.stats()
+}
@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+)
+
+func TestDispatcher(t *testing.T) {
+	d := newDispatcher(config.NewTestConfigurator(config.Config{
+		StoredConfig: config.StoredConfig{
+			NotificationWorkers:       3,
+			NotificationWorkersPerApp: 2,
+			NotificationQueueSize:     3,
+		},
+	}))
+
+	release := make(chan struct{})
+	started := make(chan apps.AppID, 10)
+	wg := sync.WaitGroup{}
+	task := func(appID apps.AppID) func() {
+		wg.Add(1)
+		return func() {
+			defer wg.Done()
+			started <- appID
+			<-release
+		}
+	}
+
+	// app1 is limited to 2 tasks at a time, so the third one waits even
+	// though there is a spare worker. app2 gets the spare worker.
+	require.True(t, d.dispatch("app1", task("app1")))
+	require.True(t, d.dispatch("app1", task("app1")))
+	require.True(t, d.dispatch("app1", task("app1")))
+	require.True(t, d.dispatch("app2", task("app2")))
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+	stats := d.stats()
+	require.Equal(t, 3, stats.Running)
+	require.Equal(t, 1, stats.Queued)
+	require.Equal(t, 2, stats.Apps["app1"].Running)
+	require.Equal(t, 1, stats.Apps["app1"].Queued)
+	require.Equal(t, 1, stats.Apps["app2"].Running)
+
+	// The queue is full, the next tasks are rejected.
+	require.True(t, d.dispatch("app2", task("app2")))
+	require.True(t, d.dispatch("app2", task("app2")))
+	require.False(t, d.dispatch("app3", func() {}))
+	stats = d.stats()
+	require.Equal(t, int64(1), stats.Rejected)
+	require.Equal(t, int64(1), stats.Apps["app3"].Rejected)
+
+	close(release)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the tasks to complete")
+	}
+
+	require.Eventually(t, func() bool {
+		stats = d.stats()
+		return stats.Running == 0 && stats.Completed == 6
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, 0, stats.Queued)
+	require.Equal(t, int64(6), stats.Dispatched)
+}
@@ -36,7 +36,7 @@ func (p *Proxy) EnableApp(client mmclient.Client, sessionID string, cc *apps.Con
 
 	var message string
 	if app.OnEnable != nil {
-		resp := p.Call(sessionID, cc.ActingUserID, &apps.CallRequest{
+		resp := p.call(sessionID, cc.ActingUserID, &apps.CallRequest{
 			Call:    *app.OnEnable,
 			Context: cc,
 		})
@@ -54,6 +54,7 @@ func (p *Proxy) EnableApp(client mmclient.Client, sessionID string, cc *apps.Con
 
 	p.log.Infow("Enabled app", "app_id", app.AppID)
 
+	p.invalidateBindings(app.AppID, "")
 	p.dispatchRefreshBindingsEvent(cc.ActingUserID)
 
 	return message, nil
@@ -72,7 +73,7 @@ func (p *Proxy) DisableApp(client mmclient.Client, sessionID string, cc *apps.Co
 	// Call the app first as later it's disabled
 	var message string
 	if app.OnDisable != nil {
-		resp := p.Call(sessionID, cc.ActingUserID, &apps.CallRequest{
+		resp := p.call(sessionID, cc.ActingUserID, &apps.CallRequest{
 			Call:    *app.OnDisable,
 			Context: cc,
 		})
@@ -103,6 +104,7 @@ func (p *Proxy) DisableApp(client mmclient.Client, sessionID string, cc *apps.Co
 	p.log.Infow("Disabled app",
 		"app_id", app.AppID)
 
+	p.invalidateBindings(app.AppID, "")
 	p.dispatchRefreshBindingsEvent(cc.ActingUserID)
 
 	return message, nil
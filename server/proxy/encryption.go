@@ -0,0 +1,33 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package proxy
+
+import (
+	"github.com/pkg/errors"
+)
+
+// EncryptionRotation is how many records were re-encrypted by
+// RotateEncryptionKey.
+type EncryptionRotation struct {
+	Apps  int `json:"apps"`
+	Users int `json:"users"`
+}
+
+// RotateEncryptionKey re-encrypts the installed apps' secrets and the OAuth2
+// user records with the current encryption key. It is run after the key is
+// changed, with the old key kept as the previous one until it completes.
+func (p *Proxy) RotateEncryptionKey() (*EncryptionRotation, error) {
+	r := &EncryptionRotation{}
+	var err error
+	r.Apps, err = p.store.App.ReencryptSecrets()
+	if err != nil {
+		return r, errors.Wrap(err, "failed to re-encrypt app secrets")
+	}
+	r.Users, err = p.store.OAuth2.ReencryptUsers()
+	if err != nil {
+		return r, errors.Wrap(err, "failed to re-encrypt OAuth2 user records")
+	}
+	p.log.Infow("Re-encrypted with the current encryption key", "apps", r.Apps, "users", r.Users)
+	return r, nil
+}
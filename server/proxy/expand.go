@@ -129,8 +129,14 @@ func (e *expander) ExpandForApp(app *apps.App, expand *apps.Expand) (*apps.Conte
 	}
 	clone.ExpandedContext.Team = stripTeam(e.Team, expand.Team)
 
-	// TODO: expand Mentions, maybe replacing User?
-	// https://mattermost.atlassian.net/browse/MM-30403
+	if expand.Mentioned != "" && e.Mentioned == nil {
+		mentioned, err := e.getMentionedUsers()
+		if err != nil {
+			return nil, err
+		}
+		e.Mentioned = mentioned
+	}
+	clone.ExpandedContext.Mentioned = stripUsers(e.Mentioned, expand.Mentioned)
 
 	if expand.User != "" && e.UserID != "" && e.User == nil {
 		user, err := e.mm.User.Get(e.UserID)
@@ -163,6 +169,95 @@ func (e *expander) ExpandForApp(app *apps.App, expand *apps.Expand) (*apps.Conte
 	return &clone, nil
 }
 
+// getMentionedUsers returns the users @-mentioned in the post, or in the root
+// post if there is no post in the context.
+func (e *expander) getMentionedUsers() ([]*model.User, error) {
+	var message string
+	switch {
+	case e.PostID != "":
+		if e.Post == nil {
+			post, err := e.mm.Post.GetPost(e.PostID)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to expand post %s", e.PostID)
+			}
+			e.Post = post
+		}
+		message = e.Post.Message
+
+	case e.RootPostID != "":
+		if e.RootPost == nil {
+			post, err := e.mm.Post.GetPost(e.RootPostID)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to expand root post %s", e.RootPostID)
+			}
+			e.RootPost = post
+		}
+		message = e.RootPost.Message
+	}
+
+	// Mentions may be followed by punctuation, e.g. "@user.", so also look up
+	// the names without a trailing special character, like the server does
+	// when sending notifications.
+	names := model.PossibleAtMentions(message)
+	var lookup []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		candidates := []string{name}
+		if trimmed, ok := model.TrimUsernameSpecialChar(name); ok {
+			candidates = append(candidates, trimmed)
+		}
+		for _, c := range candidates {
+			if !seen[c] {
+				lookup = append(lookup, c)
+				seen[c] = true
+			}
+		}
+	}
+	if len(lookup) == 0 {
+		return []*model.User{}, nil
+	}
+
+	users, err := e.mm.User.ListByUsernames(lookup)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to expand mentioned users")
+	}
+	byUsername := map[string]*model.User{}
+	for _, user := range users {
+		byUsername[user.Username] = user
+	}
+
+	mentioned := []*model.User{}
+	added := map[string]bool{}
+	for _, name := range names {
+		user := byUsername[name]
+		if user == nil {
+			if trimmed, ok := model.TrimUsernameSpecialChar(name); ok {
+				user = byUsername[trimmed]
+			}
+		}
+		if user == nil || added[user.Id] {
+			continue
+		}
+		mentioned = append(mentioned, user)
+		added[user.Id] = true
+		if len(mentioned) == apps.MaxMentionedUsers {
+			break
+		}
+	}
+	return mentioned, nil
+}
+
+func stripUsers(users []*model.User, level apps.ExpandLevel) []*model.User {
+	if level != apps.ExpandAll && level != apps.ExpandSummary {
+		return nil
+	}
+	out := make([]*model.User, 0, len(users))
+	for _, user := range users {
+		out = append(out, stripUser(user, level))
+	}
+	return out
+}
+
 func stripUser(user *model.User, level apps.ExpandLevel) *model.User {
 	if user == nil {
 		return user
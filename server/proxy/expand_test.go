@@ -0,0 +1,102 @@
+package proxy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+)
+
+func TestExpandMentioned(t *testing.T) {
+	app := &apps.App{
+		Manifest: apps.Manifest{
+			AppID: "app1",
+		},
+	}
+	newExpander := func(testAPI *plugintest.API, cc *apps.Context) *expander {
+		mm := pluginapi.NewClient(testAPI, &plugintest.Driver{})
+		conf := config.NewTestConfigurator(config.Config{}).WithMattermostConfig(model.Config{
+			ServiceSettings: model.ServiceSettings{
+				SiteURL: model.NewString("test.mattermost.com"),
+			},
+		})
+		p := &Proxy{}
+		return p.newExpander(cc, mm, conf, nil, "")
+	}
+	users := []*model.User{
+		{Id: "id1", Username: "alice", Email: "alice@example.com", Password: "secret"},
+		{Id: "id2", Username: "bob", Email: "bob@example.com"},
+	}
+
+	t.Run("from post", func(t *testing.T) {
+		testAPI := &plugintest.API{}
+		testAPI.On("GetUsersByUsernames", []string{"bob.", "bob", "alice", "nobody"}).Return(users, nil)
+		e := newExpander(testAPI, &apps.Context{
+			UserAgentContext: apps.UserAgentContext{PostID: "post1"},
+			ExpandedContext: apps.ExpandedContext{
+				Post: &model.Post{Id: "post1", Message: "@bob. please ask @alice, and @nobody, or @bob again"},
+			},
+		})
+
+		cc, err := e.ExpandForApp(app, &apps.Expand{Mentioned: apps.ExpandAll})
+		require.NoError(t, err)
+		require.Len(t, cc.Mentioned, 2)
+		require.Equal(t, "id2", cc.Mentioned[0].Id)
+		require.Equal(t, "id1", cc.Mentioned[1].Id)
+		require.Equal(t, "", cc.Mentioned[1].Password)
+
+		cc, err = e.ExpandForApp(app, &apps.Expand{Mentioned: apps.ExpandSummary})
+		require.NoError(t, err)
+		require.Equal(t, &model.User{Id: "id2", Username: "bob", Email: "bob@example.com"}, cc.Mentioned[0])
+
+		cc, err = e.ExpandForApp(app, &apps.Expand{})
+		require.NoError(t, err)
+		require.Nil(t, cc.Mentioned)
+		testAPI.AssertNumberOfCalls(t, "GetUsersByUsernames", 1)
+	})
+
+	t.Run("from root post", func(t *testing.T) {
+		testAPI := &plugintest.API{}
+		testAPI.On("GetPost", "root1").Return(&model.Post{Id: "root1", Message: "hey @alice"}, nil)
+		testAPI.On("GetUsersByUsernames", []string{"alice"}).Return(users[:1], nil)
+		e := newExpander(testAPI, &apps.Context{
+			UserAgentContext: apps.UserAgentContext{RootPostID: "root1"},
+		})
+
+		cc, err := e.ExpandForApp(app, &apps.Expand{Mentioned: apps.ExpandSummary})
+		require.NoError(t, err)
+		require.Len(t, cc.Mentioned, 1)
+		require.Equal(t, "alice", cc.Mentioned[0].Username)
+	})
+
+	t.Run("capped", func(t *testing.T) {
+		message := ""
+		var many []*model.User
+		for i := 0; i < apps.MaxMentionedUsers+5; i++ {
+			username := fmt.Sprintf("user%v", i)
+			message += " @" + username
+			many = append(many, &model.User{Id: "id" + username, Username: username})
+		}
+		testAPI := &plugintest.API{}
+		testAPI.On("GetUsersByUsernames", mock.Anything).Return(many, nil)
+		e := newExpander(testAPI, &apps.Context{
+			UserAgentContext: apps.UserAgentContext{PostID: "post1"},
+			ExpandedContext: apps.ExpandedContext{
+				Post: &model.Post{Id: "post1", Message: message},
+			},
+		})
+
+		cc, err := e.ExpandForApp(app, &apps.Expand{Mentioned: apps.ExpandSummary})
+		require.NoError(t, err)
+		require.Len(t, cc.Mentioned, apps.MaxMentionedUsers)
+		require.Equal(t, "user0", cc.Mentioned[0].Username)
+	})
+}
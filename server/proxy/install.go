@@ -30,11 +30,13 @@ func (p *Proxy) InstallApp(client mmclient.Client, sessionID string, cc *apps.Co
 	}
 
 	app, err := p.store.App.Get(cc.AppID)
+	isNew := false
 	if err != nil {
 		if !errors.Is(err, utils.ErrNotFound) {
 			return nil, "", errors.Wrap(err, "failed to find existing app")
 		}
 		app = &apps.App{}
+		isNew = true
 	}
 
 	app.Manifest = *m
@@ -75,6 +77,13 @@ func (p *Proxy) InstallApp(client mmclient.Client, sessionID string, cc *apps.Co
 		app.Trusted = trusted
 	}
 
+	if isNew {
+		err = p.store.AppKV.InitIndex(app.BotUserID)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to initialize the app's KV index")
+		}
+	}
+
 	err = p.store.App.Save(app)
 	if err != nil {
 		return nil, "", err
@@ -86,7 +95,7 @@ func (p *Proxy) InstallApp(client mmclient.Client, sessionID string, cc *apps.Co
 			Call:    *app.OnInstall,
 			Context: cc,
 		}
-		resp := p.Call(sessionID, cc.ActingUserID, creq)
+		resp := p.call(sessionID, cc.ActingUserID, creq)
 		// TODO fail on all errors except 404
 		if resp.Type == apps.CallResponseTypeError {
 			p.log.WithError(err).Warnw("OnInstall failed, installing app anyway", "app_id", app.AppID)
@@ -102,6 +111,7 @@ func (p *Proxy) InstallApp(client mmclient.Client, sessionID string, cc *apps.Co
 	p.log.Infow("Installed an app",
 		"app_id", app.AppID)
 
+	p.invalidateBindings(app.AppID, "")
 	p.dispatchRefreshBindingsEvent(cc.ActingUserID)
 
 	return app, message, nil
@@ -191,7 +201,7 @@ func (p *Proxy) ensureBot(client mmclient.Client, app *apps.App) error {
 	// Create an access token on a fresh app install
 	if app.RequestedPermissions.Contains(apps.PermissionActAsBot) &&
 		app.BotAccessTokenID == "" {
-		token, err := client.CreateUserAccessToken(bot.UserId, "Mattermost App Token")
+		token, err := client.CreateUserAccessToken(bot.UserId, botAccessTokenDescription)
 		if err != nil {
 			return errors.Wrap(err, "failed to create bot user's access token")
 		}
@@ -0,0 +1,278 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+const (
+	// JobRunInterval is how often the pending jobs are checked, for the
+	// retries, and for the jobs whose node went away.
+	JobRunInterval = time.Minute
+
+	// jobMaxAttempts is how many times a job is attempted before it is
+	// marked as failed.
+	jobMaxAttempts = 5
+
+	// jobClaimTimeout is how long a node may run a job before it is
+	// considered gone, and the job is run again.
+	jobClaimTimeout = 10 * time.Minute
+
+	// A failed job is retried with a backoff that doubles after each attempt,
+	// starting with jobRetryInitialBackoff.
+	jobRetryInitialBackoff = time.Minute
+	jobRetryMaxBackoff     = time.Hour
+
+	// jobRetention is how long the finished jobs are kept, to tell that they
+	// have already run.
+	jobRetention = 30 * 24 * time.Hour
+
+	// versionChangedJobRetention is how long the done version_changed jobs are
+	// kept. It only needs to cover the nodes synchronizing the same change, so
+	// that an upgrade to the same version after a rollback calls
+	// OnVersionChanged again.
+	versionChangedJobRetention = jobClaimTimeout
+)
+
+const (
+	jobTypeVersionChanged       = "version_changed"
+	jobTypeRotatedSecretExpired = "rotated_secret_expired"
+)
+
+// jobHandler runs a job. It must be registered for the job's type on every
+// node.
+type jobHandler func(job *store.Job) error
+
+func (p *Proxy) initJobHandlers() {
+	p.jobHandlers = map[string]jobHandler{
+		jobTypeVersionChanged:       p.runVersionChangedJob,
+		jobTypeRotatedSecretExpired: p.runRotatedSecretExpiredJob,
+	}
+}
+
+// jobID makes a job ID from the job's type and the values that identify it,
+// so that the nodes starting the same job agree on its ID.
+func jobID(jobType string, values ...string) string {
+	h := sha256.Sum256([]byte(jobType + "\x00" + strings.Join(values, "\x00")))
+	return hex.EncodeToString(h[:16])
+}
+
+// startJob creates a job, and runs it on this node unless another node
+// claims it first. If a job with the same ID already exists, it is left to
+// run (or not) as it is, so a job started by every node in the cluster runs
+// once.
+func (p *Proxy) startJob(jobType, id string, data map[string]string) error {
+	created, err := p.createJob(jobType, id, data, 0)
+	if err != nil || !created {
+		return err
+	}
+	go p.runJob(id)
+	return nil
+}
+
+// scheduleJob creates a job that RunJobs runs once at (in milliseconds) is
+// reached. Like with startJob, a job with the same ID is left as it is.
+func (p *Proxy) scheduleJob(jobType, id string, data map[string]string, at int64) error {
+	_, err := p.createJob(jobType, id, data, at)
+	return err
+}
+
+func (p *Proxy) createJob(jobType, id string, data map[string]string, nextAttemptAt int64) (bool, error) {
+	now := model.GetMillis()
+	created, err := p.store.Job.Create(&store.Job{
+		ID:            id,
+		Type:          jobType,
+		Data:          data,
+		Status:        store.JobStatusPending,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		NextAttemptAt: nextAttemptAt,
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to create job %s", id)
+	}
+	if !created {
+		p.log.Debugw("Job already exists, not started again", "job_id", id, "type", jobType)
+	}
+	return created, nil
+}
+
+// RunJobs starts the jobs that are due to be retried, or that were claimed by
+// a node that went away, and deletes the old finished jobs. It runs as a
+// scheduled cluster job. The jobs run in the background, the claims keep
+// them from being started again by the next run.
+func (p *Proxy) RunJobs() {
+	jobs, err := p.store.Job.List()
+	if err != nil {
+		p.log.WithError(err).Errorf("Failed to list jobs")
+		return
+	}
+
+	now := model.GetMillis()
+	for _, job := range jobs {
+		switch {
+		case isJobClaimable(job, now):
+			go p.runJob(job.ID)
+
+		case isJobExpired(job, now):
+			err = p.store.Job.Delete(job.ID)
+			if err != nil {
+				p.log.WithError(err).Warnw("Failed to delete an old job", "job_id", job.ID)
+			}
+		}
+	}
+}
+
+func isJobExpired(job *store.Job, now int64) bool {
+	retention := jobRetention
+	switch job.Status {
+	case store.JobStatusDone:
+		if job.Type == jobTypeVersionChanged {
+			retention = versionChangedJobRetention
+		}
+	case store.JobStatusFailed:
+	default:
+		return false
+	}
+	return job.UpdatedAt < now-retention.Milliseconds()
+}
+
+func isJobClaimable(job *store.Job, now int64) bool {
+	switch job.Status {
+	case store.JobStatusPending:
+		return job.NextAttemptAt <= now
+	case store.JobStatusRunning:
+		return job.ClaimedUntil < now
+	default:
+		return false
+	}
+}
+
+// runJob claims a job, runs it, and records the outcome. It does nothing if
+// the job is not claimable, e.g. it is already being run by another node.
+func (p *Proxy) runJob(id string) {
+	var attempt int
+	job, claimed, err := p.store.Job.Update(id, func(job *store.Job) bool {
+		now := model.GetMillis()
+		if !isJobClaimable(job, now) {
+			return false
+		}
+		job.Status = store.JobStatusRunning
+		job.Attempts++
+		job.UpdatedAt = now
+		job.NextAttemptAt = 0
+		job.ClaimedUntil = now + jobClaimTimeout.Milliseconds()
+		attempt = job.Attempts
+		return true
+	})
+	if err != nil {
+		p.log.WithError(err).Warnw("Failed to claim a job", "job_id", id)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	var runErr error
+	handler := p.jobHandlers[job.Type]
+	if handler == nil {
+		runErr = errors.Errorf("unknown job type %q", job.Type)
+	} else {
+		runErr = handler(job)
+	}
+
+	job, recorded, err := p.store.Job.Update(id, func(job *store.Job) bool {
+		if job.Status != store.JobStatusRunning || job.Attempts != attempt {
+			// The claim expired, and the job was claimed again.
+			return false
+		}
+		now := model.GetMillis()
+		job.UpdatedAt = now
+		job.ClaimedUntil = 0
+		switch {
+		case runErr == nil:
+			job.Status = store.JobStatusDone
+			job.Error = ""
+		case job.Attempts >= jobMaxAttempts:
+			job.Status = store.JobStatusFailed
+			job.Error = runErr.Error()
+		default:
+			job.Status = store.JobStatusPending
+			job.Error = runErr.Error()
+			job.NextAttemptAt = now + jobRetryBackoff(job.Attempts).Milliseconds()
+		}
+		return true
+	})
+	if err != nil {
+		p.log.WithError(err).Errorw("Failed to save the outcome of a job", "job_id", id)
+		return
+	}
+	if !recorded {
+		p.log.Warnw("Job took longer than its claim, the outcome was discarded", "job_id", id, "type", job.Type)
+		return
+	}
+	if runErr != nil {
+		p.log.WithError(runErr).Warnw("Job failed",
+			"job_id", id, "type", job.Type, "attempts", job.Attempts, "status", job.Status)
+		return
+	}
+	p.log.Debugw("Job done", "job_id", id, "type", job.Type)
+}
+
+func jobRetryBackoff(attempts int) time.Duration {
+	backoff := jobRetryInitialBackoff
+	for i := 1; i < attempts && backoff < jobRetryMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > jobRetryMaxBackoff {
+		backoff = jobRetryMaxBackoff
+	}
+	return backoff
+}
+
+// ListJobs returns the jobs, most recently created first.
+func (p *Proxy) ListJobs() ([]*store.Job, error) {
+	jobs, err := p.store.Job.List()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt > jobs[j].CreatedAt
+	})
+	return jobs, nil
+}
+
+// RetryJob resets a failed job with a fresh set of attempts. It will run on
+// the next run of RunJobs.
+func (p *Proxy) RetryJob(id string) error {
+	_, changed, err := p.store.Job.Update(id, func(job *store.Job) bool {
+		if job.Status != store.JobStatusFailed {
+			return false
+		}
+		job.Status = store.JobStatusPending
+		job.Attempts = 0
+		job.NextAttemptAt = 0
+		job.UpdatedAt = model.GetMillis()
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return utils.NewInvalidError("job %s has not failed", id)
+	}
+	return nil
+}
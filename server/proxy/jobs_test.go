@@ -0,0 +1,156 @@
+// +build !e2e
+
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/mocks/mock_store"
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestRunJob(t *testing.T) {
+	setup := func(t *testing.T, job *store.Job, handler jobHandler) *Proxy {
+		ctrl := gomock.NewController(t)
+		jobStore := mock_store.NewMockJobStore(ctrl)
+		// Update applies the changes to job, as if it were stored.
+		jobStore.EXPECT().Update(job.ID, gomock.Any()).DoAndReturn(
+			func(id string, update func(*store.Job) bool) (*store.Job, bool, error) {
+				updated := *job
+				if !update(&updated) {
+					return job, false, nil
+				}
+				*job = updated
+				return job, true, nil
+			}).AnyTimes()
+		return &Proxy{
+			log:   utils.NewTestLogger(),
+			store: &store.Service{Job: jobStore},
+			jobHandlers: map[string]jobHandler{
+				"test": handler,
+			},
+		}
+	}
+
+	t.Run("done", func(t *testing.T) {
+		job := &store.Job{ID: "id1", Type: "test", Status: store.JobStatusPending}
+		ran := 0
+		p := setup(t, job, func(*store.Job) error {
+			ran++
+			return nil
+		})
+
+		p.runJob("id1")
+		require.Equal(t, 1, ran)
+		require.Equal(t, store.JobStatusDone, job.Status)
+		require.Equal(t, 1, job.Attempts)
+		require.Zero(t, job.ClaimedUntil)
+
+		// A finished job is not run again.
+		p.runJob("id1")
+		require.Equal(t, 1, ran)
+	})
+
+	t.Run("retried", func(t *testing.T) {
+		job := &store.Job{ID: "id1", Type: "test", Status: store.JobStatusPending}
+		p := setup(t, job, func(*store.Job) error {
+			return errors.New("test error")
+		})
+
+		before := model.GetMillis()
+		p.runJob("id1")
+		require.Equal(t, store.JobStatusPending, job.Status)
+		require.Equal(t, 1, job.Attempts)
+		require.Equal(t, "test error", job.Error)
+		require.GreaterOrEqual(t, job.NextAttemptAt, before+jobRetryInitialBackoff.Milliseconds())
+
+		// Not due yet.
+		p.runJob("id1")
+		require.Equal(t, 1, job.Attempts)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		job := &store.Job{ID: "id1", Type: "test", Status: store.JobStatusPending, Attempts: jobMaxAttempts - 1}
+		p := setup(t, job, func(*store.Job) error {
+			return errors.New("test error")
+		})
+
+		p.runJob("id1")
+		require.Equal(t, store.JobStatusFailed, job.Status)
+		require.Equal(t, jobMaxAttempts, job.Attempts)
+
+		err := p.RetryJob("id1")
+		require.NoError(t, err)
+		require.Equal(t, store.JobStatusPending, job.Status)
+		require.Zero(t, job.Attempts)
+	})
+
+	t.Run("claimed by another node", func(t *testing.T) {
+		job := &store.Job{ID: "id1", Type: "test", Status: store.JobStatusRunning, ClaimedUntil: model.GetMillis() + 1000}
+		p := setup(t, job, func(*store.Job) error {
+			require.Fail(t, "must not run")
+			return nil
+		})
+
+		p.runJob("id1")
+		require.Equal(t, store.JobStatusRunning, job.Status)
+	})
+
+	t.Run("claim expired", func(t *testing.T) {
+		job := &store.Job{ID: "id1", Type: "test", Status: store.JobStatusRunning, Attempts: 1, ClaimedUntil: model.GetMillis() - 1000}
+		p := setup(t, job, func(*store.Job) error {
+			return nil
+		})
+
+		p.runJob("id1")
+		require.Equal(t, store.JobStatusDone, job.Status)
+		require.Equal(t, 2, job.Attempts)
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		job := &store.Job{ID: "id1", Type: "other", Status: store.JobStatusPending}
+		p := setup(t, job, nil)
+
+		p.runJob("id1")
+		require.Equal(t, store.JobStatusPending, job.Status)
+		require.Equal(t, `unknown job type "other"`, job.Error)
+	})
+}
+
+func TestJobID(t *testing.T) {
+	id := jobID(jobTypeVersionChanged, "app1", "v1", "v2")
+	require.Len(t, id, 32)
+	require.Equal(t, id, jobID(jobTypeVersionChanged, "app1", "v1", "v2"))
+	require.NotEqual(t, id, jobID(jobTypeVersionChanged, "app1", "v1", "v3"))
+}
+
+func TestIsJobExpired(t *testing.T) {
+	now := model.GetMillis()
+	ago := func(d time.Duration) int64 { return now - d.Milliseconds() }
+
+	for name, tc := range map[string]struct {
+		job      store.Job
+		expected bool
+	}{
+		"pending":                {store.Job{Type: "test", Status: store.JobStatusPending, UpdatedAt: ago(2 * jobRetention)}, false},
+		"running":                {store.Job{Type: "test", Status: store.JobStatusRunning, UpdatedAt: ago(2 * jobRetention)}, false},
+		"done recently":          {store.Job{Type: "test", Status: store.JobStatusDone, UpdatedAt: ago(time.Hour)}, false},
+		"done long ago":          {store.Job{Type: "test", Status: store.JobStatusDone, UpdatedAt: ago(2 * jobRetention)}, true},
+		"failed":                 {store.Job{Type: jobTypeVersionChanged, Status: store.JobStatusFailed, UpdatedAt: ago(time.Hour)}, false},
+		"version changed":        {store.Job{Type: jobTypeVersionChanged, Status: store.JobStatusDone, UpdatedAt: ago(time.Minute)}, false},
+		"version changed before": {store.Job{Type: jobTypeVersionChanged, Status: store.JobStatusDone, UpdatedAt: ago(time.Hour)}, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			job := tc.job
+			require.Equal(t, tc.expected, isJobExpired(&job, now))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package proxy
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
+)
+
+// KVUsageRecountInterval is how often the apps' KV usage is recounted from
+// their values, to correct the changes that were not counted, e.g. because of
+// a failure, or because the values were set before the usage was counted.
+const KVUsageRecountInterval = 24 * time.Hour
+
+// KVUsage is how much of the KV store an app uses, and its quota.
+type KVUsage struct {
+	store.KVUsage
+	Quota config.KVQuota `json:"quota"`
+}
+
+// GetKVUsage returns how much of the KV store an app uses, including its
+// per-user values.
+func (p *Proxy) GetKVUsage(appID apps.AppID) (*KVUsage, error) {
+	app, err := p.store.App.Get(appID)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := p.store.AppKV.GetUsage(app.BotUserID)
+	if err != nil {
+		return nil, err
+	}
+	return &KVUsage{
+		KVUsage: *usage,
+		Quota:   p.conf.GetConfig().KVQuota(),
+	}, nil
+}
+
+// RecountKVUsage recounts the KV usage of all apps. It runs as a scheduled
+// cluster job; the first run after an upgrade counts the existing values.
+func (p *Proxy) RecountKVUsage() {
+	for _, app := range p.store.App.AsMap() {
+		err := p.store.AppKV.RecountUsage(app.BotUserID)
+		if err != nil {
+			p.log.WithError(err).Warnw("Failed to recount the KV usage", "app_id", app.AppID)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package proxy
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+// LifecyclePollInterval is how often the subscribed channels are polled for
+// the lifecycle subjects that have no plugin hooks. The server can not list
+// the users changed since a time, so all users are listed to detect the
+// changes, less often, every LifecycleUsersPollInterval.
+const (
+	LifecyclePollInterval      = time.Minute
+	LifecycleUsersPollInterval = 5 * time.Minute
+)
+
+const (
+	lifecycleUsersSnapshot        = "users"
+	lifecycleChannelsSnapshot     = "channels"
+	lifecycleDeletedPostsSnapshot = "deleted_posts"
+	lifecycleUsersPerPage         = 200
+)
+
+// PollLifecycleEvents detects the channel changes, and the deleted posts, that
+// the server does not report to plugins, by comparing them with the last known
+// state, and notifies the subscribed apps. It runs as a scheduled cluster job.
+// The channels are only polled while there are subscriptions for them; the
+// first poll records the state, and does not notify.
+func (p *Proxy) PollLifecycleEvents() {
+	err := p.pollChannels()
+	if err != nil {
+		p.log.WithError(err).Warnf("Failed to poll channels for lifecycle notifications")
+	}
+	err = p.pollDeletedPosts()
+	if err != nil {
+		p.log.WithError(err).Warnf("Failed to poll channels for deleted posts")
+	}
+}
+
+// PollUserLifecycleEvents is PollLifecycleEvents for the users. It runs as a
+// separate cluster job, less often.
+func (p *Proxy) PollUserLifecycleEvents() {
+	err := p.pollUsers()
+	if err != nil {
+		p.log.WithError(err).Warnf("Failed to poll users for lifecycle notifications")
+	}
+}
+
+func (p *Proxy) pollUsers() error {
+	subscribed, err := p.hasSubscriptions(apps.SubjectUserUpdated)
+	if err != nil {
+		return err
+	}
+	if !subscribed {
+		subscribed, err = p.hasSubscriptions(apps.SubjectUserDeactivated)
+		if err != nil {
+			return err
+		}
+	}
+	if !subscribed {
+		return p.store.Lifecycle.DeleteSnapshot(lifecycleUsersSnapshot)
+	}
+
+	prev, err := p.store.Lifecycle.GetSnapshot(lifecycleUsersSnapshot)
+	if err != nil {
+		return err
+	}
+	current := map[string]store.EntityState{}
+	for page := 0; ; page++ {
+		users, err := p.mm.User.List(&model.UserGetOptions{
+			Page:    page,
+			PerPage: lifecycleUsersPerPage,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to list users - page, %d", page)
+		}
+
+		for _, user := range users {
+			state := store.EntityState{
+				UpdateAt: user.UpdateAt,
+				DeleteAt: user.DeleteAt,
+			}
+			current[user.Id] = state
+
+			// New users are notified with user_created.
+			before, ok := prev[user.Id]
+			if !ok || before == state {
+				continue
+			}
+			subject := apps.SubjectUserUpdated
+			if before.DeleteAt == 0 && state.DeleteAt != 0 {
+				subject = apps.SubjectUserDeactivated
+			}
+			p.notifyLifecycle(&apps.Context{
+				UserID: user.Id,
+				ExpandedContext: apps.ExpandedContext{
+					User: user,
+				},
+			}, subject)
+		}
+
+		if len(users) < lifecycleUsersPerPage {
+			break
+		}
+	}
+
+	return p.store.Lifecycle.SaveSnapshot(lifecycleUsersSnapshot, prev, current)
+}
+
+func (p *Proxy) pollChannels() error {
+	subscribed := map[string]bool{}
+	for _, subject := range []apps.Subject{
+		apps.SubjectChannelUpdated,
+		apps.SubjectChannelArchived,
+		apps.SubjectChannelRestored,
+	} {
+		channelIDs, err := p.store.Subscription.ListSubscribedChannels(subject)
+		if err != nil {
+			return err
+		}
+		for _, channelID := range channelIDs {
+			subscribed[channelID] = true
+		}
+	}
+	if len(subscribed) == 0 {
+		return p.store.Lifecycle.DeleteSnapshot(lifecycleChannelsSnapshot)
+	}
+
+	prev, err := p.store.Lifecycle.GetSnapshot(lifecycleChannelsSnapshot)
+	if err != nil {
+		return err
+	}
+	channelIDs := make([]string, 0, len(subscribed))
+	for channelID := range subscribed {
+		channelIDs = append(channelIDs, channelID)
+	}
+	sort.Strings(channelIDs)
+
+	current := map[string]store.EntityState{}
+	for _, channelID := range channelIDs {
+		ch, err := p.mm.Channel.Get(channelID)
+		if err != nil {
+			p.log.WithError(err).Debugw("Failed to get a channel for lifecycle notifications",
+				"channel_id", channelID)
+			if before, ok := prev[channelID]; ok {
+				current[channelID] = before
+			}
+			continue
+		}
+		state := store.EntityState{
+			UpdateAt: ch.UpdateAt,
+			DeleteAt: ch.DeleteAt,
+		}
+		current[channelID] = state
+
+		// The first time a channel is polled, its state is only recorded.
+		before, ok := prev[channelID]
+		if !ok || before == state {
+			continue
+		}
+		subject := apps.SubjectChannelUpdated
+		switch {
+		case before.DeleteAt == 0 && state.DeleteAt != 0:
+			subject = apps.SubjectChannelArchived
+		case before.DeleteAt != 0 && state.DeleteAt == 0:
+			subject = apps.SubjectChannelRestored
+		}
+		p.notifyLifecycle(&apps.Context{
+			UserAgentContext: apps.UserAgentContext{
+				TeamID:    ch.TeamId,
+				ChannelID: ch.Id,
+			},
+			ExpandedContext: apps.ExpandedContext{
+				Channel: ch,
+			},
+		}, subject)
+	}
+
+	return p.store.Lifecycle.SaveSnapshot(lifecycleChannelsSnapshot, prev, current)
+}
+
+// pollDeletedPosts lists the posts changed in each channel subscribed to
+// post_deleted since the last poll, and notifies the deleted ones. The
+// snapshot keeps the last UpdateAt seen in each channel as its UpdateAt.
+func (p *Proxy) pollDeletedPosts() error {
+	channelIDs, err := p.store.Subscription.ListSubscribedChannels(apps.SubjectPostDeleted)
+	if err != nil {
+		return err
+	}
+	if len(channelIDs) == 0 {
+		return p.store.Lifecycle.DeleteSnapshot(lifecycleDeletedPostsSnapshot)
+	}
+
+	prev, err := p.store.Lifecycle.GetSnapshot(lifecycleDeletedPostsSnapshot)
+	if err != nil {
+		return err
+	}
+	sort.Strings(channelIDs)
+
+	now := model.GetMillis()
+	current := map[string]store.EntityState{}
+	for _, channelID := range channelIDs {
+		// The first time a channel is polled, the time is only recorded.
+		before, ok := prev[channelID]
+		if !ok {
+			current[channelID] = store.EntityState{UpdateAt: now}
+			continue
+		}
+		current[channelID] = before
+
+		list, err := p.mm.Post.GetPostsSince(channelID, before.UpdateAt)
+		if err != nil {
+			p.log.WithError(err).Debugw("Failed to get the posts of a channel for post_deleted notifications",
+				"channel_id", channelID)
+			continue
+		}
+		posts := make([]*model.Post, 0, len(list.Posts))
+		for _, post := range list.Posts {
+			posts = append(posts, post)
+		}
+		sort.Slice(posts, func(i, j int) bool {
+			return posts[i].UpdateAt < posts[j].UpdateAt
+		})
+
+		// The server returns a limited number of posts, the next poll
+		// continues after the last one.
+		state := before
+		for _, post := range posts {
+			if post.UpdateAt > state.UpdateAt {
+				state.UpdateAt = post.UpdateAt
+			}
+			if post.DeleteAt <= before.UpdateAt {
+				continue
+			}
+			p.notifyLifecycle(&apps.Context{
+				UserAgentContext: apps.UserAgentContext{
+					PostID:     post.Id,
+					RootPostID: post.RootId,
+					ChannelID:  post.ChannelId,
+				},
+				UserID: post.UserId,
+				ExpandedContext: apps.ExpandedContext{
+					Post: post,
+				},
+			}, apps.SubjectPostDeleted)
+		}
+		current[channelID] = state
+	}
+
+	return p.store.Lifecycle.SaveSnapshot(lifecycleDeletedPostsSnapshot, prev, current)
+}
+
+func (p *Proxy) notifyLifecycle(cc *apps.Context, subject apps.Subject) {
+	cc = p.conf.GetConfig().SetContextDefaults(cc)
+	err := p.Notify(cc, subject)
+	if err != nil && !errors.Is(err, utils.ErrNotFound) {
+		p.log.WithError(err).Warnw("Failed to notify a lifecycle event",
+			"subject", subject, "user_id", cc.UserID, "channel_id", cc.ChannelID)
+	}
+}
+
+// hasSubscriptions checks if there are subscriptions to a global subject.
+func (p *Proxy) hasSubscriptions(subject apps.Subject) (bool, error) {
+	_, err := p.store.Subscription.Get(subject, "", "")
+	switch {
+	case errors.Is(err, utils.ErrNotFound):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return true, nil
+}
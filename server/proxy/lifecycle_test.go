@@ -0,0 +1,140 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/server/mocks/mock_store"
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestPollLifecycleEvents(t *testing.T) {
+	// Notify skips the subscriptions without a Call, so the expected
+	// Subscription.Get calls are what is verified.
+	subs := []*apps.Subscription{{AppID: "app1"}}
+
+	newTestProxy := func(ctrl *gomock.Controller, testAPI *plugintest.API) (*Proxy, *mock_store.MockSubscriptionStore, *mock_store.MockLifecycleStore) {
+		mm := pluginapi.NewClient(testAPI, &plugintest.Driver{})
+		conf := config.NewTestConfigurator(config.Config{})
+		s := store.NewService(mm, utils.NewTestLogger(), conf, nil, "", nil)
+		subStore := mock_store.NewMockSubscriptionStore(ctrl)
+		s.Subscription = subStore
+		lifecycleStore := mock_store.NewMockLifecycleStore(ctrl)
+		s.Lifecycle = lifecycleStore
+		return &Proxy{
+			mm:    mm,
+			log:   utils.NewTestLogger(),
+			store: s,
+			conf:  conf,
+		}, subStore, lifecycleStore
+	}
+
+	t.Run("users", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		testAPI := &plugintest.API{}
+		testAPI.On("GetUsers", mock.Anything).Return([]*model.User{
+			{Id: "unchanged", UpdateAt: 1},
+			{Id: "updated", UpdateAt: 2},
+			{Id: "deactivated", UpdateAt: 2, DeleteAt: 2},
+			{Id: "new", UpdateAt: 2},
+		}, nil)
+		p, subStore, lifecycleStore := newTestProxy(ctrl, testAPI)
+
+		subStore.EXPECT().Get(apps.SubjectUserUpdated, "", "").Return(subs, nil).Times(2)
+		subStore.EXPECT().Get(apps.SubjectUserDeactivated, "", "").Return(subs, nil)
+		prev := map[string]store.EntityState{
+			"unchanged":   {UpdateAt: 1},
+			"updated":     {UpdateAt: 1},
+			"deactivated": {UpdateAt: 1},
+		}
+		lifecycleStore.EXPECT().GetSnapshot(lifecycleUsersSnapshot).Return(prev, nil)
+		lifecycleStore.EXPECT().SaveSnapshot(lifecycleUsersSnapshot, prev, map[string]store.EntityState{
+			"unchanged":   {UpdateAt: 1},
+			"updated":     {UpdateAt: 2},
+			"deactivated": {UpdateAt: 2, DeleteAt: 2},
+			"new":         {UpdateAt: 2},
+		}).Return(nil)
+
+		p.PollUserLifecycleEvents()
+	})
+
+	t.Run("channels", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		testAPI := &plugintest.API{}
+		testAPI.On("GetChannel", "updated").Return(&model.Channel{Id: "updated", TeamId: "team1", UpdateAt: 2}, nil)
+		testAPI.On("GetChannel", "archived").Return(&model.Channel{Id: "archived", TeamId: "team1", UpdateAt: 2, DeleteAt: 2}, nil)
+		testAPI.On("GetChannel", "restored").Return(&model.Channel{Id: "restored", TeamId: "team1", UpdateAt: 3}, nil)
+		testAPI.On("GetChannel", "failing").Return(nil, model.NewAppError("GetChannel", "", nil, "", 500))
+		p, subStore, lifecycleStore := newTestProxy(ctrl, testAPI)
+
+		subStore.EXPECT().ListSubscribedChannels(apps.SubjectChannelUpdated).Return([]string{"updated", "failing"}, nil)
+		subStore.EXPECT().ListSubscribedChannels(apps.SubjectChannelArchived).Return([]string{"archived"}, nil)
+		subStore.EXPECT().ListSubscribedChannels(apps.SubjectChannelRestored).Return([]string{"restored", "archived"}, nil)
+		subStore.EXPECT().Get(apps.SubjectChannelUpdated, "team1", "updated").Return(subs, nil)
+		subStore.EXPECT().Get(apps.SubjectChannelArchived, "team1", "archived").Return(subs, nil)
+		subStore.EXPECT().Get(apps.SubjectChannelRestored, "team1", "restored").Return(subs, nil)
+		prev := map[string]store.EntityState{
+			"updated":  {UpdateAt: 1},
+			"archived": {UpdateAt: 1},
+			"restored": {UpdateAt: 2, DeleteAt: 2},
+			"failing":  {UpdateAt: 1},
+		}
+		lifecycleStore.EXPECT().GetSnapshot(lifecycleChannelsSnapshot).Return(prev, nil)
+		lifecycleStore.EXPECT().SaveSnapshot(lifecycleChannelsSnapshot, prev, map[string]store.EntityState{
+			"updated":  {UpdateAt: 2},
+			"archived": {UpdateAt: 2, DeleteAt: 2},
+			"restored": {UpdateAt: 3},
+			"failing":  {UpdateAt: 1},
+		}).Return(nil)
+		subStore.EXPECT().ListSubscribedChannels(apps.SubjectPostDeleted).Return(nil, nil)
+		lifecycleStore.EXPECT().DeleteSnapshot(lifecycleDeletedPostsSnapshot).Return(nil)
+
+		p.PollLifecycleEvents()
+	})
+
+	t.Run("deleted posts", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		testAPI := &plugintest.API{}
+		testAPI.On("GetPostsSince", "channel1", int64(10)).Return(&model.PostList{
+			Posts: map[string]*model.Post{
+				"edited":  {Id: "edited", ChannelId: "channel1", UpdateAt: 12},
+				"deleted": {Id: "deleted", ChannelId: "channel1", UpdateAt: 15, DeleteAt: 15},
+				// Deleted before the last poll, and listed again.
+				"old": {Id: "old", ChannelId: "channel1", UpdateAt: 10, DeleteAt: 10},
+			},
+		}, nil)
+		testAPI.On("GetPostsSince", "failing", int64(10)).Return(nil, model.NewAppError("GetPostsSince", "", nil, "", 500))
+		p, subStore, lifecycleStore := newTestProxy(ctrl, testAPI)
+
+		subStore.EXPECT().ListSubscribedChannels(apps.SubjectChannelUpdated).Return(nil, nil)
+		subStore.EXPECT().ListSubscribedChannels(apps.SubjectChannelArchived).Return(nil, nil)
+		subStore.EXPECT().ListSubscribedChannels(apps.SubjectChannelRestored).Return(nil, nil)
+		lifecycleStore.EXPECT().DeleteSnapshot(lifecycleChannelsSnapshot).Return(nil)
+		subStore.EXPECT().ListSubscribedChannels(apps.SubjectPostDeleted).Return([]string{"channel1", "failing", "new"}, nil)
+		subStore.EXPECT().Get(apps.SubjectPostDeleted, "", "channel1").Return(subs, nil)
+		prev := map[string]store.EntityState{
+			"channel1": {UpdateAt: 10},
+			"failing":  {UpdateAt: 10},
+		}
+		lifecycleStore.EXPECT().GetSnapshot(lifecycleDeletedPostsSnapshot).Return(prev, nil)
+		lifecycleStore.EXPECT().SaveSnapshot(lifecycleDeletedPostsSnapshot, prev, gomock.Any()).DoAndReturn(
+			func(_ string, _, current map[string]store.EntityState) error {
+				require.Equal(t, store.EntityState{UpdateAt: 15}, current["channel1"])
+				require.Equal(t, store.EntityState{UpdateAt: 10}, current["failing"])
+				require.NotZero(t, current["new"].UpdateAt)
+				return nil
+			})
+
+		p.PollLifecycleEvents()
+	})
+}
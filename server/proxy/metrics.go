@@ -0,0 +1,56 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/metrics"
+)
+
+var (
+	dispatcherRunningDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "", "dispatcher_running"),
+		"Notifications being sent to apps.",
+		[]string{"app_id"}, nil)
+	dispatcherQueuedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "", "dispatcher_queued"),
+		"Notifications waiting to be sent, because of the concurrency limits.",
+		[]string{"app_id"}, nil)
+	dispatcherRejectedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "", "dispatcher_rejected_total"),
+		"Notifications diverted to the retry queue because the dispatcher was full.",
+		[]string{"app_id"}, nil)
+)
+
+// dispatcherCollector reports the current load of the notification
+// dispatcher, at the time of the scrape.
+type dispatcherCollector struct {
+	dispatcher *dispatcher
+}
+
+var _ prometheus.Collector = (*dispatcherCollector)(nil)
+
+func (c *dispatcherCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dispatcherRunningDesc
+	ch <- dispatcherQueuedDesc
+	ch <- dispatcherRejectedDesc
+}
+
+func (c *dispatcherCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.dispatcher.stats()
+	for appID, s := range stats.Apps {
+		ch <- prometheus.MustNewConstMetric(dispatcherRunningDesc, prometheus.GaugeValue, float64(s.Running), string(appID))
+		ch <- prometheus.MustNewConstMetric(dispatcherQueuedDesc, prometheus.GaugeValue, float64(s.Queued), string(appID))
+		ch <- prometheus.MustNewConstMetric(dispatcherRejectedDesc, prometheus.CounterValue, float64(s.Rejected), string(appID))
+	}
+}
+
+// MetricsHandler serves the proxy's metrics in the Prometheus format,
+// including the current load of the notification dispatcher.
+func (p *Proxy) MetricsHandler() http.Handler {
+	return p.metrics.Handler()
+}
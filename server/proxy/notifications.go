@@ -0,0 +1,227 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package proxy
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/appservices"
+	"github.com/mattermost/mattermost-plugin-apps/server/metrics"
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
+	"github.com/mattermost/mattermost-plugin-apps/upstream"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+const (
+	// NotificationRetryInterval is how often the retry queue is processed.
+	NotificationRetryInterval = 30 * time.Second
+
+	// The delay before retrying a failed notification doubles with every
+	// attempt, starting with notificationRetryInitialBackoff.
+	notificationRetryInitialBackoff = 30 * time.Second
+	notificationRetryMaxBackoff     = time.Hour
+)
+
+func (p *Proxy) newNotificationRequest(expander *expander, app *apps.App, call apps.Call, subj apps.Subject) (*apps.CallRequest, error) {
+	cc, err := expander.ExpandForApp(app, call.Expand)
+	if err != nil {
+		return nil, err
+	}
+	cc.Subject = subj
+
+	return &apps.CallRequest{
+		Call:    call,
+		Context: cc,
+	}, nil
+}
+
+// queuedContext returns a copy of a notification context that is safe to
+// persist in the retry queue: it includes the Mattermost entities from the
+// event, but no credentials.
+func queuedContext(cc *apps.Context) *apps.Context {
+	clone := *cc
+	clone.ExpandedContext = apps.ExpandedContext{
+		ActingUser: stripUser(cc.ActingUser, apps.ExpandAll),
+		Channel:    cc.Channel,
+		Post:       cc.Post,
+		RootPost:   cc.RootPost,
+		Team:       cc.Team,
+		Reaction:   cc.Reaction,
+		User:       stripUser(cc.User, apps.ExpandAll),
+	}
+	return &clone
+}
+
+// sendNotification expands the notification's context for the app, and sends
+// it. Failures are queued to be retried. queued indicates that the
+// notification came from the retry queue, and is to be removed from it once
+// delivered.
+func (p *Proxy) sendNotification(n *store.Notification, queued bool) {
+	app, err := p.store.App.Get(n.AppID)
+	if errors.Is(err, utils.ErrNotFound) {
+		p.log.Debugw("App is no longer installed, dropped a notification",
+			"app_id", n.AppID, "notification_id", n.ID)
+		if queued {
+			_ = p.store.Notification.DeleteQueued(n.AppID, n.ID)
+		}
+		return
+	}
+
+	// The bot may have been removed from the channel or team since the app
+	// subscribed.
+	if err == nil {
+		err = appservices.EnsureCanSubscribe(p.mm, app, &apps.Subscription{
+			AppID:     n.AppID,
+			Subject:   n.Subject,
+			ChannelID: n.ChannelID,
+			TeamID:    n.TeamID,
+		})
+		if errors.Is(err, utils.ErrForbidden) {
+			p.log.WithError(err).Debugw("App is no longer allowed to receive the notification, dropped it",
+				"app_id", n.AppID, "subject", n.Subject, "notification_id", n.ID)
+			p.metrics.ObserveNotification(n.AppID, n.Subject, metrics.ResultForbidden)
+			if queued {
+				_ = p.store.Notification.DeleteQueued(n.AppID, n.ID)
+			}
+			return
+		}
+	}
+
+	var creq *apps.CallRequest
+	if err == nil {
+		expander := p.newExpander(n.Context, p.mm, p.conf, p.store, "")
+		creq, err = p.newNotificationRequest(expander, app, n.Call, n.Subject)
+	}
+	if err != nil {
+		n.Attempts++
+		n.Error = err.Error()
+		p.queueNotification(n)
+		return
+	}
+
+	p.deliverNotification(app, creq, n, queued)
+}
+
+// deliverNotification sends a notification to an app, and queues it to be
+// retried if the app fails to receive it.
+func (p *Proxy) deliverNotification(app *apps.App, creq *apps.CallRequest, n *store.Notification, queued bool) {
+	n.Attempts++
+	start := time.Now()
+	err := p.deliver(app, creq)
+	p.recordCall(app, CallRecord{
+		Source:       CallSourceNotification,
+		Path:         creq.Path,
+		Subject:      n.Subject,
+		ActingUserID: creq.Context.ActingUserID,
+	}, start, err)
+	if err != nil {
+		n.Error = err.Error()
+		p.queueNotification(n)
+		return
+	}
+
+	if queued {
+		err = p.store.Notification.DeleteQueued(n.AppID, n.ID)
+		if err != nil {
+			p.log.WithError(err).Warnw("Failed to remove a delivered notification from the retry queue",
+				"app_id", n.AppID, "notification_id", n.ID)
+		}
+	}
+}
+
+func (p *Proxy) deliver(app *apps.App, creq *apps.CallRequest) error {
+	up, err := p.upstreamForApp(app)
+	if err != nil {
+		return err
+	}
+	return upstream.Deliver(up, creq)
+}
+
+// queueNotification schedules a failed notification to be retried, or moves
+// it to the dead-letter list once it runs out of attempts.
+func (p *Proxy) queueNotification(n *store.Notification) {
+	if n.Attempts >= p.conf.GetConfig().MaxNotificationAttempts() {
+		n.NextAttemptAt = 0
+		err := p.store.Notification.SaveDeadLetter(n)
+		if err != nil {
+			p.log.WithError(err).Errorw("Failed to save a notification to the dead-letter list, dropped",
+				"app_id", n.AppID, "subject", n.Subject, "notification_id", n.ID)
+			return
+		}
+		p.log.Warnw("Failed to deliver a notification, moved to the dead-letter list",
+			"app_id", n.AppID, "subject", n.Subject, "notification_id", n.ID, "attempts", n.Attempts, "error", n.Error)
+		return
+	}
+
+	n.NextAttemptAt = model.GetMillis() + notificationRetryBackoff(n.Attempts).Milliseconds()
+	err := p.store.Notification.Queue(n)
+	if err != nil {
+		p.log.WithError(err).Errorw("Failed to queue a notification for retrying, dropped",
+			"app_id", n.AppID, "subject", n.Subject, "notification_id", n.ID)
+		return
+	}
+	p.log.Debugw("Failed to deliver a notification, queued for retrying",
+		"app_id", n.AppID, "subject", n.Subject, "notification_id", n.ID, "attempts", n.Attempts, "error", n.Error)
+}
+
+func notificationRetryBackoff(attempts int) time.Duration {
+	backoff := notificationRetryInitialBackoff
+	for i := 1; i < attempts && backoff < notificationRetryMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > notificationRetryMaxBackoff {
+		backoff = notificationRetryMaxBackoff
+	}
+	return backoff
+}
+
+// RetryNotifications re-sends the queued notifications that are due. It runs
+// as a scheduled cluster job, so the queue is processed by one node at a time.
+func (p *Proxy) RetryNotifications() {
+	queued, err := p.store.Notification.ListQueued()
+	if err != nil {
+		p.log.WithError(err).Errorf("Failed to list the notifications queued for retrying")
+		return
+	}
+
+	now := model.GetMillis()
+	for _, n := range queued {
+		if n.NextAttemptAt > now {
+			continue
+		}
+		p.sendNotification(n, true)
+	}
+}
+
+// GetSubscriptions returns the subscriptions of an app.
+func (p *Proxy) GetSubscriptions(appID apps.AppID) ([]*apps.Subscription, error) {
+	return p.store.Subscription.ListByApp(appID)
+}
+
+func (p *Proxy) ListDeadLetters() ([]*store.Notification, error) {
+	return p.store.Notification.ListDeadLetters()
+}
+
+// ReplayDeadLetter moves a notification from the dead-letter list back to the
+// retry queue, with a fresh set of attempts. It will be sent on the next run of
+// RetryNotifications.
+func (p *Proxy) ReplayDeadLetter(id string) error {
+	n, err := p.store.Notification.GetDeadLetter(id)
+	if err != nil {
+		return err
+	}
+
+	n.Attempts = 0
+	n.NextAttemptAt = 0
+	err = p.store.Notification.Queue(n)
+	if err != nil {
+		return err
+	}
+	return p.store.Notification.DeleteDeadLetter(n.AppID, id)
+}
@@ -0,0 +1,182 @@
+package proxy
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/server/metrics"
+	"github.com/mattermost/mattermost-plugin-apps/server/mocks/mock_store"
+	"github.com/mattermost/mattermost-plugin-apps/server/mocks/mock_upstream"
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
+	"github.com/mattermost/mattermost-plugin-apps/upstream"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestNotificationRetryBackoff(t *testing.T) {
+	require.Equal(t, 30*time.Second, notificationRetryBackoff(1))
+	require.Equal(t, time.Minute, notificationRetryBackoff(2))
+	require.Equal(t, 4*time.Minute, notificationRetryBackoff(4))
+	require.Equal(t, time.Hour, notificationRetryBackoff(100))
+}
+
+func TestDeliverNotification(t *testing.T) {
+	app := &apps.App{
+		Manifest: apps.Manifest{
+			AppID:   "app1",
+			AppType: apps.AppTypeBuiltin,
+		},
+	}
+	creq := &apps.CallRequest{
+		Call: apps.Call{
+			Path: "/notify",
+		},
+		Context: &apps.Context{},
+	}
+
+	for name, tc := range map[string]struct {
+		attempts    int
+		queued      bool
+		upstreamErr error
+		expect      func(ns *mock_store.MockNotificationStore)
+	}{
+		"delivered": {
+			upstreamErr: nil,
+		},
+		"delivered on retry": {
+			attempts:    2,
+			queued:      true,
+			upstreamErr: nil,
+			expect: func(ns *mock_store.MockNotificationStore) {
+				ns.EXPECT().DeleteQueued(apps.AppID("app1"), "id1").Return(nil)
+			},
+		},
+		"failed, queued": {
+			upstreamErr: errors.New("app is down"),
+			expect: func(ns *mock_store.MockNotificationStore) {
+				ns.EXPECT().Queue(gomock.Any()).DoAndReturn(func(n *store.Notification) error {
+					require.Equal(t, 1, n.Attempts)
+					require.Equal(t, "app is down", n.Error)
+					require.True(t, n.NextAttemptAt > model.GetMillis())
+					return nil
+				})
+			},
+		},
+		"failed, out of attempts": {
+			attempts:    config.DefaultNotificationMaxAttempts - 1,
+			upstreamErr: errors.New("app is down"),
+			expect: func(ns *mock_store.MockNotificationStore) {
+				ns.EXPECT().SaveDeadLetter(gomock.Any()).DoAndReturn(func(n *store.Notification) error {
+					require.Equal(t, config.DefaultNotificationMaxAttempts, n.Attempts)
+					require.Equal(t, int64(0), n.NextAttemptAt)
+					return nil
+				})
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			p, up, ns := newTestProxyForNotifications(app, ctrl)
+			up.EXPECT().Roundtrip(creq, false).Return(ioutil.NopCloser(strings.NewReader("{}")), tc.upstreamErr)
+			if tc.expect != nil {
+				tc.expect(ns)
+			}
+
+			p.deliverNotification(app, creq, &store.Notification{
+				ID:       "id1",
+				AppID:    app.AppID,
+				Attempts: tc.attempts,
+			}, tc.queued)
+		})
+	}
+}
+
+func TestSendNotificationWithoutAccess(t *testing.T) {
+	app := &apps.App{
+		Manifest: apps.Manifest{
+			AppID:   "app1",
+			AppType: apps.AppTypeBuiltin,
+		},
+		BotUserID: "botid",
+	}
+
+	for name, tc := range map[string]struct {
+		n      store.Notification
+		queued bool
+	}{
+		"channel": {
+			n: store.Notification{
+				ID:        "id1",
+				AppID:     "app1",
+				Subject:   apps.SubjectPostCreated,
+				ChannelID: "channelid",
+			},
+		},
+		"team, queued": {
+			n: store.Notification{
+				ID:      "id1",
+				AppID:   "app1",
+				Subject: apps.SubjectChannelCreated,
+				TeamID:  "teamid",
+			},
+			queued: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// The upstream is not called.
+			p, _, ns := newTestProxyForNotifications(app, ctrl)
+			testAPI := &plugintest.API{}
+			testAPI.On("HasPermissionToChannel", "botid", "channelid", model.PERMISSION_READ_CHANNEL).Return(false)
+			testAPI.On("HasPermissionToTeam", "botid", "teamid", model.PERMISSION_VIEW_TEAM).Return(false)
+			p.mm = pluginapi.NewClient(testAPI, &plugintest.Driver{})
+			appStore := mock_store.NewMockAppStore(ctrl)
+			appStore.EXPECT().Get(apps.AppID("app1")).Return(app, nil)
+			p.store.App = appStore
+			if tc.queued {
+				ns.EXPECT().DeleteQueued(apps.AppID("app1"), "id1").Return(nil)
+			}
+
+			n := tc.n
+			p.sendNotification(&n, tc.queued)
+		})
+	}
+}
+
+func newTestProxyForNotifications(app *apps.App, ctrl *gomock.Controller) (*Proxy, *mock_upstream.MockUpstream, *mock_store.MockNotificationStore) {
+	mm := pluginapi.NewClient(&plugintest.API{}, &plugintest.Driver{})
+	conf := config.NewTestConfigurator(config.Config{})
+
+	s := store.NewService(mm, utils.NewTestLogger(), conf, nil, "", nil)
+	ns := mock_store.NewMockNotificationStore(ctrl)
+	s.Notification = ns
+
+	up := mock_upstream.NewMockUpstream(ctrl)
+	p := &Proxy{
+		mm:    mm,
+		log:   utils.NewTestLogger(),
+		store: s,
+		builtinUpstreams: map[apps.AppID]upstream.Upstream{
+			app.AppID: up,
+		},
+		callHistory: newCallHistory(),
+		metrics:     metrics.NewService(),
+		conf:        conf,
+	}
+	return p, up, ns
+}
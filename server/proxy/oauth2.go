@@ -32,7 +32,7 @@ func (p *Proxy) GetRemoteOAuth2ConnectURL(sessionID, actingUserID string, appID
 			"state": state,
 		},
 	}
-	cresp := p.Call(sessionID, actingUserID, creq)
+	cresp := p.call(sessionID, actingUserID, creq)
 	if cresp.Type == apps.CallResponseTypeError {
 		return "", cresp
 	}
@@ -70,7 +70,7 @@ func (p *Proxy) CompleteRemoteOAuth2(sessionID, actingUserID string, appID apps.
 		Context: p.conf.GetConfig().SetContextDefaultsForApp(appID, nil),
 		Values:  urlValues,
 	}
-	cresp := p.Call(sessionID, actingUserID, creq)
+	cresp := p.call(sessionID, actingUserID, creq)
 	if cresp.Type == apps.CallResponseTypeError {
 		return cresp
 	}
@@ -78,6 +78,7 @@ func (p *Proxy) CompleteRemoteOAuth2(sessionID, actingUserID string, appID apps.
 		return errors.Errorf("oauth2: unexpected response type from the app: %q", cresp.Type)
 	}
 
+	p.invalidateBindings(appID, actingUserID)
 	p.dispatchRefreshBindingsEvent(actingUserID)
 	return nil
 }
@@ -8,12 +8,18 @@ import (
 	"io"
 	"net/http"
 	"path"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/mattermost/mattermost-server/v5/model"
+
 	"github.com/mattermost/mattermost-plugin-apps/apps"
 	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/server/metrics"
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
 	"github.com/mattermost/mattermost-plugin-apps/upstream"
 	"github.com/mattermost/mattermost-plugin-apps/upstream/upaws"
 	"github.com/mattermost/mattermost-plugin-apps/upstream/uphttp"
@@ -21,12 +27,37 @@ import (
 	"github.com/mattermost/mattermost-plugin-apps/utils"
 )
 
+// Call makes a call to an app on behalf of a user, subject to the Call API
+// limits. The calls that the proxy makes on its own, like fetching bindings,
+// are not limited.
 func (p *Proxy) Call(sessionID, actingUserID string, creq *apps.CallRequest) *apps.ProxyCallResponse {
 	if creq.Context == nil || creq.Context.AppID == "" {
 		resp := apps.NewErrorCallResponse(utils.NewInvalidError("must provide Context and set the app ID"))
 		return apps.NewProxyCallResponse(resp, nil)
 	}
 
+	release, err := p.callLimiter.acquire(creq.Context.AppID, actingUserID)
+	if err != nil {
+		p.log.Debugw("Call rejected", "app_id", creq.Context.AppID, "acting_user_id", actingUserID, "error", err.Error())
+		return apps.NewProxyCallResponse(apps.NewErrorCallResponse(err), nil)
+	}
+	defer release()
+
+	return p.call(sessionID, actingUserID, creq)
+}
+
+func (p *Proxy) call(sessionID, actingUserID string, creq *apps.CallRequest) *apps.ProxyCallResponse {
+	return p.callFromSource(CallSourceCall, sessionID, actingUserID, creq)
+}
+
+// callFromSource makes a call, and records it in the call history as coming
+// from source.
+func (p *Proxy) callFromSource(source, sessionID, actingUserID string, creq *apps.CallRequest) *apps.ProxyCallResponse {
+	if creq.Context == nil || creq.Context.AppID == "" {
+		resp := apps.NewErrorCallResponse(utils.NewInvalidError("must provide Context and set the app ID"))
+		return apps.NewProxyCallResponse(resp, nil)
+	}
+
 	if actingUserID != "" {
 		creq.Context.ActingUserID = actingUserID
 		creq.Context.UserID = actingUserID
@@ -56,6 +87,19 @@ func (p *Proxy) Call(sessionID, actingUserID string, creq *apps.CallRequest) *ap
 	}
 	creq.Path = cleanPath
 
+	if creq.Form != nil {
+		// The form is only used to validate the values, it is not sent to the
+		// app.
+		form := creq.Form
+		creq.Form = nil
+		switch callTypeOfPath(creq.Path) {
+		case "", apps.CallTypeSubmit:
+			if fieldErrors := form.ValidateValues(creq.Values); fieldErrors != nil {
+				return apps.NewProxyCallResponse(newFieldErrorsResponse(fieldErrors), metadata)
+			}
+		}
+	}
+
 	up, err := p.upstreamForApp(app)
 	if err != nil {
 		return apps.NewProxyCallResponse(apps.NewErrorCallResponse(err), metadata)
@@ -67,19 +111,23 @@ func (p *Proxy) Call(sessionID, actingUserID string, creq *apps.CallRequest) *ap
 	conf := p.conf.GetConfig()
 	cc := conf.SetContextDefaultsForApp(creq.Context.AppID, creq.Context)
 
-	expander := p.newExpander(cc, p.mm, p.conf, p.store, sessionID)
-	cc, err = expander.ExpandForApp(app, creq.Expand)
-	if err != nil {
-		return apps.NewProxyCallResponse(apps.NewErrorCallResponse(err), metadata)
-	}
-	clone := *creq
-	clone.Context = cc
-
-	callResponse := upstream.Call(up, &clone)
+	start := time.Now()
+	callResponse := p.callChain(up, app, sessionID, cc, creq)
 
 	if callResponse.Type == "" {
 		callResponse.Type = apps.CallResponseTypeOK
 	}
+	record := CallRecord{
+		Source:       source,
+		Path:         creq.Path,
+		Type:         callTypeOfPath(creq.Path),
+		ActingUserID: cc.ActingUserID,
+		ResponseType: callResponse.Type,
+	}
+	if callResponse.Type == apps.CallResponseTypeError {
+		record.ErrorText = callResponse.ErrorText
+	}
+	p.recordCall(app, record, start, nil)
 
 	if callResponse.Form != nil && callResponse.Form.Icon != "" {
 		icon, err := normalizeStaticPath(conf, cc.AppID, callResponse.Form.Icon)
@@ -96,6 +144,90 @@ func (p *Proxy) Call(sessionID, actingUserID string, creq *apps.CallRequest) *ap
 	return apps.NewProxyCallResponse(callResponse, metadata)
 }
 
+func newFieldErrorsResponse(fieldErrors map[string]string) *apps.CallResponse {
+	names := []string{}
+	for name := range fieldErrors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return apps.NewFieldErrorsCallResponse("invalid values for: "+strings.Join(names, ", "), fieldErrors)
+}
+
+// callChain invokes the app, and follows any "call" responses it returns, up
+// to MaxCallChainDepth hops. The context is re-expanded for each hop, using the
+// Expand of the call being made, so that the app sees the current state of
+// the Mattermost entities. Values and State are not carried over from the
+// previous hop, the returned Call defines the State for the next one.
+func (p *Proxy) callChain(up upstream.Upstream, app *apps.App, sessionID string, cc *apps.Context, creq *apps.CallRequest) *apps.CallResponse {
+	visited := map[string]bool{}
+	for depth := 0; ; depth++ {
+		key, err := callChainKey(&creq.Call)
+		if err != nil {
+			return apps.NewErrorCallResponse(err)
+		}
+		if visited[key] {
+			return apps.NewErrorCallResponse(utils.NewInvalidError("call loop detected: %s was already called", creq.Path))
+		}
+		visited[key] = true
+
+		// Start each hop with a clean copy of the context, the expander caches
+		// the expanded entities in it.
+		hopContext := *cc
+		hopContext.ExpandedContext = apps.ExpandedContext{}
+		expander := p.newExpander(&hopContext, p.mm, p.conf, p.store, sessionID)
+		expanded, err := expander.ExpandForApp(app, creq.Expand)
+		if err != nil {
+			return apps.NewErrorCallResponse(err)
+		}
+		clone := *creq
+		clone.Context = expanded
+
+		callResponse := upstream.Call(up, &clone)
+		if callResponse.Type != apps.CallResponseTypeCall {
+			return callResponse
+		}
+
+		if callResponse.Call == nil {
+			return apps.NewErrorCallResponse(utils.NewInvalidError("%s returned a %q response with no call", creq.Path, apps.CallResponseTypeCall))
+		}
+		if depth+1 >= MaxCallChainDepth {
+			return apps.NewErrorCallResponse(utils.NewInvalidError("call chain is too long, exceeded %v calls", MaxCallChainDepth))
+		}
+
+		next := *callResponse.Call
+		if next.Path == "" || next.Path[0] != '/' {
+			return apps.NewErrorCallResponse(utils.NewInvalidError("call path must start with a %q: %q", "/", next.Path))
+		}
+		next.Path, err = utils.CleanPath(next.Path)
+		if err != nil {
+			return apps.NewErrorCallResponse(err)
+		}
+
+		p.log.Debugw("Following a call response",
+			"app_id", app.AppID,
+			"from", creq.Path,
+			"to", next.Path)
+
+		creq = &apps.CallRequest{
+			Call:    next,
+			Context: creq.Context,
+		}
+	}
+}
+
+// callChainKey identifies a call within a chain, for loop detection. Calls to
+// the same path with different State are considered different.
+func callChainKey(call *apps.Call) (string, error) {
+	if call.State == nil {
+		return call.Path, nil
+	}
+	data, err := json.Marshal(call.State)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode call state")
+	}
+	return call.Path + "#" + string(data), nil
+}
+
 // normalizeStaticPath converts a given URL to a absolute one pointing to a static asset if needed.
 // If icon is an absolute URL, it's not changed.
 // Otherwise assume it's a path to a static asset and the static path URL prepended.
@@ -112,43 +244,54 @@ func normalizeStaticPath(conf config.Config, appID apps.AppID, icon string) (str
 	return icon, nil
 }
 
+// Notify sends a notification to all apps subscribed to the subject, in the
+// context's scope. The notifications are sent asynchronously by the
+// dispatcher; if it is overloaded, they are queued to be retried.
 func (p *Proxy) Notify(cc *apps.Context, subj apps.Subject) error {
 	subs, err := p.store.Subscription.Get(subj, cc.TeamID, cc.ChannelID)
 	if err != nil {
 		return err
 	}
 
-	expander := p.newExpander(cc, p.mm, p.conf, p.store, "")
-
-	notify := func(sub *apps.Subscription) error {
-		call := sub.Call
-		if call == nil {
-			return errors.New("nothing to call")
+	var target *filterTarget
+	if cc.Post != nil {
+		target = &filterTarget{
+			mm:      p.mm,
+			regexps: p.filterRegexps,
+			post:    cc.Post,
 		}
+	}
 
-		callRequest := &apps.CallRequest{Call: *call}
-		app, err := p.store.App.Get(sub.AppID)
-		if err != nil {
-			return err
-		}
-		callRequest.Context, err = expander.ExpandForApp(app, callRequest.Expand)
-		if err != nil {
-			return err
+	for _, sub := range subs {
+		if sub.Call == nil {
+			continue
 		}
-		callRequest.Context.Subject = subj
 
-		up, err := p.upstreamForApp(app)
-		if err != nil {
-			return err
+		// If a filter can not be evaluated, the app is notified.
+		if sub.Filter != nil && target != nil {
+			matched, err := target.match(sub.Filter)
+			if err != nil {
+				p.log.WithError(err).Debugw("Failed to evaluate a subscription filter",
+					"app_id", sub.AppID, "subject", subj)
+			} else if !matched {
+				p.metrics.ObserveNotification(sub.AppID, subj, metrics.ResultFiltered)
+				continue
+			}
 		}
-		return upstream.Notify(up, callRequest)
-	}
 
-	for _, sub := range subs {
-		err := notify(sub)
-		if err != nil {
-			// TODO log err
-			continue
+		n := &store.Notification{
+			ID:        model.NewId(),
+			AppID:     sub.AppID,
+			Subject:   subj,
+			Call:      *sub.Call,
+			ChannelID: sub.ChannelID,
+			TeamID:    sub.TeamID,
+			Context:   queuedContext(cc),
+			CreatedAt: model.GetMillis(),
+		}
+		if !p.dispatcher.dispatch(n.AppID, func() { p.sendNotification(n, false) }) {
+			n.Error = "too many notifications pending"
+			p.queueNotification(n)
 		}
 	}
 	return nil
@@ -189,7 +332,22 @@ func (p *Proxy) NotifyRemoteWebhook(app *apps.App, data []byte, webhookPath stri
 		return err
 	}
 
-	return upstream.Notify(up, creq)
+	if !p.dispatcher.dispatch(app.AppID, func() {
+		start := time.Now()
+		err := upstream.Notify(up, creq)
+		p.recordCall(app, CallRecord{
+			Source:       CallSourceWebhook,
+			Path:         creq.Path,
+			ActingUserID: creq.Context.ActingUserID,
+		}, start, err)
+		if err != nil {
+			p.log.WithError(err).Warnw("Failed to send a webhook to the app",
+				"app_id", app.AppID, "path", creq.Path)
+		}
+	}) {
+		return errors.Errorf("too many notifications pending for %s, dropped the webhook", app.AppID)
+	}
+	return nil
 }
 
 func (p *Proxy) GetStatic(appID apps.AppID, path string) (io.ReadCloser, int, error) {
@@ -206,7 +364,10 @@ func (p *Proxy) GetStatic(appID apps.AppID, path string) (io.ReadCloser, int, er
 		return nil, http.StatusInternalServerError, err
 	}
 
-	return up.GetStatic(path)
+	start := time.Now()
+	body, status, err := up.GetStatic(path)
+	p.metrics.ObserveStatic(appID, m.AppType, status, time.Since(start))
+	return body, status, err
 }
 
 func (p *Proxy) getStaticForApp(app *apps.App, path string) (io.ReadCloser, int, error) {
@@ -3,6 +3,7 @@ package proxy
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"testing"
 
@@ -15,6 +16,7 @@ import (
 
 	"github.com/mattermost/mattermost-plugin-apps/apps"
 	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/server/metrics"
 	"github.com/mattermost/mattermost-plugin-apps/server/mocks/mock_store"
 	"github.com/mattermost/mattermost-plugin-apps/server/mocks/mock_upstream"
 	"github.com/mattermost/mattermost-plugin-apps/server/store"
@@ -55,6 +57,222 @@ func TestAppMetadataForClient(t *testing.T) {
 	})
 }
 
+func TestCallChain(t *testing.T) {
+	app := &apps.App{
+		BotUserID: "botid",
+		Manifest: apps.Manifest{
+			AppID:   apps.AppID("app1"),
+			AppType: apps.AppTypeBuiltin,
+		},
+	}
+
+	respond := func(cr *apps.CallResponse) io.ReadCloser {
+		b, _ := json.Marshal(cr)
+		return ioutil.NopCloser(bytes.NewReader(b))
+	}
+	callTo := func(path string, state interface{}) *apps.CallResponse {
+		return &apps.CallResponse{
+			Type: apps.CallResponseTypeCall,
+			Call: &apps.Call{
+				Path:  path,
+				State: state,
+			},
+		}
+	}
+	newRequest := func() *apps.CallRequest {
+		return &apps.CallRequest{
+			Context: &apps.Context{
+				UserAgentContext: apps.UserAgentContext{
+					AppID: "app1",
+				},
+			},
+			Call: apps.Call{
+				Path: "/first",
+			},
+		}
+	}
+
+	t.Run("follows calls", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		p, up := newTestProxyForCallChain(app, ctrl)
+		var paths []string
+		up.EXPECT().Roundtrip(gomock.Any(), false).Times(3).DoAndReturn(
+			func(creq *apps.CallRequest, _ bool) (io.ReadCloser, error) {
+				paths = append(paths, creq.Path)
+				require.Equal(t, "botid", creq.Context.BotUserID)
+				switch creq.Path {
+				case "/first":
+					return respond(callTo("/second", nil)), nil
+				case "/second":
+					require.Nil(t, creq.State)
+					return respond(callTo("/third", "with-state")), nil
+				default:
+					require.Equal(t, "with-state", creq.State)
+					return respond(&apps.CallResponse{Markdown: "done"}), nil
+				}
+			})
+
+		resp := p.Call("session_id", "acting_user_id", newRequest())
+		require.Equal(t, apps.CallResponseTypeOK, resp.Type, resp.ErrorText)
+		require.Equal(t, "done", resp.Markdown)
+		require.Equal(t, []string{"/first", "/second", "/third"}, paths)
+	})
+
+	t.Run("loop", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		p, up := newTestProxyForCallChain(app, ctrl)
+		up.EXPECT().Roundtrip(gomock.Any(), false).Times(2).DoAndReturn(
+			func(creq *apps.CallRequest, _ bool) (io.ReadCloser, error) {
+				if creq.Path == "/first" {
+					return respond(callTo("/second", nil)), nil
+				}
+				return respond(callTo("/first", nil)), nil
+			})
+
+		resp := p.Call("session_id", "acting_user_id", newRequest())
+		require.Equal(t, apps.CallResponseTypeError, resp.Type)
+		require.Contains(t, resp.ErrorText, "call loop detected")
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		p, up := newTestProxyForCallChain(app, ctrl)
+		n := 0
+		up.EXPECT().Roundtrip(gomock.Any(), false).Times(MaxCallChainDepth).DoAndReturn(
+			func(creq *apps.CallRequest, _ bool) (io.ReadCloser, error) {
+				n++
+				return respond(callTo("/next", n)), nil
+			})
+
+		resp := p.Call("session_id", "acting_user_id", newRequest())
+		require.Equal(t, apps.CallResponseTypeError, resp.Type)
+		require.Contains(t, resp.ErrorText, "call chain is too long")
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		p, up := newTestProxyForCallChain(app, ctrl)
+		up.EXPECT().Roundtrip(gomock.Any(), false).Return(respond(callTo("no-slash", nil)), nil)
+
+		resp := p.Call("session_id", "acting_user_id", newRequest())
+		require.Equal(t, apps.CallResponseTypeError, resp.Type)
+		require.Contains(t, resp.ErrorText, "call path must start with a")
+	})
+}
+
+func TestCallFormValidation(t *testing.T) {
+	app := &apps.App{
+		Manifest: apps.Manifest{
+			AppID:   apps.AppID("app1"),
+			AppType: apps.AppTypeBuiltin,
+		},
+	}
+	form := &apps.Form{
+		Fields: []*apps.Field{
+			{
+				Name:       "name",
+				Type:       apps.FieldTypeText,
+				IsRequired: true,
+			},
+			{
+				Name:          "email",
+				Type:          apps.FieldTypeText,
+				TextSubtype:   apps.TextFieldSubtypeEmail,
+				TextMaxLength: 20,
+			},
+		},
+	}
+	newRequest := func(path string, values map[string]interface{}) *apps.CallRequest {
+		return &apps.CallRequest{
+			Context: &apps.Context{
+				UserAgentContext: apps.UserAgentContext{
+					AppID: "app1",
+				},
+			},
+			Call: apps.Call{
+				Path: path,
+			},
+			Values: values,
+			Form:   form,
+		}
+	}
+
+	t.Run("invalid submit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		p, _ := newTestProxyForCallChain(app, ctrl)
+
+		resp := p.Call("session_id", "acting_user_id", newRequest("/send/submit", map[string]interface{}{
+			"email": "not-an-email",
+		}))
+		require.Equal(t, apps.CallResponseTypeError, resp.Type)
+		require.Equal(t, "invalid values for: email, name", resp.ErrorText)
+		require.Equal(t, map[string]interface{}{
+			"errors": map[string]string{
+				"name":  "this field is required",
+				"email": "must be an email address",
+			},
+		}, resp.Data)
+	})
+
+	t.Run("valid submit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		p, up := newTestProxyForCallChain(app, ctrl)
+		up.EXPECT().Roundtrip(gomock.Any(), false).DoAndReturn(
+			func(creq *apps.CallRequest, _ bool) (io.ReadCloser, error) {
+				require.Nil(t, creq.Form)
+				return ioutil.NopCloser(bytes.NewReader([]byte(`{"type":"ok"}`))), nil
+			})
+
+		resp := p.Call("session_id", "acting_user_id", newRequest("/send", map[string]interface{}{
+			"name":  "test",
+			"email": "test@example.com",
+		}))
+		require.Equal(t, apps.CallResponseTypeOK, resp.Type, resp.ErrorText)
+	})
+
+	t.Run("lookup is not validated", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		p, up := newTestProxyForCallChain(app, ctrl)
+		up.EXPECT().Roundtrip(gomock.Any(), false).Return(
+			ioutil.NopCloser(bytes.NewReader([]byte(`{"type":"ok"}`))), nil)
+
+		resp := p.Call("session_id", "acting_user_id", newRequest("/send/lookup", nil))
+		require.Equal(t, apps.CallResponseTypeOK, resp.Type, resp.ErrorText)
+	})
+}
+
+func newTestProxyForCallChain(app *apps.App, ctrl *gomock.Controller) (*Proxy, *mock_upstream.MockUpstream) {
+	testAPI := &plugintest.API{}
+	testDriver := &plugintest.Driver{}
+	mm := pluginapi.NewClient(testAPI, testDriver)
+
+	conf := config.NewTestConfigurator(config.Config{}).WithMattermostConfig(model.Config{
+		ServiceSettings: model.ServiceSettings{
+			SiteURL: model.NewString("test.mattermost.com"),
+		},
+	})
+
+	s := store.NewService(mm, utils.NewTestLogger(), conf, nil, "", nil)
+	appStore := mock_store.NewMockAppStore(ctrl)
+	appStore.EXPECT().Get(app.AppID).Return(app, nil)
+	s.App = appStore
+
+	up := mock_upstream.NewMockUpstream(ctrl)
+	p := &Proxy{
+		mm:    mm,
+		log:   utils.NewTestLogger(),
+		store: s,
+		builtinUpstreams: map[apps.AppID]upstream.Upstream{
+			app.AppID: up,
+		},
+		callHistory: newCallHistory(),
+		callLimiter: newCallLimiter(conf),
+		metrics:     metrics.NewService(),
+		conf:        conf,
+	}
+	return p, up
+}
+
 func newTestProxy(testApps []*apps.App, ctrl *gomock.Controller) *Proxy {
 	testAPI := &plugintest.API{}
 	testDriver := &plugintest.Driver{}
@@ -66,7 +284,7 @@ func newTestProxy(testApps []*apps.App, ctrl *gomock.Controller) *Proxy {
 		},
 	})
 
-	s := store.NewService(mm, utils.NewTestLogger(), conf, nil, "")
+	s := store.NewService(mm, utils.NewTestLogger(), conf, nil, "", nil)
 	appStore := mock_store.NewMockAppStore(ctrl)
 	s.App = appStore
 
@@ -88,6 +306,9 @@ func newTestProxy(testApps []*apps.App, ctrl *gomock.Controller) *Proxy {
 		mm:               mm,
 		store:            s,
 		builtinUpstreams: upstreams,
+		callHistory:      newCallHistory(),
+		callLimiter:      newCallLimiter(conf),
+		metrics:          metrics.NewService(),
 		conf:             conf,
 	}
 
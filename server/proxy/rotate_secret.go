@@ -0,0 +1,257 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package proxy
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+// The values of the OnSecretRotated call.
+const (
+	RotatedSecretType       = "secret_type"
+	RotatedSecret           = "secret"
+	PreviousSecretExpiresAt = "previous_expires_at"
+)
+
+const botAccessTokenDescription = "Mattermost App Token"
+
+// rotatedSecretNotifyPeriod is how long the previous secret remains valid
+// while the app is notified of a secret rotated without a grace period.
+const rotatedSecretNotifyPeriod = 5 * time.Minute
+
+// RotateSecret replaces one of the app's secrets with a newly generated one,
+// and notifies the app with its OnSecretRotated call. The previous secret
+// remains valid for gracePeriod; with no grace period it is invalidated
+// immediately.
+func (p *Proxy) RotateSecret(sessionID string, cc *apps.Context, appID apps.AppID, secretType apps.SecretType, gracePeriod time.Duration) (string, error) {
+	if gracePeriod < 0 {
+		return "", utils.NewInvalidError("grace period must not be negative")
+	}
+	app, err := p.store.App.Get(appID)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get app. appID: %s", appID)
+	}
+
+	var expiresAt int64
+	if gracePeriod > 0 {
+		expiresAt = model.GetMillis() + gracePeriod.Milliseconds()
+	}
+	updated := *app
+	previous := previousSecret(&updated, secretType)
+	// Access tokens that are no longer valid, to revoke once the app is saved.
+	var revoke []string
+
+	var secret string
+	switch secretType {
+	case apps.SecretTypeJWT:
+		if app.AppType != apps.AppTypeHTTP {
+			return "", utils.NewInvalidError("only HTTP apps have a JWT secret")
+		}
+		secret = model.NewId()
+		// The app may still be receiving the JWTs issued with the previous
+		// secret, keep using it until the app is notified.
+		*previous = apps.RotatedSecret{
+			Secret: app.JWTSecret(),
+			InUse:  true,
+		}
+		updated.Secret = secret
+
+	case apps.SecretTypeWebhook:
+		if app.WebhookSecret == "" {
+			return "", utils.NewInvalidError("%s has no webhook secret", appID)
+		}
+		secret = model.NewId()
+		*previous = apps.RotatedSecret{
+			Secret: app.WebhookSecret,
+		}
+		updated.WebhookSecret = secret
+
+	case apps.SecretTypeBotToken:
+		if app.BotAccessTokenID == "" {
+			return "", utils.NewInvalidError("%s has no bot access token", appID)
+		}
+		token, err := p.mm.User.CreateAccessToken(app.BotUserID, botAccessTokenDescription)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to create bot user's access token")
+		}
+		secret = token.Token
+		if app.PreviousBotAccessToken.ID != "" {
+			revoke = append(revoke, app.PreviousBotAccessToken.ID)
+		}
+		*previous = apps.RotatedSecret{
+			Secret: app.BotAccessToken,
+			ID:     app.BotAccessTokenID,
+		}
+		updated.BotAccessToken = token.Token
+		updated.BotAccessTokenID = token.Id
+
+	default:
+		return "", utils.NewInvalidError("unknown secret type %q", secretType)
+	}
+
+	// The new secret is saved before the app is notified, with the previous
+	// secret still valid, so that the call is made with the previous secret,
+	// which the app knows. Without a grace period, the previous secret is
+	// invalidated once the app has been notified; the job expires it in case
+	// that fails.
+	notify := app.OnSecretRotated != nil
+	validUntil := expiresAt
+	if validUntil == 0 && notify {
+		validUntil = model.GetMillis() + rotatedSecretNotifyPeriod.Milliseconds()
+	}
+	if validUntil == 0 {
+		if previous.ID != "" {
+			revoke = append(revoke, previous.ID)
+		}
+		*previous = apps.RotatedSecret{}
+	} else {
+		previous.ExpiresAt = validUntil
+	}
+
+	err = p.store.App.Save(&updated)
+	if err != nil {
+		if secretType == apps.SecretTypeBotToken {
+			if revokeErr := p.mm.User.RevokeAccessToken(updated.BotAccessTokenID); revokeErr != nil {
+				p.log.WithError(revokeErr).Warnw("Failed to revoke the new bot access token", "app_id", app.AppID)
+			}
+		}
+		return "", errors.Wrapf(err, "failed to save app. appID: %s", appID)
+	}
+	p.revokeAccessTokens(app.AppID, revoke)
+
+	if validUntil != 0 {
+		at := strconv.FormatInt(validUntil, 10)
+		err = p.scheduleJob(jobTypeRotatedSecretExpired,
+			jobID(jobTypeRotatedSecretExpired, string(appID), string(secretType), at),
+			map[string]string{
+				"app_id":                string(appID),
+				RotatedSecretType:       string(secretType),
+				PreviousSecretExpiresAt: at,
+			},
+			validUntil)
+		if err != nil {
+			p.log.WithError(err).Warnw("Failed to schedule the expiry of the previous secret",
+				"app_id", app.AppID, "secret_type", secretType)
+		}
+	}
+
+	var message string
+	notified := false
+	if notify {
+		resp := p.call(sessionID, cc.ActingUserID, &apps.CallRequest{
+			Call:    *app.OnSecretRotated,
+			Context: cc,
+			Values: map[string]interface{}{
+				RotatedSecretType:       secretType,
+				RotatedSecret:           secret,
+				PreviousSecretExpiresAt: expiresAt,
+			},
+		})
+		if resp.Type == apps.CallResponseTypeError {
+			p.log.WithError(resp).Warnw("OnSecretRotated failed, rotated the secret anyway",
+				"app_id", app.AppID, "secret_type", secretType)
+			message = fmt.Sprintf("%s failed to receive the new secret: %s\n", app.DisplayName, resp.Error())
+		} else {
+			notified = true
+		}
+	}
+
+	switch {
+	case expiresAt == 0 && validUntil != 0:
+		revoke = nil
+		if previous.ID != "" {
+			revoke = append(revoke, previous.ID)
+		}
+		*previous = apps.RotatedSecret{}
+		err = p.store.App.Save(&updated)
+		if err != nil {
+			return "", errors.Wrapf(err, "rotated the secret, but failed to invalidate the previous one, it remains valid until %s",
+				time.Unix(0, validUntil*int64(time.Millisecond)).UTC().Format(time.RFC3339))
+		}
+		p.revokeAccessTokens(app.AppID, revoke)
+
+	case notified && previous.InUse:
+		// The app has the new secret, issue the JWTs with it. The previous
+		// secret is kept until it expires.
+		previous.InUse = false
+		err = p.store.App.Save(&updated)
+		if err != nil {
+			return "", errors.Wrap(err, "rotated the secret, but failed to start issuing the JWTs with it")
+		}
+	}
+
+	p.log.Infow("Rotated an app secret", "app_id", app.AppID, "secret_type", secretType, "grace_period", gracePeriod.String())
+
+	if expiresAt == 0 {
+		message += fmt.Sprintf("Rotated the %s secret of %s, the previous secret is no longer valid.", secretType, app.DisplayName)
+	} else {
+		message += fmt.Sprintf("Rotated the %s secret of %s, the previous secret remains valid until %s.",
+			secretType, app.DisplayName, time.Unix(0, expiresAt*int64(time.Millisecond)).UTC().Format(time.RFC3339))
+	}
+	return message, nil
+}
+
+// runRotatedSecretExpiredJob invalidates a previous secret once its grace
+// period is over. It does nothing if the secret was rotated again since.
+func (p *Proxy) runRotatedSecretExpiredJob(job *store.Job) error {
+	app, err := p.store.App.Get(apps.AppID(job.Data["app_id"]))
+	if errors.Is(err, utils.ErrNotFound) {
+		// Uninstalled since.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	expiresAt, err := strconv.ParseInt(job.Data[PreviousSecretExpiresAt], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	updated := *app
+	previous := previousSecret(&updated, apps.SecretType(job.Data[RotatedSecretType]))
+	if previous == nil || previous.ExpiresAt != expiresAt {
+		return nil
+	}
+	if previous.ID != "" {
+		err = p.mm.User.RevokeAccessToken(previous.ID)
+		if err != nil {
+			return errors.Wrap(err, "failed to revoke the previous bot access token")
+		}
+	}
+	*previous = apps.RotatedSecret{}
+	return p.store.App.Save(&updated)
+}
+
+func (p *Proxy) revokeAccessTokens(appID apps.AppID, tokenIDs []string) {
+	for _, tokenID := range tokenIDs {
+		err := p.mm.User.RevokeAccessToken(tokenID)
+		if err != nil {
+			p.log.WithError(err).Warnw("Failed to revoke the previous bot access token", "app_id", appID)
+		}
+	}
+}
+
+// previousSecret returns the app's field that holds the previous secret of
+// the type.
+func previousSecret(app *apps.App, secretType apps.SecretType) *apps.RotatedSecret {
+	switch secretType {
+	case apps.SecretTypeJWT:
+		return &app.PreviousSecret
+	case apps.SecretTypeWebhook:
+		return &app.PreviousWebhookSecret
+	case apps.SecretTypeBotToken:
+		return &app.PreviousBotAccessToken
+	}
+	return nil
+}
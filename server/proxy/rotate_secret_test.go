@@ -0,0 +1,354 @@
+// +build !e2e
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/server/httpout"
+	"github.com/mattermost/mattermost-plugin-apps/server/metrics"
+	"github.com/mattermost/mattermost-plugin-apps/server/mocks/mock_store"
+	"github.com/mattermost/mattermost-plugin-apps/server/mocks/mock_upstream"
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
+	"github.com/mattermost/mattermost-plugin-apps/upstream"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestRotateSecret(t *testing.T) {
+	newApp := func() *apps.App {
+		return &apps.App{
+			Manifest: apps.Manifest{
+				AppID:   "app1",
+				AppType: apps.AppTypeHTTP,
+			},
+			BotUserID:        "botid",
+			Secret:           "secret",
+			WebhookSecret:    "webhook-secret",
+			BotAccessToken:   "token",
+			BotAccessTokenID: "tokenid",
+		}
+	}
+
+	setup := func(t *testing.T, app *apps.App) (*Proxy, *plugintest.API, *mock_store.MockJobStore, *apps.App) {
+		ctrl := gomock.NewController(t)
+		appStore := mock_store.NewMockAppStore(ctrl)
+		appStore.EXPECT().Get(apps.AppID("app1")).Return(app, nil).AnyTimes()
+		saved := &apps.App{}
+		appStore.EXPECT().Save(gomock.Any()).DoAndReturn(func(a *apps.App) error {
+			*saved = *a
+			return nil
+		}).AnyTimes()
+		jobStore := mock_store.NewMockJobStore(ctrl)
+		testAPI := &plugintest.API{}
+		return &Proxy{
+			log: utils.NewTestLogger(),
+			mm:  pluginapi.NewClient(testAPI, &plugintest.Driver{}),
+			store: &store.Service{
+				App: appStore,
+				Job: jobStore,
+			},
+		}, testAPI, jobStore, saved
+	}
+	cc := &apps.Context{}
+	cc.AppID = "app1"
+
+	t.Run("jwt with grace period", func(t *testing.T) {
+		p, _, jobStore, saved := setup(t, newApp())
+		var job *store.Job
+		jobStore.EXPECT().Create(gomock.Any()).DoAndReturn(func(j *store.Job) (bool, error) {
+			job = j
+			return true, nil
+		})
+
+		before := model.GetMillis()
+		_, err := p.RotateSecret("", cc, "app1", apps.SecretTypeJWT, time.Hour)
+		require.NoError(t, err)
+		require.NotEqual(t, "secret", saved.Secret)
+		require.NotEmpty(t, saved.Secret)
+		require.Equal(t, "secret", saved.PreviousSecret.Secret)
+		require.GreaterOrEqual(t, saved.PreviousSecret.ExpiresAt, before+time.Hour.Milliseconds())
+		// The JWTs are issued with the previous secret until it expires.
+		require.Equal(t, "secret", saved.JWTSecret())
+
+		require.Equal(t, jobTypeRotatedSecretExpired, job.Type)
+		require.Equal(t, saved.PreviousSecret.ExpiresAt, job.NextAttemptAt)
+		require.Equal(t, string(apps.SecretTypeJWT), job.Data[RotatedSecretType])
+	})
+
+	t.Run("webhook without grace period", func(t *testing.T) {
+		p, _, _, saved := setup(t, newApp())
+
+		_, err := p.RotateSecret("", cc, "app1", apps.SecretTypeWebhook, 0)
+		require.NoError(t, err)
+		require.NotEqual(t, "webhook-secret", saved.WebhookSecret)
+		require.Empty(t, saved.PreviousWebhookSecret)
+		require.False(t, saved.IsWebhookSecret("webhook-secret"))
+		require.Equal(t, "secret", saved.Secret)
+	})
+
+	t.Run("bot token without grace period", func(t *testing.T) {
+		p, testAPI, _, saved := setup(t, newApp())
+		testAPI.On("CreateUserAccessToken", mock.Anything).Return(&model.UserAccessToken{Id: "newtokenid", Token: "newtoken"}, nil)
+		testAPI.On("RevokeUserAccessToken", "tokenid").Once().Return(nil)
+
+		_, err := p.RotateSecret("", cc, "app1", apps.SecretTypeBotToken, 0)
+		require.NoError(t, err)
+		require.Equal(t, "newtoken", saved.BotAccessToken)
+		require.Equal(t, "newtokenid", saved.BotAccessTokenID)
+		require.Empty(t, saved.PreviousBotAccessToken)
+		testAPI.AssertExpectations(t)
+	})
+
+	t.Run("bot token expires", func(t *testing.T) {
+		app := newApp()
+		app.PreviousBotAccessToken = apps.RotatedSecret{Secret: "oldtoken", ID: "oldtokenid", ExpiresAt: 1000}
+		p, testAPI, _, saved := setup(t, app)
+		testAPI.On("RevokeUserAccessToken", "oldtokenid").Once().Return(nil)
+
+		err := p.runRotatedSecretExpiredJob(&store.Job{
+			Data: map[string]string{
+				"app_id":                "app1",
+				RotatedSecretType:       string(apps.SecretTypeBotToken),
+				PreviousSecretExpiresAt: strconv.Itoa(1000),
+			},
+		})
+		require.NoError(t, err)
+		require.Empty(t, saved.PreviousBotAccessToken)
+		require.Equal(t, "token", saved.BotAccessToken)
+		testAPI.AssertExpectations(t)
+	})
+
+	t.Run("rotated again before expiry", func(t *testing.T) {
+		app := newApp()
+		app.PreviousSecret = apps.RotatedSecret{Secret: "old", ExpiresAt: 2000}
+		p, _, _, saved := setup(t, app)
+
+		err := p.runRotatedSecretExpiredJob(&store.Job{
+			Data: map[string]string{
+				"app_id":                "app1",
+				RotatedSecretType:       string(apps.SecretTypeJWT),
+				PreviousSecretExpiresAt: strconv.Itoa(1000),
+			},
+		})
+		require.NoError(t, err)
+		// Not saved.
+		require.Empty(t, saved.AppID)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		app := newApp()
+		app.WebhookSecret = ""
+		p, _, _, _ := setup(t, app)
+
+		_, err := p.RotateSecret("", cc, "app1", apps.SecretTypeWebhook, 0)
+		require.ErrorIs(t, err, utils.ErrInvalid)
+		_, err = p.RotateSecret("", cc, "app1", "password", 0)
+		require.ErrorIs(t, err, utils.ErrInvalid)
+		_, err = p.RotateSecret("", cc, "app1", apps.SecretTypeJWT, -time.Hour)
+		require.ErrorIs(t, err, utils.ErrInvalid)
+	})
+}
+
+func TestRotateSecretNotify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	app := &apps.App{
+		Manifest: apps.Manifest{
+			AppID:           "app1",
+			AppType:         apps.AppTypeBuiltin,
+			OnSecretRotated: apps.NewCall("/rotated"),
+		},
+		BotUserID:     "botid",
+		WebhookSecret: "webhook-secret",
+	}
+
+	conf := config.NewTestConfigurator(config.Config{})
+	mm := pluginapi.NewClient(&plugintest.API{}, &plugintest.Driver{})
+	s := store.NewService(mm, utils.NewTestLogger(), conf, nil, "", nil)
+	appStore := mock_store.NewMockAppStore(ctrl)
+	jobStore := mock_store.NewMockJobStore(ctrl)
+	s.App = appStore
+	s.Job = jobStore
+	up := mock_upstream.NewMockUpstream(ctrl)
+	p := &Proxy{
+		mm:    mm,
+		log:   utils.NewTestLogger(),
+		store: s,
+		builtinUpstreams: map[apps.AppID]upstream.Upstream{
+			app.AppID: up,
+		},
+		callHistory: newCallHistory(),
+		callLimiter: newCallLimiter(conf),
+		metrics:     metrics.NewService(),
+		conf:        conf,
+	}
+
+	current := app
+	var saved []apps.App
+	appStore.EXPECT().Get(app.AppID).DoAndReturn(func(apps.AppID) (*apps.App, error) {
+		return current, nil
+	}).AnyTimes()
+	appStore.EXPECT().Save(gomock.Any()).DoAndReturn(func(a *apps.App) error {
+		saved = append(saved, *a)
+		current = &saved[len(saved)-1]
+		return nil
+	}).Times(2)
+	jobStore.EXPECT().Create(gomock.Any()).Return(true, nil)
+
+	var secret interface{}
+	up.EXPECT().Roundtrip(gomock.Any(), false).DoAndReturn(func(creq *apps.CallRequest, _ bool) (io.ReadCloser, error) {
+		// The new secret is saved before the app is notified, and the previous
+		// one is still valid.
+		require.Len(t, saved, 1)
+		require.True(t, current.IsWebhookSecret("webhook-secret"))
+		secret = creq.Values[RotatedSecret]
+		require.True(t, current.IsWebhookSecret(secret.(string)))
+
+		b, _ := json.Marshal(&apps.CallResponse{})
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	})
+
+	cc := &apps.Context{}
+	cc.AppID = "app1"
+	_, err := p.RotateSecret("", cc, "app1", apps.SecretTypeWebhook, 0)
+	require.NoError(t, err)
+	// Invalidated once the app has been notified.
+	require.Len(t, saved, 2)
+	require.Equal(t, secret, current.WebhookSecret)
+	require.False(t, current.IsWebhookSecret("webhook-secret"))
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type testHTTPOut struct {
+	httpout.Service
+	roundTrip roundTripFunc
+}
+
+func (h *testHTTPOut) MakeClient(bool) *http.Client {
+	return &http.Client{Transport: h.roundTrip}
+}
+
+func TestRotateSecretJWT(t *testing.T) {
+	for name, tc := range map[string]struct {
+		status        int
+		expectNew     bool
+		expectedSaves int
+	}{
+		"notified": {
+			status:        http.StatusOK,
+			expectNew:     true,
+			expectedSaves: 2,
+		},
+		"notification failed": {
+			status:        http.StatusInternalServerError,
+			expectNew:     false,
+			expectedSaves: 1,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			app := &apps.App{
+				Manifest: apps.Manifest{
+					AppID:           "app1",
+					AppType:         apps.AppTypeHTTP,
+					HTTPRootURL:     "https://app.example.com",
+					OnSecretRotated: apps.NewCall("/rotated"),
+				},
+				BotUserID: "botid",
+				Secret:    "secret",
+			}
+
+			conf := config.NewTestConfigurator(config.Config{})
+			mm := pluginapi.NewClient(&plugintest.API{}, &plugintest.Driver{})
+			s := store.NewService(mm, utils.NewTestLogger(), conf, nil, "", nil)
+			appStore := mock_store.NewMockAppStore(ctrl)
+			jobStore := mock_store.NewMockJobStore(ctrl)
+			manifestStore := mock_store.NewMockManifestStore(ctrl)
+			manifestStore.EXPECT().Get(app.AppID).Return(&app.Manifest, nil).AnyTimes()
+			s.App = appStore
+			s.Job = jobStore
+			s.Manifest = manifestStore
+
+			var secret string
+			httpOut := &testHTTPOut{
+				roundTrip: func(req *http.Request) (*http.Response, error) {
+					// The call is issued with the previous secret.
+					token := strings.TrimPrefix(req.Header.Get(apps.OutgoingAuthHeader), "Bearer ")
+					_, err := jwt.ParseWithClaims(token, &apps.JWTClaims{}, func(*jwt.Token) (interface{}, error) {
+						return []byte("secret"), nil
+					})
+					require.NoError(t, err)
+
+					creq := apps.CallRequest{}
+					require.NoError(t, json.NewDecoder(req.Body).Decode(&creq))
+					secret = creq.Values[RotatedSecret].(string)
+
+					b, _ := json.Marshal(&apps.CallResponse{})
+					return &http.Response{
+						StatusCode: tc.status,
+						Body:       ioutil.NopCloser(bytes.NewReader(b)),
+					}, nil
+				},
+			}
+			p := &Proxy{
+				mm:          mm,
+				log:         utils.NewTestLogger(),
+				store:       s,
+				httpOut:     httpOut,
+				callHistory: newCallHistory(),
+				callLimiter: newCallLimiter(conf),
+				metrics:     metrics.NewService(),
+				conf:        conf,
+			}
+
+			current := app
+			var saved []apps.App
+			appStore.EXPECT().Get(app.AppID).DoAndReturn(func(apps.AppID) (*apps.App, error) {
+				return current, nil
+			}).AnyTimes()
+			appStore.EXPECT().Save(gomock.Any()).DoAndReturn(func(a *apps.App) error {
+				saved = append(saved, *a)
+				current = &saved[len(saved)-1]
+				return nil
+			}).Times(tc.expectedSaves)
+			jobStore.EXPECT().Create(gomock.Any()).Return(true, nil)
+
+			cc := &apps.Context{}
+			cc.AppID = "app1"
+			_, err := p.RotateSecret("", cc, "app1", apps.SecretTypeJWT, time.Hour)
+			require.NoError(t, err)
+			require.NotEmpty(t, secret)
+			require.Equal(t, secret, current.Secret)
+			// The previous secret is kept until it expires either way.
+			require.Equal(t, "secret", current.PreviousSecret.Secret)
+			if tc.expectNew {
+				require.Equal(t, secret, current.JWTSecret())
+			} else {
+				require.Equal(t, "secret", current.JWTSecret())
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package proxy
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+// ScheduleInterval is how often the apps' schedules and scheduled calls are
+// checked.
+const ScheduleInterval = time.Minute
+
+const (
+	// scheduleLateThreshold is how late a scheduled run may be made before it
+	// is considered missed.
+	scheduleLateThreshold = 2 * ScheduleInterval
+
+	// maxMissedRunsCounted limits how many missed runs of a schedule are
+	// counted, for the frequent schedules of an app that was disabled for a
+	// long time. The schedule still resumes from its latest due run.
+	maxMissedRunsCounted = 10000
+)
+
+// ScheduleStatus is the state of a schedule declared in an app's manifest.
+type ScheduleStatus struct {
+	apps.Schedule
+
+	// LastRun and NextRun are in milliseconds since the epoch, 0 if unknown.
+	LastRun int64 `json:"last_run,omitempty"`
+	NextRun int64 `json:"next_run,omitempty"`
+}
+
+// RunSchedules makes the calls of the schedules declared in the enabled apps'
+// manifests, and the one-time calls scheduled by the apps, that are due. It
+// runs as a scheduled cluster job. A schedule's first run is recorded when it
+// is first seen, so the runs before the app was installed are not considered
+// missed. The runs are recorded before the calls are made, so a call is made
+// at most once.
+func (p *Proxy) RunSchedules() {
+	now := time.Now()
+	for _, app := range p.store.App.AsMap() {
+		if len(app.Schedules) == 0 || !p.AppIsEnabled(app) {
+			continue
+		}
+		err := p.runAppSchedules(app, now)
+		if err != nil {
+			p.log.WithError(err).Warnw("Failed to run schedules", "app_id", app.AppID)
+		}
+	}
+
+	err := p.runScheduledCalls(now)
+	if err != nil {
+		p.log.WithError(err).Warnf("Failed to run scheduled calls")
+	}
+}
+
+type scheduledRun struct {
+	call   apps.Call
+	values map[string]interface{}
+}
+
+func (p *Proxy) runAppSchedules(app *apps.App, now time.Time) error {
+	lastRuns, err := p.store.Schedule.GetLastRuns(app.AppID)
+	if err != nil {
+		return err
+	}
+
+	updated, runs := p.dueScheduledRuns(app, lastRuns, now)
+	if !reflect.DeepEqual(lastRuns, updated) {
+		err = p.store.Schedule.SaveLastRuns(app.AppID, updated)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, run := range runs {
+		p.dispatchScheduledCall(app, run.call, run.values)
+	}
+	return nil
+}
+
+// dueScheduledRuns returns the runs of the app's schedules that are due at
+// now, and the updated last runs.
+func (p *Proxy) dueScheduledRuns(app *apps.App, lastRuns map[string]int64, now time.Time) (map[string]int64, []scheduledRun) {
+	updated := map[string]int64{}
+	var runs []scheduledRun
+	for _, s := range app.Schedules {
+		cron, err := utils.ParseCron(s.Cron)
+		if err != nil {
+			p.log.WithError(err).Warnw("Invalid schedule", "app_id", app.AppID, "schedule", s.Name)
+			continue
+		}
+		last, ok := lastRuns[s.Name]
+		if !ok {
+			updated[s.Name] = model.GetMillisForTime(now)
+			continue
+		}
+		updated[s.Name] = last
+
+		due := cron.Next(model.GetTimeForMillis(last))
+		if due.IsZero() || due.After(now) {
+			continue
+		}
+		latest := latestRun(cron, due, now)
+		missed := 0
+		for next := cron.Next(due); !next.IsZero() && !next.After(latest) && missed < maxMissedRunsCounted; next = cron.Next(next) {
+			missed++
+		}
+		due = latest
+		updated[s.Name] = model.GetMillisForTime(due)
+
+		late := now.Sub(due) > scheduleLateThreshold
+		if s.MissedRuns == apps.MissedRunsSkip {
+			if late {
+				continue
+			}
+			missed = 0
+		}
+
+		values := map[string]interface{}{
+			"schedule":     s.Name,
+			"scheduled_at": model.GetMillisForTime(due),
+		}
+		if missed > 0 {
+			values["missed_runs"] = missed
+		}
+		runs = append(runs, scheduledRun{
+			call:   *s.Call,
+			values: values,
+		})
+	}
+	return updated, runs
+}
+
+// latestRun returns the latest run of cron that is not after now, given first,
+// a run that is not after now. It looks back from now in growing windows, so
+// it does not go through all the runs since first, which may be many for a
+// frequent schedule of an app that was disabled for a long time.
+func latestRun(cron *utils.Cron, first, now time.Time) time.Time {
+	for window := ScheduleInterval; ; window *= 2 {
+		from := now.Add(-window)
+		if !from.After(first) {
+			from = first
+		}
+		latest := time.Time{}
+		for next := cron.Next(from); !next.IsZero() && !next.After(now); next = cron.Next(next) {
+			latest = next
+		}
+		if !latest.IsZero() {
+			return latest
+		}
+		if from.Equal(first) {
+			return first
+		}
+	}
+}
+
+func (p *Proxy) runScheduledCalls(now time.Time) error {
+	calls, err := p.store.Schedule.ListCalls("")
+	if err != nil {
+		return err
+	}
+
+	nowMillis := model.GetMillisForTime(now)
+	for _, c := range calls {
+		if c.RunAt > nowMillis {
+			continue
+		}
+		app, err := p.store.App.Get(c.AppID)
+		if err != nil {
+			if errors.Is(err, utils.ErrNotFound) {
+				err = p.store.Schedule.DeleteCall(c.AppID, c.ID)
+			}
+			if err != nil {
+				p.log.WithError(err).Warnw("Failed to get the app for a scheduled call", "app_id", c.AppID, "id", c.ID)
+			}
+			continue
+		}
+		if !p.AppIsEnabled(app) {
+			continue
+		}
+
+		err = p.store.Schedule.DeleteCall(c.AppID, c.ID)
+		if err != nil {
+			p.log.WithError(err).Warnw("Failed to delete a scheduled call", "app_id", c.AppID, "id", c.ID)
+			continue
+		}
+		p.dispatchScheduledCall(app, c.Call, map[string]interface{}{
+			"scheduled_call_id": c.ID,
+			"scheduled_at":      c.RunAt,
+		})
+	}
+	return nil
+}
+
+func (p *Proxy) dispatchScheduledCall(app *apps.App, call apps.Call, values map[string]interface{}) {
+	creq := &apps.CallRequest{
+		Call: call,
+		Context: &apps.Context{
+			UserAgentContext: apps.UserAgentContext{
+				AppID: app.AppID,
+			},
+		},
+		Values: values,
+	}
+	if !p.dispatcher.dispatch(app.AppID, func() {
+		resp := p.callFromSource(CallSourceSchedule, "", app.BotUserID, creq)
+		if resp.Type == apps.CallResponseTypeError {
+			p.log.Warnw("Scheduled call failed",
+				"app_id", app.AppID, "path", creq.Path, "error", resp.ErrorText)
+		}
+	}) {
+		p.log.Warnw("Too many calls pending, dropped a scheduled call",
+			"app_id", app.AppID, "path", creq.Path)
+	}
+}
+
+// GetSchedules returns the schedules declared in an app's manifest, with when
+// they last ran, and when they are due next.
+func (p *Proxy) GetSchedules(appID apps.AppID) ([]ScheduleStatus, error) {
+	app, err := p.store.App.Get(appID)
+	if err != nil {
+		return nil, err
+	}
+	if len(app.Schedules) == 0 {
+		return nil, nil
+	}
+	lastRuns, err := p.store.Schedule.GetLastRuns(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []ScheduleStatus
+	for _, s := range app.Schedules {
+		status := ScheduleStatus{
+			Schedule: s,
+			LastRun:  lastRuns[s.Name],
+		}
+		if cron, err := utils.ParseCron(s.Cron); err == nil {
+			from := time.Now()
+			if status.LastRun != 0 {
+				from = model.GetTimeForMillis(status.LastRun)
+			}
+			if next := cron.Next(from); !next.IsZero() {
+				status.NextRun = model.GetMillisForTime(next)
+			}
+		}
+		out = append(out, status)
+	}
+	return out, nil
+}
+
+// ListScheduledCalls returns the pending one-time calls of an app, or of all
+// apps if appID is empty, by when they are due.
+func (p *Proxy) ListScheduledCalls(appID apps.AppID) ([]*apps.ScheduledCall, error) {
+	return p.store.Schedule.ListCalls(appID)
+}
+
+func (p *Proxy) deleteAppSchedules(appID apps.AppID) error {
+	calls, err := p.ListScheduledCalls(appID)
+	if err != nil {
+		return err
+	}
+	for _, c := range calls {
+		err = p.store.Schedule.DeleteCall(c.AppID, c.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return p.store.Schedule.DeleteLastRuns(appID)
+}
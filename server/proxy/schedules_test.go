@@ -0,0 +1,138 @@
+// +build !e2e
+
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestDueScheduledRuns(t *testing.T) {
+	p := &Proxy{
+		log: utils.NewTestLogger(),
+	}
+	millis := func(t time.Time) int64 { return model.GetMillisForTime(t) }
+	now := time.Date(2021, 6, 16, 10, 30, 20, 0, time.UTC)
+	call := &apps.Call{Path: "/tick"}
+
+	for _, tc := range []struct {
+		name             string
+		schedule         apps.Schedule
+		lastRun          int64
+		expectedLastRun  int64
+		expectedValues   map[string]interface{}
+		expectedNoRecord bool
+	}{
+		{
+			name:            "first seen",
+			schedule:        apps.Schedule{Name: "s", Cron: "* * * * *", Call: call},
+			expectedLastRun: millis(now),
+		},
+		{
+			name:            "not due",
+			schedule:        apps.Schedule{Name: "s", Cron: "0 * * * *", Call: call},
+			lastRun:         millis(time.Date(2021, 6, 16, 10, 0, 0, 0, time.UTC)),
+			expectedLastRun: millis(time.Date(2021, 6, 16, 10, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:            "due",
+			schedule:        apps.Schedule{Name: "s", Cron: "30 10 * * *", Call: call},
+			lastRun:         millis(time.Date(2021, 6, 15, 10, 30, 0, 0, time.UTC)),
+			expectedLastRun: millis(time.Date(2021, 6, 16, 10, 30, 0, 0, time.UTC)),
+			expectedValues: map[string]interface{}{
+				"schedule":     "s",
+				"scheduled_at": millis(time.Date(2021, 6, 16, 10, 30, 0, 0, time.UTC)),
+			},
+		},
+		{
+			name:            "missed runs are made once",
+			schedule:        apps.Schedule{Name: "s", Cron: "0 * * * *", Call: call},
+			lastRun:         millis(time.Date(2021, 6, 16, 6, 0, 0, 0, time.UTC)),
+			expectedLastRun: millis(time.Date(2021, 6, 16, 10, 0, 0, 0, time.UTC)),
+			expectedValues: map[string]interface{}{
+				"schedule":     "s",
+				"scheduled_at": millis(time.Date(2021, 6, 16, 10, 0, 0, 0, time.UTC)),
+				"missed_runs":  3,
+			},
+		},
+		{
+			name:            "missed runs over the limit",
+			schedule:        apps.Schedule{Name: "s", Cron: "* * * * *", Call: call},
+			lastRun:         millis(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)),
+			expectedLastRun: millis(time.Date(2021, 6, 16, 10, 30, 0, 0, time.UTC)),
+			expectedValues: map[string]interface{}{
+				"schedule":     "s",
+				"scheduled_at": millis(time.Date(2021, 6, 16, 10, 30, 0, 0, time.UTC)),
+				"missed_runs":  maxMissedRunsCounted,
+			},
+		},
+		{
+			name:            "missed runs over the limit are skipped",
+			schedule:        apps.Schedule{Name: "s", Cron: "* * * * *", MissedRuns: apps.MissedRunsSkip, Call: call},
+			lastRun:         millis(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)),
+			expectedLastRun: millis(time.Date(2021, 6, 16, 10, 30, 0, 0, time.UTC)),
+			expectedValues: map[string]interface{}{
+				"schedule":     "s",
+				"scheduled_at": millis(time.Date(2021, 6, 16, 10, 30, 0, 0, time.UTC)),
+			},
+		},
+		{
+			name:            "missed runs are skipped",
+			schedule:        apps.Schedule{Name: "s", Cron: "0 * * * *", MissedRuns: apps.MissedRunsSkip, Call: call},
+			lastRun:         millis(time.Date(2021, 6, 16, 6, 0, 0, 0, time.UTC)),
+			expectedLastRun: millis(time.Date(2021, 6, 16, 10, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:            "on time run is made when skipping",
+			schedule:        apps.Schedule{Name: "s", Cron: "*/10 * * * *", MissedRuns: apps.MissedRunsSkip, Call: call},
+			lastRun:         millis(time.Date(2021, 6, 16, 9, 0, 0, 0, time.UTC)),
+			expectedLastRun: millis(time.Date(2021, 6, 16, 10, 30, 0, 0, time.UTC)),
+			expectedValues: map[string]interface{}{
+				"schedule":     "s",
+				"scheduled_at": millis(time.Date(2021, 6, 16, 10, 30, 0, 0, time.UTC)),
+			},
+		},
+		{
+			name:             "invalid cron",
+			schedule:         apps.Schedule{Name: "s", Cron: "* *", Call: call},
+			lastRun:          millis(time.Date(2021, 6, 16, 9, 0, 0, 0, time.UTC)),
+			expectedNoRecord: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			app := &apps.App{
+				Manifest: apps.Manifest{
+					AppID:     "app1",
+					Schedules: []apps.Schedule{tc.schedule},
+				},
+			}
+			lastRuns := map[string]int64{}
+			if tc.lastRun != 0 {
+				lastRuns["s"] = tc.lastRun
+				// Stale schedules are dropped.
+				lastRuns["removed"] = tc.lastRun
+			}
+
+			updated, runs := p.dueScheduledRuns(app, lastRuns, now)
+			if tc.expectedNoRecord {
+				require.Empty(t, updated)
+			} else {
+				require.Equal(t, map[string]int64{"s": tc.expectedLastRun}, updated)
+			}
+			if tc.expectedValues == nil {
+				require.Empty(t, runs)
+				return
+			}
+			require.Len(t, runs, 1)
+			require.Equal(t, *call, runs[0].call)
+			require.Equal(t, tc.expectedValues, runs[0].values)
+		})
+	}
+}
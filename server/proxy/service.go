@@ -7,24 +7,36 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"time"
 
 	pluginapi "github.com/mattermost/mattermost-plugin-api"
-	"github.com/mattermost/mattermost-plugin-api/cluster"
 
 	"github.com/mattermost/mattermost-plugin-apps/apps"
 	"github.com/mattermost/mattermost-plugin-apps/mmclient"
+	"github.com/mattermost/mattermost-plugin-apps/server/clusterevents"
 	"github.com/mattermost/mattermost-plugin-apps/server/config"
 	"github.com/mattermost/mattermost-plugin-apps/server/httpout"
+	"github.com/mattermost/mattermost-plugin-apps/server/metrics"
 	"github.com/mattermost/mattermost-plugin-apps/server/store"
 	"github.com/mattermost/mattermost-plugin-apps/upstream"
 	"github.com/mattermost/mattermost-plugin-apps/upstream/upaws"
 	"github.com/mattermost/mattermost-plugin-apps/utils"
 )
 
-type Proxy struct {
-	callOnceMutex *cluster.Mutex
+// MaxCallChainDepth is the maximum number of calls that the proxy will make to
+// an app in response to a single call request, following "call" responses.
+const MaxCallChainDepth = 10
 
+type Proxy struct {
 	builtinUpstreams map[apps.AppID]upstream.Upstream
+	bindingsCache    *bindingsCache
+	filterRegexps    *regexpCache
+	dispatcher       *dispatcher
+	callHistory      *callHistory
+	callLimiter      *callLimiter
+	metrics          metrics.Service
+	clusterEvents    clusterevents.Service
+	jobHandlers      map[string]jobHandler
 
 	mm            *pluginapi.Client
 	log           utils.Logger
@@ -39,13 +51,37 @@ type Service interface {
 	Call(sessionID, actingUserID string, creq *apps.CallRequest) *apps.ProxyCallResponse
 	CompleteRemoteOAuth2(sessionID, actingUserID string, appID apps.AppID, urlValues map[string]interface{}) error
 	GetStatic(appID apps.AppID, path string) (io.ReadCloser, int, error)
+	GetCallHistory(appID apps.AppID) []CallRecord
+	GetKVUsage(appID apps.AppID) (*KVUsage, error)
+	ExportApp(appID apps.AppID) (*AppBackup, error)
+	ImportApp(appID apps.AppID, backup *AppBackup) error
 	GetBindings(sessionID, actingUserID string, cc *apps.Context) ([]*apps.Binding, error)
 	GetRemoteOAuth2ConnectURL(sessionID, actingUserID string, appID apps.AppID) (string, error)
 	Notify(cc *apps.Context, subj apps.Subject) error
 	NotifyRemoteWebhook(app *apps.App, data []byte, path string) error
+	RefreshBindings(appID apps.AppID, userID string)
+	RetryNotifications()
+	PollLifecycleEvents()
+	PollUserLifecycleEvents()
+	PurgeInactiveUserKV()
+	RecountKVUsage()
+	RunSchedules()
+	RunJobs()
+	DispatcherStats() DispatcherStats
+	MetricsHandler() http.Handler
+
+	GetSubscriptions(appID apps.AppID) ([]*apps.Subscription, error)
+	GetSchedules(appID apps.AppID) ([]ScheduleStatus, error)
+	ListScheduledCalls(appID apps.AppID) ([]*apps.ScheduledCall, error)
+	ListDeadLetters() ([]*store.Notification, error)
+	ReplayDeadLetter(id string) error
+	ListJobs() ([]*store.Job, error)
+	RetryJob(id string) error
+	RotateEncryptionKey() (*EncryptionRotation, error)
 
 	AddLocalManifest(actingUserID string, m *apps.Manifest) (string, error)
 	AppIsEnabled(app *apps.App) bool
+	RotateSecret(sessionID string, cc *apps.Context, appID apps.AppID, secretType apps.SecretType, gracePeriod time.Duration) (string, error)
 	EnableApp(client mmclient.Client, sessionID string, cc *apps.Context, appID apps.AppID) (string, error)
 	DisableApp(client mmclient.Client, sessionID string, cc *apps.Context, appID apps.AppID) (string, error)
 	GetInstalledApp(appID apps.AppID) (*apps.App, error)
@@ -62,18 +98,28 @@ type Service interface {
 
 var _ Service = (*Proxy)(nil)
 
-func NewService(mm *pluginapi.Client, log utils.Logger, conf config.Service, aws upaws.Client, s3AssetBucket string, store *store.Service, mutex *cluster.Mutex, httpOut httpout.Service) *Proxy {
-	return &Proxy{
+func NewService(mm *pluginapi.Client, log utils.Logger, conf config.Service, aws upaws.Client, s3AssetBucket string, store *store.Service, httpOut httpout.Service, clusterEvents clusterevents.Service, metricsService metrics.Service) *Proxy {
+	p := &Proxy{
 		builtinUpstreams: map[apps.AppID]upstream.Upstream{},
+		bindingsCache:    newBindingsCache(),
+		filterRegexps:    newRegexpCache(),
+		dispatcher:       newDispatcher(conf),
+		callHistory:      newCallHistory(),
+		callLimiter:      newCallLimiter(conf),
+		metrics:          metricsService,
+		clusterEvents:    clusterEvents,
 		mm:               mm,
 		log:              log,
 		conf:             conf,
 		store:            store,
 		aws:              aws,
 		s3AssetBucket:    s3AssetBucket,
-		callOnceMutex:    mutex,
 		httpOut:          httpOut,
 	}
+	clusterEvents.Handle(clusterEventInvalidateBindings, p.onInvalidateBindingsEvent)
+	metricsService.MustRegister(&dispatcherCollector{dispatcher: p.dispatcher})
+	p.initJobHandlers()
+	return p
 }
 
 func (p *Proxy) AddBuiltinUpstream(appID apps.AppID, up upstream.Upstream) {
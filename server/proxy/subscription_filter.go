@@ -0,0 +1,144 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package proxy
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+)
+
+// maxFilterRegexps limits how many compiled filter regexps are cached.
+const maxFilterRegexps = 1000
+
+// regexpCache caches the compiled regular expressions of the subscription
+// filters, since they are evaluated for every post in the subscribed channels.
+// Once it is full, it is emptied.
+type regexpCache struct {
+	mutex   sync.Mutex
+	regexps map[string]*regexp.Regexp
+}
+
+func newRegexpCache() *regexpCache {
+	return &regexpCache{
+		regexps: map[string]*regexp.Regexp{},
+	}
+}
+
+// compile returns the compiled expression. A nil cache compiles it every time.
+func (c *regexpCache) compile(expr string) (*regexp.Regexp, error) {
+	if c == nil {
+		return regexp.Compile(expr)
+	}
+
+	c.mutex.Lock()
+	re, ok := c.regexps[expr]
+	c.mutex.Unlock()
+	if ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	c.mutex.Lock()
+	if len(c.regexps) >= maxFilterRegexps {
+		c.regexps = map[string]*regexp.Regexp{}
+	}
+	c.regexps[expr] = re
+	c.mutex.Unlock()
+	return re, nil
+}
+
+// filterTarget is the post that the subscription filters are evaluated
+// against. The post's author is loaded once, when a filter needs it.
+type filterTarget struct {
+	mm      *pluginapi.Client
+	regexps *regexpCache
+	post    *model.Post
+	author  *model.User
+}
+
+func (t *filterTarget) getAuthor() (*model.User, error) {
+	if t.author == nil {
+		author, err := t.mm.User.Get(t.post.UserId)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the author of post %s", t.post.Id)
+		}
+		t.author = author
+	}
+	return t.author, nil
+}
+
+// match returns true if the post matches all conditions of the filter.
+func (t *filterTarget) match(f *apps.SubscriptionFilter) (bool, error) {
+	post := t.post
+	if f.MessagePrefix != "" && !strings.HasPrefix(post.Message, f.MessagePrefix) {
+		return false, nil
+	}
+	if f.MessageRegexp != "" {
+		re, err := t.regexps.compile(f.MessageRegexp)
+		if err != nil {
+			return false, err
+		}
+		if !re.MatchString(post.Message) {
+			return false, nil
+		}
+	}
+
+	if len(f.PostTypes) > 0 && !containsString(f.PostTypes, post.Type) {
+		return false, nil
+	}
+
+	switch f.Thread {
+	case apps.ThreadFilterRoot:
+		if post.RootId != "" {
+			return false, nil
+		}
+	case apps.ThreadFilterReply:
+		if post.RootId == "" {
+			return false, nil
+		}
+	}
+
+	if f.AuthorIsBot != nil || len(f.AuthorRoles) > 0 {
+		author, err := t.getAuthor()
+		if err != nil {
+			return false, err
+		}
+		if f.AuthorIsBot != nil && author.IsBot != *f.AuthorIsBot {
+			return false, nil
+		}
+		if len(f.AuthorRoles) > 0 && !hasAnyRole(author, f.AuthorRoles) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func hasAnyRole(user *model.User, roles []string) bool {
+	for _, role := range strings.Fields(user.Roles) {
+		if containsString(roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+)
+
+func TestSubscriptionFilter(t *testing.T) {
+	root := &model.Post{Id: "post1", UserId: "user1", Message: "!deploy staging now"}
+	reply := &model.Post{Id: "post2", UserId: "bot1", RootId: "post1", Message: "deployed", Type: "custom_deploy"}
+	users := map[string]*model.User{
+		"user1": {Id: "user1", Roles: "system_user system_admin"},
+		"bot1":  {Id: "bot1", Roles: "system_user", IsBot: true},
+	}
+
+	for name, tc := range map[string]struct {
+		filter   apps.SubscriptionFilter
+		post     *model.Post
+		expected bool
+	}{
+		"empty":                {apps.SubscriptionFilter{}, root, true},
+		"prefix":               {apps.SubscriptionFilter{MessagePrefix: "!deploy"}, root, true},
+		"prefix mismatch":      {apps.SubscriptionFilter{MessagePrefix: "!deploy"}, reply, false},
+		"regexp":               {apps.SubscriptionFilter{MessageRegexp: `\bstaging\b`}, root, true},
+		"regexp mismatch":      {apps.SubscriptionFilter{MessageRegexp: `^staging`}, root, false},
+		"post type":            {apps.SubscriptionFilter{PostTypes: []string{"custom_deploy"}}, reply, true},
+		"regular post type":    {apps.SubscriptionFilter{PostTypes: []string{""}}, root, true},
+		"post type mismatch":   {apps.SubscriptionFilter{PostTypes: []string{""}}, reply, false},
+		"root":                 {apps.SubscriptionFilter{Thread: apps.ThreadFilterRoot}, root, true},
+		"root mismatch":        {apps.SubscriptionFilter{Thread: apps.ThreadFilterRoot}, reply, false},
+		"reply":                {apps.SubscriptionFilter{Thread: apps.ThreadFilterReply}, reply, true},
+		"reply mismatch":       {apps.SubscriptionFilter{Thread: apps.ThreadFilterReply}, root, false},
+		"bot":                  {apps.SubscriptionFilter{AuthorIsBot: model.NewBool(true)}, reply, true},
+		"not a bot":            {apps.SubscriptionFilter{AuthorIsBot: model.NewBool(false)}, reply, false},
+		"role":                 {apps.SubscriptionFilter{AuthorRoles: []string{"team_admin", "system_admin"}}, root, true},
+		"role mismatch":        {apps.SubscriptionFilter{AuthorRoles: []string{"system_admin"}}, reply, false},
+		"all conditions":       {apps.SubscriptionFilter{MessagePrefix: "!", Thread: apps.ThreadFilterRoot, AuthorIsBot: model.NewBool(false)}, root, true},
+		"one condition failed": {apps.SubscriptionFilter{MessagePrefix: "!", Thread: apps.ThreadFilterReply, AuthorIsBot: model.NewBool(false)}, root, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			testAPI := &plugintest.API{}
+			testAPI.On("GetUser", tc.post.UserId).Return(users[tc.post.UserId], nil)
+			target := &filterTarget{
+				mm:   pluginapi.NewClient(testAPI, &plugintest.Driver{}),
+				post: tc.post,
+			}
+
+			matched, err := target.match(&tc.filter)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, matched)
+		})
+	}
+
+	t.Run("author is loaded once", func(t *testing.T) {
+		testAPI := &plugintest.API{}
+		testAPI.On("GetUser", "user1").Return(users["user1"], nil).Once()
+		target := &filterTarget{
+			mm:   pluginapi.NewClient(testAPI, &plugintest.Driver{}),
+			post: root,
+		}
+
+		for i := 0; i < 3; i++ {
+			matched, err := target.match(&apps.SubscriptionFilter{AuthorIsBot: model.NewBool(false)})
+			require.NoError(t, err)
+			require.True(t, matched)
+		}
+		testAPI.AssertNumberOfCalls(t, "GetUser", 1)
+	})
+}
+
+func TestRegexpCache(t *testing.T) {
+	c := newRegexpCache()
+	re, err := c.compile(`^a+$`)
+	require.NoError(t, err)
+	again, err := c.compile(`^a+$`)
+	require.NoError(t, err)
+	require.Same(t, re, again)
+
+	_, err = c.compile(`(`)
+	require.Error(t, err)
+	require.Len(t, c.regexps, 1)
+
+	for i := 0; i < maxFilterRegexps+10; i++ {
+		_, err = c.compile(fmt.Sprintf("^%d$", i))
+		require.NoError(t, err)
+	}
+	require.LessOrEqual(t, len(c.regexps), maxFilterRegexps)
+}
@@ -4,12 +4,10 @@
 package proxy
 
 import (
-	"time"
-
 	"github.com/pkg/errors"
 
 	"github.com/mattermost/mattermost-plugin-apps/apps"
-	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
 )
 
 const PrevVersion = "prev_version"
@@ -34,9 +32,7 @@ func (p *Proxy) SynchronizeInstalledApps() error {
 
 	for _, app := range diff {
 		m := listed[app.AppID]
-		values := map[string]string{
-			PrevVersion: string(app.Version),
-		}
+		prevVersion := app.Version
 
 		// Store the new manifest to update the current mappings of the App
 		app.Manifest = *m
@@ -44,26 +40,21 @@ func (p *Proxy) SynchronizeInstalledApps() error {
 		if err != nil {
 			return err
 		}
+		p.invalidateBindings(app.AppID, "")
 
-		// Call OnVersionChanged the function of the app. It should be called only once
+		// Call OnVersionChanged the function of the app. Every node in the
+		// cluster synchronizes the apps, the job makes sure it is called once.
+		// The done job is deleted soon after, so the same upgrade made again
+		// after a rollback is not mistaken for it.
 		if app.OnVersionChanged != nil {
-			err := p.callOnce(func() error {
-				creq := &apps.CallRequest{
-					Call:   *app.OnVersionChanged,
-					Values: map[string]interface{}{},
-				}
-				for k, v := range values {
-					creq.Values[k] = v
-				}
-
-				resp := p.Call("", app.BotUserID, creq)
-				if resp.Type == apps.CallResponseTypeError {
-					return errors.Wrapf(resp, "call %s failed", creq.Path)
-				}
-				return nil
-			})
+			err = p.startJob(jobTypeVersionChanged,
+				jobID(jobTypeVersionChanged, string(app.AppID), string(prevVersion), string(app.Version)),
+				map[string]string{
+					"app_id":    string(app.AppID),
+					PrevVersion: string(prevVersion),
+				})
 			if err != nil {
-				p.log.WithError(err).Errorw("Failed in callOnce:OnVersionChanged",
+				p.log.WithError(err).Errorw("Failed to start the OnVersionChanged job",
 					"app_id", app.AppID)
 			}
 		}
@@ -72,36 +63,25 @@ func (p *Proxy) SynchronizeInstalledApps() error {
 	return nil
 }
 
-func (p *Proxy) callOnce(f func() error) error {
-	// Delete previous job
-	if err := p.mm.KV.Delete(config.KVCallOnceKey); err != nil {
-		return errors.Wrap(err, "can't delete key")
-	}
-	// Ensure all instances run this
-	time.Sleep(10 * time.Second)
-
-	p.callOnceMutex.Lock()
-	defer p.callOnceMutex.Unlock()
-	value := 0
-	if err := p.mm.KV.Get(config.KVCallOnceKey, &value); err != nil {
+// runVersionChangedJob calls the OnVersionChanged function of an app.
+func (p *Proxy) runVersionChangedJob(job *store.Job) error {
+	app, err := p.store.App.Get(apps.AppID(job.Data["app_id"]))
+	if err != nil {
 		return err
 	}
-	if value != 0 {
-		// job is already run by other instance
+	if app.OnVersionChanged == nil {
 		return nil
 	}
 
-	// job is should be run by this instance
-	if err := f(); err != nil {
-		return errors.Wrap(err, "can't run the job")
-	}
-	value = 1
-	ok, err := p.mm.KV.Set(config.KVCallOnceKey, value)
-	if err != nil {
-		return errors.Wrapf(err, "can't set key %s to %d", config.KVCallOnceKey, value)
+	creq := &apps.CallRequest{
+		Call: *app.OnVersionChanged,
+		Values: map[string]interface{}{
+			PrevVersion: job.Data[PrevVersion],
+		},
 	}
-	if !ok {
-		return errors.Errorf("can't set key %s to %d", config.KVCallOnceKey, value)
+	resp := p.call("", app.BotUserID, creq)
+	if resp.Type == apps.CallResponseTypeError {
+		return errors.Wrapf(resp, "call %s failed", creq.Path)
 	}
 	return nil
 }
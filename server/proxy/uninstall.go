@@ -24,7 +24,7 @@ func (p *Proxy) UninstallApp(client mmclient.Client, sessionID string, cc *apps.
 			Call:    *app.OnUninstall,
 			Context: cc,
 		}
-		resp := p.Call(sessionID, cc.ActingUserID, creq)
+		resp := p.call(sessionID, cc.ActingUserID, creq)
 		if resp.Type == apps.CallResponseTypeError {
 			p.log.WithError(err).Warnw("OnUninstall failed, uninstalling app anyway",
 				"app_id", app.AppID)
@@ -50,6 +50,12 @@ func (p *Proxy) UninstallApp(client mmclient.Client, sessionID string, cc *apps.
 			return "", errors.Wrapf(err, "failed to revoke bot access token for %s", app.AppID)
 		}
 	}
+	if app.PreviousBotAccessToken.ID != "" {
+		if err = client.RevokeUserAccessToken(app.PreviousBotAccessToken.ID); err != nil {
+			p.log.WithError(err).Warnw("Failed to revoke the previous bot access token",
+				"app_id", app.AppID)
+		}
+	}
 
 	// disable the bot account
 	if _, err = client.DisableBot(app.BotUserID); err != nil {
@@ -70,13 +76,28 @@ func (p *Proxy) UninstallApp(client mmclient.Client, sessionID string, cc *apps.
 	}
 
 	// remove data
+	if err = p.store.UserKV.DeleteAll(app.BotUserID); err != nil {
+		return "", errors.Wrapf(err, "can't delete app user data - %s", app.AppID)
+	}
 	if err = p.store.AppKV.DeleteAll(app.BotUserID); err != nil {
 		return "", errors.Wrapf(err, "can't delete app data - %s", app.AppID)
 	}
 
+	// remove subscriptions
+	if err = p.store.Subscription.DeleteByApp(app.AppID); err != nil {
+		return "", errors.Wrapf(err, "can't delete subscriptions - %s", app.AppID)
+	}
+
+	// remove scheduled calls
+	if err = p.deleteAppSchedules(app.AppID); err != nil {
+		return "", errors.Wrapf(err, "can't delete scheduled calls - %s", app.AppID)
+	}
+
 	p.log.Infow("Uninstalled app",
 		"app_id", app.AppID)
 
+	p.invalidateBindings(app.AppID, "")
+	p.callHistory.delete(app.AppID)
 	p.dispatchRefreshBindingsEvent(cc.ActingUserID)
 
 	return message, nil
@@ -0,0 +1,78 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package proxy
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// UserKVPurgeInterval is how often the apps' per-user values of the
+// deactivated users are deleted, so they are kept for up to this long after
+// a user is deactivated.
+const UserKVPurgeInterval = time.Hour
+
+const inactiveUsersPerPage = 200
+
+// PurgeInactiveUserKV deletes the apps' per-user values of the users that have
+// been deactivated. It runs as a scheduled cluster job. The deactivated users
+// are listed once per run, and matched against the users that the apps have
+// values for; the values of the users deleted permanently are not purged.
+func (p *Proxy) PurgeInactiveUserKV() {
+	err := p.purgeInactiveUserKV()
+	if err != nil {
+		p.log.WithError(err).Warnf("Failed to delete the values of inactive users")
+	}
+}
+
+func (p *Proxy) purgeInactiveUserKV() error {
+	usersByBot := map[string][]string{}
+	for _, app := range p.store.App.AsMap() {
+		userIDs, err := p.store.UserKV.ListUsers(app.BotUserID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list users with values for %s", app.AppID)
+		}
+		if len(userIDs) > 0 {
+			usersByBot[app.BotUserID] = userIDs
+		}
+	}
+	if len(usersByBot) == 0 {
+		return nil
+	}
+
+	inactive := map[string]bool{}
+	for page := 0; ; page++ {
+		users, err := p.mm.User.List(&model.UserGetOptions{
+			Inactive: true,
+			Page:     page,
+			PerPage:  inactiveUsersPerPage,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to list inactive users - page, %d", page)
+		}
+		for _, user := range users {
+			inactive[user.Id] = true
+		}
+		if len(users) < inactiveUsersPerPage {
+			break
+		}
+	}
+
+	for botUserID, userIDs := range usersByBot {
+		for _, userID := range userIDs {
+			if !inactive[userID] {
+				continue
+			}
+			err := p.store.UserKV.DeleteUser(botUserID, userID)
+			if err != nil {
+				return errors.Wrapf(err, "failed to delete the values of user %s for bot %s", userID, botUserID)
+			}
+			p.log.Debugw("Deleted the values of an inactive user", "bot_user_id", botUserID, "user_id", userID)
+		}
+	}
+	return nil
+}
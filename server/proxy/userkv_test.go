@@ -0,0 +1,67 @@
+// +build !e2e
+
+package proxy
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/mocks/mock_store"
+	"github.com/mattermost/mattermost-plugin-apps/server/store"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestPurgeInactiveUserKV(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	appStore := mock_store.NewMockAppStore(ctrl)
+	userKVStore := mock_store.NewMockUserKVStore(ctrl)
+	testAPI := &plugintest.API{}
+	testAPI.On("GetUsers", mock.MatchedBy(func(opts *model.UserGetOptions) bool {
+		return opts.Inactive && opts.Page == 0
+	})).Return([]*model.User{
+		{Id: "deactivated", DeleteAt: 1},
+		{Id: "other", DeleteAt: 1},
+	}, nil)
+
+	p := &Proxy{
+		mm:  pluginapi.NewClient(testAPI, &plugintest.Driver{}),
+		log: utils.NewTestLogger(),
+		store: &store.Service{
+			App:    appStore,
+			UserKV: userKVStore,
+		},
+	}
+
+	appStore.EXPECT().AsMap().Return(map[apps.AppID]*apps.App{
+		"app1": {BotUserID: "bot1"},
+		"app2": {BotUserID: "bot2"},
+		"app3": {BotUserID: "bot3"},
+	})
+	userKVStore.EXPECT().ListUsers("bot1").Return([]string{"active", "deactivated"}, nil)
+	userKVStore.EXPECT().ListUsers("bot2").Return([]string{"deactivated"}, nil)
+	userKVStore.EXPECT().ListUsers("bot3").Return(nil, nil)
+	userKVStore.EXPECT().DeleteUser("bot1", "deactivated").Return(nil)
+	userKVStore.EXPECT().DeleteUser("bot2", "deactivated").Return(nil)
+
+	require.NoError(t, p.purgeInactiveUserKV())
+	// The inactive users are listed once.
+	testAPI.AssertNumberOfCalls(t, "GetUsers", 1)
+
+	t.Run("no values", func(t *testing.T) {
+		appStore.EXPECT().AsMap().Return(map[apps.AppID]*apps.App{
+			"app3": {BotUserID: "bot3"},
+		})
+		userKVStore.EXPECT().ListUsers("bot3").Return(nil, nil)
+
+		require.NoError(t, p.purgeInactiveUserKV())
+		testAPI.AssertNumberOfCalls(t, "GetUsers", 1)
+	})
+}
@@ -1,22 +1,62 @@
 package store
 
 import (
+	"encoding/json"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
 	"github.com/mattermost/mattermost-plugin-apps/server/config"
 )
 
-const (
-	keysPerPage = 1000
-)
+const keysPerPage = 1000
 
 type AppKVStore interface {
 	Set(botUserID, prefix, id string, ref interface{}) (bool, error)
+	// SetWithOptions returns false if the value was not set because the
+	// conditions in opts were not met.
+	SetWithOptions(botUserID, prefix, id string, ref interface{}, opts apps.KVSetOptions) (bool, error)
 	Get(botUserID, prefix, id string, ref interface{}) error
 	Delete(botUserID, prefix, id string) error
 	DeleteAll(botUserID string) error
+
+	// InitIndex marks a new app's keys as fully indexed, so that DeleteAll
+	// does not need to scan the whole KV store for the keys set before the
+	// index existed.
+	InitIndex(botUserID string) error
+	// ListKeys returns up to limit of the IDs in a namespace that follow
+	// after, sorted. The keys are indexed before they are set, and removed
+	// from the index after they are deleted, so keys whose conditional set
+	// failed may be listed; expired keys are not. The list is marked
+	// incomplete if some keys may not be indexed: the ones set before the
+	// app's keys were indexed, or the ones that failed to be indexed.
+	ListKeys(botUserID, prefix, after string, limit int) (*apps.KVListResponse, error)
+	// GetBatch returns the raw (JSON) values of the IDs that are set.
+	GetBatch(botUserID, prefix string, ids []string) (map[string]json.RawMessage, error)
+	// SetBatch sets the values one by one, it is not atomic.
+	SetBatch(botUserID, prefix string, values map[string]interface{}) error
+
+	// GetUsage returns how much of the KV store the app uses, including its
+	// per-user values.
+	GetUsage(botUserID string) (*KVUsage, error)
+	// RecountUsage recounts the app's usage from its values, to correct the
+	// changes that were not counted.
+	RecountUsage(botUserID string) error
+}
+
+// appKVNamespaces is the list of an app's namespaces that have an index.
+type appKVNamespaces struct {
+	// Complete is set if all of the app's keys are indexed, i.e. the app was
+	// installed after the index was introduced, and no key failed to be
+	// indexed. The keys are hashed, so the ones set before cannot be indexed
+	// later.
+	Complete   bool     `json:"complete,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
 }
 
 type appKVStore struct {
@@ -25,13 +65,61 @@ type appKVStore struct {
 
 var _ AppKVStore = (*appKVStore)(nil)
 
-// TODO use raw byte API: for now all JSON is re-encoded to use api.Mattermost API
 func (s *appKVStore) Set(botUserID, prefix, id string, ref interface{}) (bool, error) {
+	return s.SetWithOptions(botUserID, prefix, id, ref, apps.KVSetOptions{})
+}
+
+// TODO use raw byte API: for now all JSON is re-encoded to use api.Mattermost API
+func (s *appKVStore) SetWithOptions(botUserID, prefix, id string, ref interface{}, opts apps.KVSetOptions) (bool, error) {
+	if err := opts.IsValid(); err != nil {
+		return false, err
+	}
 	key, err := s.hashkey(config.KVAppPrefix, botUserID, prefix, id)
 	if err != nil {
 		return false, err
 	}
-	return s.mm.KV.Set(key, ref)
+
+	data, err := marshalValue(ref)
+	if err != nil {
+		return false, err
+	}
+
+	expiry := kvExpiry{
+		ExpireAt: expireAt(opts.ExpireInSeconds),
+	}
+	entry := appKVIndexEntry{
+		ID:       id,
+		ExpireAt: expiry.ExpireAt,
+	}
+	if entry.ExpireAt != 0 {
+		entry.Size = len(key) + len(data)
+	}
+	prev, err := s.addToIndex(botUserID, prefix, entry)
+	if err != nil {
+		// The value is set anyway, the app's index is marked incomplete
+		// instead, so that DeleteAll still finds the key.
+		s.log.WithError(err).Warnw("Failed to index app KV key", "bot_user_id", botUserID)
+		err = s.markIndexIncomplete(botUserID)
+		if err != nil {
+			return false, err
+		}
+	}
+	if prev != nil {
+		expiry.PrevExpireAt = prev.ExpireAt
+		expiry.PrevSize = prev.Size
+	}
+
+	var options []pluginapi.KVSetOption
+	if opts.ExpireInSeconds > 0 {
+		options = append(options, pluginapi.SetExpiry(time.Duration(opts.ExpireInSeconds)*time.Second))
+	}
+	switch {
+	case opts.IfAbsent:
+		options = append(options, pluginapi.SetAtomic(nil))
+	case opts.OldValue != nil:
+		options = append(options, pluginapi.SetAtomic(opts.OldValue))
+	}
+	return s.setAppValue(botUserID, key, data, expiry, options...)
 }
 
 func (s *appKVStore) Get(botUserID, prefix, id string, ref interface{}) error {
@@ -47,10 +135,53 @@ func (s *appKVStore) Delete(botUserID, prefix, id string) error {
 	if err != nil {
 		return err
 	}
-	return s.mm.KV.Delete(key)
+	expiry := kvExpiry{}
+	entry, err := s.getIndexEntry(botUserID, prefix, id)
+	if err != nil {
+		return err
+	}
+	if entry != nil {
+		expiry.PrevExpireAt = entry.ExpireAt
+		expiry.PrevSize = entry.Size
+	}
+	_, err = s.setAppValue(botUserID, key, nil, expiry)
+	if err != nil {
+		return err
+	}
+	return s.removeFromIndex(botUserID, prefix, id)
 }
 
+// DeleteAll deletes the app's keys listed in its index. The keys of the apps
+// installed before the index existed may not all be indexed, so for those the
+// whole KV store is scanned. The app's KV usage is reset, so the per-user
+// values should be deleted first.
 func (s *appKVStore) DeleteAll(botUserID string) error {
+	namespaces, err := s.getNamespaces(botUserID)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces.Namespaces {
+		err = s.deleteIndex(botUserID, ns)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !namespaces.Complete {
+		err = s.deleteAllUnindexed(botUserID)
+		if err != nil {
+			return err
+		}
+	}
+	err = s.mm.KV.Delete(config.KVAppNamespacesPrefix + botUserID)
+	if err != nil {
+		return err
+	}
+	return s.deleteKVUsage(botUserID)
+}
+
+func (s *appKVStore) deleteAllUnindexed(botUserID string) error {
 	prefix := config.KVAppPrefix + botUserID
 	var keysToDelete []string
 
@@ -80,3 +211,113 @@ func (s *appKVStore) DeleteAll(botUserID string) error {
 
 	return nil
 }
+
+func (s *appKVStore) GetUsage(botUserID string) (*KVUsage, error) {
+	return s.getKVUsage(botUserID)
+}
+
+func (s *appKVStore) RecountUsage(botUserID string) error {
+	return s.recountKVUsage(botUserID)
+}
+
+func (s *appKVStore) InitIndex(botUserID string) error {
+	_, err := s.mm.KV.Set(config.KVAppNamespacesPrefix+botUserID, appKVNamespaces{Complete: true}, pluginapi.SetAtomic(nil))
+	return err
+}
+
+func (s *appKVStore) ListKeys(botUserID, prefix, after string, limit int) (*apps.KVListResponse, error) {
+	namespaces, err := s.getNamespaces(botUserID)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := s.listIndex(botUserID, prefix, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &apps.KVListResponse{
+		IDs:        ids,
+		Incomplete: !namespaces.Complete,
+	}, nil
+}
+
+func (s *appKVStore) GetBatch(botUserID, prefix string, ids []string) (map[string]json.RawMessage, error) {
+	out := map[string]json.RawMessage{}
+	for _, id := range ids {
+		key, err := s.hashkey(config.KVAppPrefix, botUserID, prefix, id)
+		if err != nil {
+			return nil, err
+		}
+		var data []byte
+		err = s.mm.KV.Get(key, &data)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			out[id] = data
+		}
+	}
+	return out, nil
+}
+
+func (s *appKVStore) SetBatch(botUserID, prefix string, values map[string]interface{}) error {
+	ids := make([]string, 0, len(values))
+	for id := range values {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		_, err := s.Set(botUserID, prefix, id, values[id])
+		if err != nil {
+			return errors.Wrapf(err, "failed to set %s", id)
+		}
+	}
+	return nil
+}
+
+func (s *appKVStore) getNamespaces(botUserID string) (*appKVNamespaces, error) {
+	namespaces := &appKVNamespaces{}
+	err := s.mm.KV.Get(config.KVAppNamespacesPrefix+botUserID, namespaces)
+	if err != nil {
+		return nil, err
+	}
+	return namespaces, nil
+}
+
+func (s *appKVStore) addNamespace(botUserID, prefix string) error {
+	_, err := s.updateAtomic(config.KVAppNamespacesPrefix+botUserID, func(data []byte) ([]byte, error) {
+		namespaces := appKVNamespaces{}
+		if len(data) > 0 {
+			err := json.Unmarshal(data, &namespaces)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, ns := range namespaces.Namespaces {
+			if ns == prefix {
+				return data, nil
+			}
+		}
+		namespaces.Namespaces = append(namespaces.Namespaces, prefix)
+		return json.Marshal(namespaces)
+	})
+	return err
+}
+
+// markIndexIncomplete records that some of the app's keys may not be indexed.
+func (s *appKVStore) markIndexIncomplete(botUserID string) error {
+	_, err := s.updateAtomic(config.KVAppNamespacesPrefix+botUserID, func(data []byte) ([]byte, error) {
+		namespaces := appKVNamespaces{}
+		if len(data) > 0 {
+			err := json.Unmarshal(data, &namespaces)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !namespaces.Complete {
+			return data, nil
+		}
+		namespaces.Complete = false
+		return json.Marshal(namespaces)
+	})
+	return err
+}
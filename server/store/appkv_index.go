@@ -0,0 +1,461 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+)
+
+// The IDs that an app sets in a namespace are indexed in pages of up to
+// appKVIndexPageSize IDs, sorted. A page covers the IDs from its first ID up
+// to the first ID of the next page; the pages and their first IDs are listed
+// in the namespace's directory. A set or a delete rewrites only the page that
+// covers the ID, and the directory is rewritten only when a page is split in
+// two, or an empty page is dropped.
+//
+// A page may hold IDs that it no longer covers, if it was split concurrently.
+// Those are ignored when listing, and moved to the page that covers them when
+// the page is split, or becomes empty.
+const appKVIndexPageSize = 256
+
+// appKVIndexPage is an entry of a namespace's index directory.
+type appKVIndexPage struct {
+	First string `json:"f,omitempty"`
+	ID    string `json:"p"`
+}
+
+// appKVIndexEntry is an indexed ID, and when its value expires, in Unix
+// milliseconds, 0 if it does not. The size of an expiring value is kept, so
+// that it can be removed from the app's KV usage after it expired.
+type appKVIndexEntry struct {
+	ID       string `json:"i"`
+	ExpireAt int64  `json:"x,omitempty"`
+	Size     int    `json:"s,omitempty"`
+}
+
+func (e appKVIndexEntry) expired(now int64) bool {
+	return e.ExpireAt != 0 && e.ExpireAt <= now
+}
+
+type appKVIndexDir []appKVIndexPage
+
+// find returns the index of the page that covers id.
+func (dir appKVIndexDir) find(id string) int {
+	return sort.Search(len(dir), func(i int) bool { return dir[i].First > id }) - 1
+}
+
+func (dir appKVIndexDir) indexOf(pageID string) int {
+	for i, page := range dir {
+		if page.ID == pageID {
+			return i
+		}
+	}
+	return -1
+}
+
+// covers returns true if page i is the page that covers id.
+func (dir appKVIndexDir) covers(i int, id string) bool {
+	return i >= 0 && dir[i].First <= id && (i == len(dir)-1 || id < dir[i+1].First)
+}
+
+func appKVIndexDirKey(botUserID, prefix string) string {
+	return config.KVAppKeyIndexPrefix + botUserID + "." + prefix
+}
+
+func appKVIndexPageKey(botUserID, prefix, pageID string) string {
+	return appKVIndexDirKey(botUserID, prefix) + "." + pageID
+}
+
+func (s *appKVStore) getIndexDir(botUserID, prefix string) (appKVIndexDir, error) {
+	var dir appKVIndexDir
+	err := s.mm.KV.Get(appKVIndexDirKey(botUserID, prefix), &dir)
+	if err != nil {
+		return nil, err
+	}
+	return dir, nil
+}
+
+// ensureIndexDir returns the namespace's index directory, and creates it with
+// a single page if it does not exist yet. The namespace is added to the app's
+// list before its index is created.
+func (s *appKVStore) ensureIndexDir(botUserID, prefix string) (appKVIndexDir, error) {
+	dir, err := s.getIndexDir(botUserID, prefix)
+	if err != nil || dir != nil {
+		return dir, err
+	}
+
+	err = s.addNamespace(botUserID, prefix)
+	if err != nil {
+		return nil, err
+	}
+	dir = appKVIndexDir{{ID: model.NewId()}}
+	_, err = s.mm.KV.Set(appKVIndexDirKey(botUserID, prefix), dir, pluginapi.SetAtomic(nil))
+	if err != nil {
+		return nil, err
+	}
+	// Re-read, in case it was created concurrently.
+	return s.getIndexDir(botUserID, prefix)
+}
+
+func (s *appKVStore) getIndexPage(botUserID, prefix, pageID string) ([]appKVIndexEntry, error) {
+	var entries []appKVIndexEntry
+	err := s.mm.KV.Get(appKVIndexPageKey(botUserID, prefix, pageID), &entries)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// updateIndexPage applies change to the entries of a page, sorted, and then
+// drops the expired entries. It returns the entries that were written.
+func (s *appKVStore) updateIndexPage(botUserID, prefix, pageID string, change func([]appKVIndexEntry) []appKVIndexEntry) ([]appKVIndexEntry, error) {
+	var entries []appKVIndexEntry
+	_, err := s.updateAtomic(appKVIndexPageKey(botUserID, prefix, pageID), func(data []byte) ([]byte, error) {
+		entries = nil
+		if len(data) > 0 {
+			err := json.Unmarshal(data, &entries)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		now := model.GetMillis()
+		changed := change(entries)
+		entries = make([]appKVIndexEntry, 0, len(changed))
+		for _, e := range changed {
+			if !e.expired(now) {
+				entries = append(entries, e)
+			}
+		}
+		if len(entries) == 0 {
+			return nil, nil
+		}
+		return json.Marshal(entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func putIndexEntries(entries []appKVIndexEntry, add ...appKVIndexEntry) []appKVIndexEntry {
+	for _, e := range add {
+		i := sort.Search(len(entries), func(i int) bool { return entries[i].ID >= e.ID })
+		if i < len(entries) && entries[i].ID == e.ID {
+			entries[i] = e
+			continue
+		}
+		entries = append(entries, appKVIndexEntry{})
+		copy(entries[i+1:], entries[i:])
+		entries[i] = e
+	}
+	return entries
+}
+
+func removeIndexEntry(entries []appKVIndexEntry, id string) []appKVIndexEntry {
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].ID >= id })
+	if i == len(entries) || entries[i].ID != id {
+		return entries
+	}
+	return append(entries[:i], entries[i+1:]...)
+}
+
+func findIndexEntry(entries []appKVIndexEntry, id string) *appKVIndexEntry {
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].ID >= id })
+	if i == len(entries) || entries[i].ID != id {
+		return nil
+	}
+	e := entries[i]
+	return &e
+}
+
+// addToIndex indexes an ID. It returns the entry that it replaced, nil if the
+// ID was not indexed.
+func (s *appKVStore) addToIndex(botUserID, prefix string, entry appKVIndexEntry) (*appKVIndexEntry, error) {
+	dir, err := s.ensureIndexDir(botUserID, prefix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to index key %s", entry.ID)
+	}
+	var prev *appKVIndexEntry
+	err = s.updateIndex(botUserID, prefix, dir, entry.ID, func(entries []appKVIndexEntry) []appKVIndexEntry {
+		if e := findIndexEntry(entries, entry.ID); e != nil && prev == nil {
+			prev = e
+		}
+		return putIndexEntries(entries, entry)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to index key %s", entry.ID)
+	}
+	return prev, nil
+}
+
+// getIndexEntry returns the entry of an ID, nil if it is not indexed.
+func (s *appKVStore) getIndexEntry(botUserID, prefix, id string) (*appKVIndexEntry, error) {
+	dir, err := s.getIndexDir(botUserID, prefix)
+	if err != nil || dir == nil {
+		return nil, err
+	}
+	entries, err := s.getIndexPage(botUserID, prefix, dir[dir.find(id)].ID)
+	if err != nil {
+		return nil, err
+	}
+	return findIndexEntry(entries, id), nil
+}
+
+func (s *appKVStore) removeFromIndex(botUserID, prefix, id string) error {
+	dir, err := s.getIndexDir(botUserID, prefix)
+	if err != nil || dir == nil {
+		return err
+	}
+	err = s.updateIndex(botUserID, prefix, dir, id, func(entries []appKVIndexEntry) []appKVIndexEntry {
+		return removeIndexEntry(entries, id)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove key %s from the index", id)
+	}
+	return nil
+}
+
+// updateIndex applies change to the page that covers id. If the directory
+// changed meanwhile, so that another page covers id now, the change is applied
+// to that page too. A page that grows too large is split, and an empty page is
+// dropped.
+func (s *appKVStore) updateIndex(botUserID, prefix string, dir appKVIndexDir, id string, change func([]appKVIndexEntry) []appKVIndexEntry) error {
+	for attempt := 0; attempt < maxAtomicUpdateAttempts; attempt++ {
+		pageID := dir[dir.find(id)].ID
+		entries, err := s.updateIndexPage(botUserID, prefix, pageID, change)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case len(entries) > appKVIndexPageSize:
+			err = s.splitIndexPage(botUserID, prefix, pageID, entries)
+		case len(entries) == 0 && dir.indexOf(pageID) > 0:
+			err = s.dropIndexPage(botUserID, prefix, pageID)
+		}
+		if err != nil {
+			return err
+		}
+
+		dir, err = s.getIndexDir(botUserID, prefix)
+		if err != nil {
+			return err
+		}
+		if dir == nil || dir[dir.find(id)].ID == pageID {
+			return nil
+		}
+	}
+	return errors.New("too many concurrent changes to the index")
+}
+
+// splitIndexPage adds a page for the upper half of a page's IDs to the
+// directory, and moves them there.
+func (s *appKVStore) splitIndexPage(botUserID, prefix, pageID string, entries []appKVIndexEntry) error {
+	first := entries[len(entries)/2].ID
+	_, err := s.updateAtomic(appKVIndexDirKey(botUserID, prefix), func(data []byte) ([]byte, error) {
+		var dir appKVIndexDir
+		err := json.Unmarshal(data, &dir)
+		if err != nil {
+			return nil, err
+		}
+		i := dir.indexOf(pageID)
+		if i < 0 || first <= dir[i].First || !dir.covers(i, first) {
+			// Split concurrently.
+			return data, nil
+		}
+		dir = append(dir, appKVIndexPage{})
+		copy(dir[i+2:], dir[i+1:])
+		dir[i+1] = appKVIndexPage{First: first, ID: model.NewId()}
+		return json.Marshal(dir)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to split an index page")
+	}
+	return s.moveIndexEntries(botUserID, prefix, pageID)
+}
+
+// dropIndexPage removes an empty page from the directory, so that the
+// previous page covers its IDs.
+func (s *appKVStore) dropIndexPage(botUserID, prefix, pageID string) error {
+	_, err := s.updateAtomic(appKVIndexDirKey(botUserID, prefix), func(data []byte) ([]byte, error) {
+		var dir appKVIndexDir
+		err := json.Unmarshal(data, &dir)
+		if err != nil {
+			return nil, err
+		}
+		i := dir.indexOf(pageID)
+		if i <= 0 {
+			return data, nil
+		}
+		dir = append(dir[:i], dir[i+1:]...)
+		return json.Marshal(dir)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to drop an index page")
+	}
+	// IDs may have been added to the page concurrently.
+	return s.moveIndexEntries(botUserID, prefix, pageID)
+}
+
+// moveIndexEntries moves the entries of a page that it does not cover to the
+// pages that do. They are added to the other pages first, and then removed,
+// so that they are always listed in the index; a concurrent change of an ID
+// that is being moved is applied again by updateIndex, to the page that
+// covers it now.
+func (s *appKVStore) moveIndexEntries(botUserID, prefix, pageID string) error {
+	dir, err := s.getIndexDir(botUserID, prefix)
+	if err != nil {
+		return err
+	}
+	entries, err := s.getIndexPage(botUserID, prefix, pageID)
+	if err != nil {
+		return err
+	}
+
+	if dir == nil {
+		return nil
+	}
+
+	i := dir.indexOf(pageID)
+	moved := map[string][]appKVIndexEntry{}
+	for _, e := range entries {
+		if !dir.covers(i, e.ID) {
+			to := dir[dir.find(e.ID)].ID
+			moved[to] = append(moved[to], e)
+		}
+	}
+	for to, add := range moved {
+		add := add
+		_, err = s.updateIndexPage(botUserID, prefix, to, func(entries []appKVIndexEntry) []appKVIndexEntry {
+			return putIndexEntries(entries, add...)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.updateIndexPage(botUserID, prefix, pageID, func(entries []appKVIndexEntry) []appKVIndexEntry {
+		covered := entries[:0]
+		for _, e := range entries {
+			if dir.covers(i, e.ID) {
+				covered = append(covered, e)
+			}
+		}
+		return covered
+	})
+	return err
+}
+
+// listIndex returns up to limit of the IDs that follow after, sorted. The
+// expired IDs are skipped.
+func (s *appKVStore) listIndex(botUserID, prefix, after string, limit int) ([]string, error) {
+	ids := []string{}
+	dir, err := s.getIndexDir(botUserID, prefix)
+	if err != nil || dir == nil {
+		return ids, err
+	}
+
+	now := model.GetMillis()
+	for i := dir.find(after); i < len(dir) && len(ids) < limit; i++ {
+		entries, err := s.getIndexPage(botUserID, prefix, dir[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.ID <= after || e.expired(now) || !dir.covers(i, e.ID) {
+				continue
+			}
+			ids = append(ids, e.ID)
+			if len(ids) == limit {
+				break
+			}
+		}
+	}
+	return ids, nil
+}
+
+// deleteIndex deletes the values of the IDs in a namespace's index, and the
+// index.
+func (s *appKVStore) deleteIndex(botUserID, prefix string) error {
+	dir, err := s.getIndexDir(botUserID, prefix)
+	if err != nil {
+		return err
+	}
+	for _, page := range dir {
+		entries, err := s.getIndexPage(botUserID, prefix, page.ID)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			key, err := s.hashkey(config.KVAppPrefix, botUserID, prefix, e.ID)
+			if err != nil {
+				return err
+			}
+			err = s.mm.KV.Delete(key)
+			if err != nil {
+				return errors.Wrap(err, "failed to delete key")
+			}
+		}
+		err = s.mm.KV.Delete(appKVIndexPageKey(botUserID, prefix, page.ID))
+		if err != nil {
+			return errors.Wrap(err, "failed to delete key index")
+		}
+	}
+	return s.mm.KV.Delete(appKVIndexDirKey(botUserID, prefix))
+}
+
+// expireAt returns when a value set now expires, in Unix milliseconds.
+func expireAt(expireInSeconds int64) int64 {
+	if expireInSeconds <= 0 {
+		return 0
+	}
+	return model.GetMillis() + (time.Duration(expireInSeconds) * time.Second).Milliseconds()
+}
+
+// migratePagedIndexes moves the IDs indexed in the 16 hashed shards of each
+// namespace, as they were first indexed, to the paged index.
+func (s *appKVStore) migratePagedIndexes() error {
+	keys, err := s.listKeys(config.KVAppNamespacesPrefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		botUserID := key[len(config.KVAppNamespacesPrefix):]
+		namespaces, err := s.getNamespaces(botUserID)
+		if err != nil {
+			return err
+		}
+		for _, ns := range namespaces.Namespaces {
+			for shard := 0; shard < 16; shard++ {
+				shardKey := fmt.Sprintf("%s.%x", appKVIndexDirKey(botUserID, ns), shard)
+				ids, err := s.getSortedSet(shardKey)
+				if err != nil {
+					return err
+				}
+				for _, id := range ids {
+					_, err = s.addToIndex(botUserID, ns, appKVIndexEntry{ID: id})
+					if err != nil {
+						return err
+					}
+				}
+				err = s.mm.KV.Delete(shardKey)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+// +build !e2e
+
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestAppKVSetWithOptions(t *testing.T) {
+	botID := "01234567890123456789012345"
+	key := hashkey([]byte(config.KVAppPrefix), []byte(botID), []byte("  "), []byte("id"))
+	value := []byte(`{"a":1}`)
+
+	for name, tc := range map[string]struct {
+		opts          apps.KVSetOptions
+		expectedOpts  model.PluginKVSetOptions
+		expectedError string
+	}{
+		"expiry": {
+			opts:         apps.KVSetOptions{ExpireInSeconds: 60},
+			expectedOpts: model.PluginKVSetOptions{ExpireInSeconds: 60},
+		},
+		"if absent": {
+			opts:         apps.KVSetOptions{IfAbsent: true},
+			expectedOpts: model.PluginKVSetOptions{Atomic: true},
+		},
+		"compare and set": {
+			opts:         apps.KVSetOptions{OldValue: map[string]int{"a": 0}, ExpireInSeconds: 5},
+			expectedOpts: model.PluginKVSetOptions{Atomic: true, OldValue: []byte(`{"a":0}`), ExpireInSeconds: 5},
+		},
+		"invalid": {
+			opts:          apps.KVSetOptions{IfAbsent: true, OldValue: "x"},
+			expectedError: "only one of if_absent and old_value may be set: invalid input",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockAPI := &plugintest.API{}
+			apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+			conf := config.NewService(apiClient, utils.NewTestLogger(), config.BuildConfig{}, "bot-id")
+			s := NewService(apiClient, utils.NewTestLogger(), conf, nil, "", nil)
+
+			if tc.expectedError == "" {
+				mockAPI.On("KVSetWithOptions", key, value, tc.expectedOpts).Return(true, nil).Once()
+			}
+			kv := memKV(mockAPI)
+			kv[key] = value
+			changed, err := s.AppKV.SetWithOptions(botID, "", "id", value, tc.opts)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, changed)
+			mockAPI.AssertCalled(t, "KVSetWithOptions", key, value, tc.expectedOpts)
+		})
+	}
+}
+
+// memKV makes the mock API behave as an in-memory KV store, with the
+// compare-and-set semantics of the server.
+func memKV(mockAPI *plugintest.API) map[string][]byte {
+	kv := map[string][]byte{}
+	mockAPI.On("KVGet", mock.Anything).Return(
+		func(key string) []byte { return kv[key] },
+		func(string) *model.AppError { return nil })
+	mockAPI.On("KVSetWithOptions", mock.Anything, mock.Anything, mock.Anything).Return(
+		func(key string, value []byte, opts model.PluginKVSetOptions) bool {
+			if opts.Atomic && !bytes.Equal(kv[key], opts.OldValue) {
+				return false
+			}
+			if value == nil {
+				delete(kv, key)
+			} else {
+				kv[key] = value
+			}
+			return true
+		},
+		func(string, []byte, model.PluginKVSetOptions) *model.AppError { return nil })
+	mockAPI.On("KVList", mock.Anything, mock.Anything).Return(
+		func(page, perPage int) []string {
+			var keys []string
+			for k := range kv {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			start := page * perPage
+			if start >= len(keys) {
+				return nil
+			}
+			end := start + perPage
+			if end > len(keys) {
+				end = len(keys)
+			}
+			return keys[start:end]
+		},
+		func(int, int) *model.AppError { return nil })
+	return kv
+}
+
+func TestAppKVIndex(t *testing.T) {
+	botID := "01234567890123456789012345"
+	otherBotID := "99999999999999999999999999"
+
+	setup := func() (*Service, map[string][]byte) {
+		mockAPI := &plugintest.API{}
+		kv := memKV(mockAPI)
+		apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+		return NewService(apiClient, utils.NewTestLogger(), config.NewTestConfigurator(config.Config{}), nil, "", nil), kv
+	}
+
+	t.Run("list and batch", func(t *testing.T) {
+		s, _ := setup()
+		require.NoError(t, s.AppKV.InitIndex(botID))
+
+		values := map[string]interface{}{}
+		for i := 0; i < 30; i++ {
+			values[fmt.Sprintf("id%02d", i)] = i
+		}
+		require.NoError(t, s.AppKV.SetBatch(botID, "ns", values))
+		_, err := s.AppKV.Set(botID, "", "other", "x")
+		require.NoError(t, err)
+
+		list, err := s.AppKV.ListKeys(botID, "ns", "id09", 10)
+		require.NoError(t, err)
+		require.Equal(t, &apps.KVListResponse{
+			IDs: []string{"id10", "id11", "id12", "id13", "id14", "id15", "id16", "id17", "id18", "id19"},
+		}, list)
+		list, err = s.AppKV.ListKeys(botID, "ns", "id29", 10)
+		require.NoError(t, err)
+		require.Empty(t, list.IDs)
+		list, err = s.AppKV.ListKeys(botID, "", "", 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"other"}, list.IDs)
+
+		got, err := s.AppKV.GetBatch(botID, "ns", []string{"id01", "id29", "missing"})
+		require.NoError(t, err)
+		require.Equal(t, map[string]json.RawMessage{
+			"id01": json.RawMessage("1"),
+			"id29": json.RawMessage("29"),
+		}, got)
+
+		require.NoError(t, s.AppKV.Delete(botID, "ns", "id10"))
+		list, err = s.AppKV.ListKeys(botID, "ns", "id08", 3)
+		require.NoError(t, err)
+		require.Equal(t, []string{"id09", "id11", "id12"}, list.IDs)
+	})
+
+	t.Run("many pages", func(t *testing.T) {
+		s, kv := setup()
+		require.NoError(t, s.AppKV.InitIndex(botID))
+
+		n := 3*appKVIndexPageSize + 10
+		var expected []string
+		for i := 0; i < n; i++ {
+			// Not in order, so that the pages in the middle are split.
+			id := fmt.Sprintf("id%04d", (i*7)%n)
+			_, err := s.AppKV.Set(botID, "ns", id, i)
+			require.NoError(t, err)
+		}
+		for i := 0; i < n; i++ {
+			expected = append(expected, fmt.Sprintf("id%04d", i))
+		}
+
+		dir, err := (&appKVStore{Service: s}).getIndexDir(botID, "ns")
+		require.NoError(t, err)
+		require.Greater(t, len(dir), 3)
+		for _, page := range dir {
+			entries, err := (&appKVStore{Service: s}).getIndexPage(botID, "ns", page.ID)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(entries), appKVIndexPageSize)
+		}
+
+		var listed []string
+		after := ""
+		for {
+			list, err := s.AppKV.ListKeys(botID, "ns", after, 100)
+			require.NoError(t, err)
+			if len(list.IDs) == 0 {
+				break
+			}
+			listed = append(listed, list.IDs...)
+			after = list.IDs[len(list.IDs)-1]
+		}
+		require.Equal(t, expected, listed)
+
+		// Deleting all but the first ID drops the empty pages.
+		for _, id := range expected[1:] {
+			require.NoError(t, s.AppKV.Delete(botID, "ns", id))
+		}
+		list, err := s.AppKV.ListKeys(botID, "ns", "", 100)
+		require.NoError(t, err)
+		require.Equal(t, []string{"id0000"}, list.IDs)
+		dir, err = (&appKVStore{Service: s}).getIndexDir(botID, "ns")
+		require.NoError(t, err)
+		require.Len(t, dir, 1)
+
+		require.NoError(t, s.AppKV.DeleteAll(botID))
+		require.Empty(t, kv)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		s, kv := setup()
+		require.NoError(t, s.AppKV.InitIndex(botID))
+		_, err := s.AppKV.SetWithOptions(botID, "", "expiring", 1, apps.KVSetOptions{ExpireInSeconds: 60})
+		require.NoError(t, err)
+		_, err = s.AppKV.Set(botID, "", "kept", 2)
+		require.NoError(t, err)
+
+		list, err := s.AppKV.ListKeys(botID, "", "", 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"expiring", "kept"}, list.IDs)
+
+		// Expire the entry.
+		dirKey := appKVIndexDirKey(botID, "")
+		var dir appKVIndexDir
+		require.NoError(t, json.Unmarshal(kv[dirKey], &dir))
+		pageKey := appKVIndexPageKey(botID, "", dir[0].ID)
+		kv[pageKey] = []byte(`[{"i":"expiring","x":1},{"i":"kept"}]`)
+
+		list, err = s.AppKV.ListKeys(botID, "", "", 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"kept"}, list.IDs)
+
+		// The next change of the page drops it.
+		_, err = s.AppKV.Set(botID, "", "new", 3)
+		require.NoError(t, err)
+		require.Equal(t, `[{"i":"kept"},{"i":"new"}]`, string(kv[pageKey]))
+	})
+
+	t.Run("incomplete", func(t *testing.T) {
+		s, _ := setup()
+		// Installed before the index existed.
+		_, err := s.AppKV.Set(botID, "", "id1", 1)
+		require.NoError(t, err)
+
+		list, err := s.AppKV.ListKeys(botID, "", "", 10)
+		require.NoError(t, err)
+		require.Equal(t, &apps.KVListResponse{IDs: []string{"id1"}, Incomplete: true}, list)
+	})
+
+	t.Run("migrate sharded indexes", func(t *testing.T) {
+		s, kv := setup()
+		kv[config.KVAppNamespacesPrefix+botID] = []byte(`{"complete":true,"namespaces":["ns"]}`)
+		kv[appKVIndexDirKey(botID, "ns")+".3"] = []byte(`["b","d"]`)
+		kv[appKVIndexDirKey(botID, "ns")+".f"] = []byte(`["a","c"]`)
+
+		require.NoError(t, (&appKVStore{Service: s}).migratePagedIndexes())
+		require.NotContains(t, kv, appKVIndexDirKey(botID, "ns")+".3")
+		require.NotContains(t, kv, appKVIndexDirKey(botID, "ns")+".f")
+		list, err := s.AppKV.ListKeys(botID, "ns", "", 10)
+		require.NoError(t, err)
+		require.Equal(t, &apps.KVListResponse{IDs: []string{"a", "b", "c", "d"}}, list)
+	})
+
+	t.Run("delete all indexed", func(t *testing.T) {
+		s, kv := setup()
+		require.NoError(t, s.AppKV.InitIndex(botID))
+		_, err := s.AppKV.Set(botID, "ns", "id1", 1)
+		require.NoError(t, err)
+		_, err = s.AppKV.Set(botID, "", "id2", 2)
+		require.NoError(t, err)
+		_, err = s.AppKV.Set(otherBotID, "", "id1", 1)
+		require.NoError(t, err)
+
+		require.NoError(t, s.AppKV.DeleteAll(botID))
+		for k := range kv {
+			require.NotContains(t, k, botID)
+		}
+		var v int
+		require.NoError(t, s.AppKV.Get(otherBotID, "", "id1", &v))
+		require.Equal(t, 1, v)
+	})
+
+	t.Run("delete all unindexed", func(t *testing.T) {
+		s, kv := setup()
+		// Set before the index existed.
+		legacyKey, err := s.hashkey(config.KVAppPrefix, botID, "", "legacy")
+		require.NoError(t, err)
+		kv[legacyKey] = []byte("1")
+		_, err = s.AppKV.Set(botID, "", "id1", 1)
+		require.NoError(t, err)
+
+		require.NoError(t, s.AppKV.DeleteAll(botID))
+		require.Empty(t, kv)
+	})
+}
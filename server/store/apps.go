@@ -8,8 +8,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
 
+	"github.com/pkg/errors"
+
 	"github.com/mattermost/mattermost-plugin-apps/apps"
 	"github.com/mattermost/mattermost-plugin-apps/server/config"
 	"github.com/mattermost/mattermost-plugin-apps/utils"
@@ -23,6 +26,11 @@ type AppStore interface {
 	Get(appID apps.AppID) (*apps.App, error)
 	InitBuiltin(...*apps.App)
 	Save(app *apps.App) error
+
+	// ReencryptSecrets saves the installed apps again, with their secrets
+	// encrypted with the current encryption key. It returns how many apps
+	// were saved.
+	ReencryptSecrets() (int, error)
 }
 
 // appStore combines installed and builtin Apps.  The installed Apps are stored
@@ -36,6 +44,10 @@ type appStore struct {
 
 	installed        map[apps.AppID]*apps.App
 	builtinInstalled map[apps.AppID]*apps.App
+
+	// unusable are the installed apps whose secrets failed to decrypt, e.g.
+	// because the key they were encrypted with is no longer configured.
+	unusable map[apps.AppID]error
 }
 
 var _ AppStore = (*appStore)(nil)
@@ -53,6 +65,7 @@ func (s *appStore) InitBuiltin(builtinApps ...*apps.App) {
 
 func (s *appStore) Configure(conf config.Config) {
 	newInstalled := map[apps.AppID]*apps.App{}
+	newUnusable := map[apps.AppID]error{}
 
 	for id, key := range conf.InstalledApps {
 		var app *apps.App
@@ -68,12 +81,20 @@ func (s *appStore) Configure(conf config.Config) {
 				"key", config.KVInstalledAppPrefix+key)
 
 		default:
+			err = s.decryptSecrets(app)
+			if err != nil {
+				s.log.WithError(err).Errorw("Failed to decrypt app secrets, the app can not be used until the key it was encrypted with is configured",
+					"app_id", id)
+				newUnusable[apps.AppID(id)] = err
+				continue
+			}
 			newInstalled[apps.AppID(id)] = app
 		}
 	}
 
 	s.mutex.Lock()
 	s.installed = newInstalled
+	s.unusable = newUnusable
 	s.mutex.Unlock()
 }
 
@@ -81,6 +102,7 @@ func (s *appStore) Get(appID apps.AppID) (*apps.App, error) {
 	s.mutex.RLock()
 	installed := s.installed
 	builtin := s.builtinInstalled
+	unusable := s.unusable
 	s.mutex.RUnlock()
 
 	app, ok := builtin[appID]
@@ -91,6 +113,9 @@ func (s *appStore) Get(appID apps.AppID) (*apps.App, error) {
 	if ok {
 		return app, nil
 	}
+	if err := unusable[appID]; err != nil {
+		return nil, errors.Wrapf(err, "app %s can not be used, failed to decrypt its secrets", appID)
+	}
 	return nil, utils.ErrNotFound
 }
 
@@ -135,13 +160,18 @@ func (s *appStore) Save(app *apps.App) error {
 		// no change in the data
 		return nil
 	}
-	_, err = s.mm.KV.Set(config.KVInstalledAppPrefix+sha, app)
+	encrypted, err := s.encryptSecrets(app)
+	if err != nil {
+		return errors.Wrap(err, "failed to encrypt app secrets")
+	}
+	_, err = s.mm.KV.Set(config.KVInstalledAppPrefix+sha, encrypted)
 	if err != nil {
 		return err
 	}
 
 	s.mutex.RLock()
 	installed := s.installed
+	unusable := s.unusable
 	s.mutex.RUnlock()
 	updatedInstalled := map[apps.AppID]*apps.App{}
 	for k, v := range installed {
@@ -150,8 +180,15 @@ func (s *appStore) Save(app *apps.App) error {
 		}
 	}
 	updatedInstalled[app.AppID] = app
+	updatedUnusable := map[apps.AppID]error{}
+	for k, v := range unusable {
+		if k != app.AppID {
+			updatedUnusable[k] = v
+		}
+	}
 	s.mutex.Lock()
 	s.installed = updatedInstalled
+	s.unusable = updatedUnusable
 	s.mutex.Unlock()
 
 	sc := conf.StoredConfig
@@ -215,3 +252,79 @@ func (s *appStore) Delete(appID apps.AppID) error {
 	sc.InstalledApps = updated
 	return s.conf.StoreConfig(sc)
 }
+
+func (s *appStore) ReencryptSecrets() (int, error) {
+	s.mutex.RLock()
+	installed := s.installed
+	unusable := s.unusable
+	s.mutex.RUnlock()
+	conf := s.conf.GetConfig()
+
+	// Removing the previous key would make the apps that failed to decrypt
+	// unrecoverable.
+	if len(unusable) > 0 {
+		var appIDs []string
+		for appID := range unusable {
+			appIDs = append(appIDs, string(appID))
+		}
+		sort.Strings(appIDs)
+		return 0, errors.Errorf("failed to decrypt secrets of apps: %s", strings.Join(appIDs, ", "))
+	}
+
+	n := 0
+	for appID, app := range installed {
+		sha := conf.InstalledApps[string(appID)]
+		if sha == "" {
+			continue
+		}
+		encrypted, err := s.encryptSecrets(app)
+		if err != nil {
+			return n, errors.Wrapf(err, "failed to encrypt secrets of app %s", appID)
+		}
+		// The key is the sha of the unencrypted app, so it does not change.
+		_, err = s.mm.KV.Set(config.KVInstalledAppPrefix+sha, encrypted)
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// appSecrets returns pointers to the app's fields that are encrypted at rest.
+func appSecrets(app *apps.App) []*string {
+	return []*string{
+		&app.Secret,
+		&app.WebhookSecret,
+		&app.BotAccessToken,
+		&app.MattermostOAuth2.ClientSecret,
+		&app.RemoteOAuth2.ClientSecret,
+		&app.PreviousSecret.Secret,
+		&app.PreviousWebhookSecret.Secret,
+		&app.PreviousBotAccessToken.Secret,
+	}
+}
+
+// encryptSecrets returns a copy of the app, with its secrets encrypted.
+func (s *appStore) encryptSecrets(app *apps.App) (*apps.App, error) {
+	encrypted := *app
+	for _, secret := range appSecrets(&encrypted) {
+		var err error
+		*secret, err = s.encryptString(*secret)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &encrypted, nil
+}
+
+func (s *appStore) decryptSecrets(app *apps.App) error {
+	for _, secret := range appSecrets(app) {
+		var err error
+		*secret, err = s.decryptString(*secret)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
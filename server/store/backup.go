@@ -0,0 +1,195 @@
+package store
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+// KVRecord is a raw KV record, as it is exported in a backup. ExpireAt is
+// when an app's value expires, in Unix milliseconds, 0 if it does not.
+type KVRecord struct {
+	Key      string `json:"key"`
+	Value    []byte `json:"value"`
+	ExpireAt int64  `json:"expire_at,omitempty"`
+}
+
+type BackupStore interface {
+	// ExportKV returns the raw KV records that are kept for an app: its
+	// values, its per-user values, its users' OAuth2 records, and their
+	// indexes. The OAuth2 records are exported decrypted, so that they can be
+	// imported with a different encryption key.
+	ExportKV(botUserID string) ([]KVRecord, error)
+	// ImportKV replaces the app's KV records with the ones exported for
+	// another bot user, with the keys remapped to botUserID. The OAuth2
+	// records are encrypted with the current encryption key. The records are
+	// set before the app's other records are deleted, and the values that
+	// expired since they were exported are skipped.
+	ImportKV(fromBotUserID, botUserID string, records []KVRecord) error
+}
+
+type backupStore struct {
+	*Service
+}
+
+var _ BackupStore = (*backupStore)(nil)
+
+// appKeyPrefixes returns the prefixes of the keys that are kept for an app.
+// They all end with the bot user ID, so the keys can be remapped to another
+// bot.
+func appKeyPrefixes(botUserID string) []string {
+	return []string{
+		config.KVAppPrefix + botUserID,
+		config.KVUserPrefix + botUserID,
+		config.KVUserKVPrefix + botUserID,
+		config.KVUserKVIndexPrefix + botUserID,
+		config.KVAppKeyIndexPrefix + botUserID,
+		config.KVAppNamespacesPrefix + botUserID,
+		config.KVUserKVUsersPrefix + botUserID,
+		config.KVAppUsagePrefix + botUserID,
+	}
+}
+
+func (s *backupStore) ExportKV(botUserID string) ([]KVRecord, error) {
+	keys, err := s.listKeys(appKeyPrefixes(botUserID)...)
+	if err != nil {
+		return nil, err
+	}
+	expiries, err := s.appKVExpiries(botUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]KVRecord, 0, len(keys))
+	for _, key := range keys {
+		var value []byte
+		err = s.mm.KV.Get(key, &value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get key %s", key)
+		}
+		if value == nil {
+			// Expired, or deleted since listed.
+			continue
+		}
+		if strings.HasPrefix(key, config.KVUserPrefix+botUserID) {
+			value, err = s.decrypt(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to decrypt key %s", key)
+			}
+		}
+		records = append(records, KVRecord{
+			Key:      key,
+			Value:    value,
+			ExpireAt: expiries[key],
+		})
+	}
+	return records, nil
+}
+
+func (s *backupStore) ImportKV(fromBotUserID, botUserID string, records []KVRecord) error {
+	fromPrefixes := appKeyPrefixes(fromBotUserID)
+	toPrefixes := appKeyPrefixes(botUserID)
+	imported := map[string]KVRecord{}
+	for _, r := range records {
+		value := r.Value
+		key := ""
+		for i, prefix := range fromPrefixes {
+			if strings.HasPrefix(r.Key, prefix) {
+				key = toPrefixes[i] + strings.TrimPrefix(r.Key, prefix)
+				break
+			}
+		}
+		if key == "" {
+			return utils.NewInvalidError("key %q does not belong to bot %s", r.Key, fromBotUserID)
+		}
+		if strings.HasPrefix(key, config.KVUserPrefix+botUserID) {
+			var err error
+			value, err = s.encrypt(value)
+			if err != nil {
+				return errors.Wrapf(err, "failed to encrypt key %s", key)
+			}
+		}
+		imported[key] = KVRecord{
+			Key:      key,
+			Value:    value,
+			ExpireAt: r.ExpireAt,
+		}
+	}
+
+	existing, err := s.listKeys(toPrefixes...)
+	if err != nil {
+		return err
+	}
+
+	now := model.GetMillis()
+	for key, r := range imported {
+		var options []pluginapi.KVSetOption
+		if r.ExpireAt != 0 {
+			if r.ExpireAt <= now {
+				delete(imported, key)
+				continue
+			}
+			// The expiry is set in whole seconds.
+			seconds := (r.ExpireAt - now + 999) / 1000
+			options = append(options, pluginapi.SetExpiry(time.Duration(seconds)*time.Second))
+		}
+		_, err = s.mm.KV.Set(key, r.Value, options...)
+		if err != nil {
+			return errors.Wrapf(err, "failed to set key %s", key)
+		}
+	}
+
+	for _, key := range existing {
+		if _, ok := imported[key]; ok {
+			continue
+		}
+		err = s.mm.KV.Delete(key)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete key %s", key)
+		}
+	}
+
+	// The usage is exported with the values, but it may not match them.
+	return s.recountKVUsage(botUserID)
+}
+
+// appKVExpiries returns when the app's expiring values expire, by their keys,
+// as recorded in the app's KV index.
+func (s *backupStore) appKVExpiries(botUserID string) (map[string]int64, error) {
+	appKV := &appKVStore{Service: s.Service}
+	namespaces, err := appKV.getNamespaces(botUserID)
+	if err != nil {
+		return nil, err
+	}
+	expiries := map[string]int64{}
+	for _, ns := range namespaces.Namespaces {
+		dir, err := appKV.getIndexDir(botUserID, ns)
+		if err != nil {
+			return nil, err
+		}
+		for _, page := range dir {
+			entries, err := appKV.getIndexPage(botUserID, ns, page.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range entries {
+				if e.ExpireAt == 0 {
+					continue
+				}
+				key, err := s.hashkey(config.KVAppPrefix, botUserID, ns, e.ID)
+				if err != nil {
+					return nil, err
+				}
+				expiries[key] = e.ExpireAt
+			}
+		}
+	}
+	return expiries, nil
+}
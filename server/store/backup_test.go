@@ -0,0 +1,129 @@
+// +build !e2e
+
+package store
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestBackupKV(t *testing.T) {
+	fromBotID := "frombotfrombotfrombotfromb"
+	toBotID := "tobottobottobottobottobott"
+	otherBotID := "99999999999999999999999999"
+	userID := "user1user1user1user1user1u"
+
+	mockAPI := &plugintest.API{}
+	kv := memKV(mockAPI)
+	apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+	s := NewService(apiClient, utils.NewTestLogger(), config.NewTestConfigurator(config.Config{}), nil, "", nil)
+
+	require.NoError(t, s.AppKV.InitIndex(fromBotID))
+	_, err := s.AppKV.Set(fromBotID, "ns", "id1", "value1")
+	require.NoError(t, err)
+	_, err = s.AppKV.SetWithOptions(fromBotID, "ns", "ttl", "expiring", apps.KVSetOptions{ExpireInSeconds: 60})
+	require.NoError(t, err)
+	require.NoError(t, s.UserKV.Set(fromBotID, userID, "id2", "value2"))
+	require.NoError(t, s.OAuth2.SaveUser(fromBotID, userID, "token"))
+	_, err = s.AppKV.Set(otherBotID, "", "id1", "other")
+	require.NoError(t, err)
+
+	// The target app has some data of its own.
+	_, err = s.AppKV.Set(toBotID, "", "stale", "stale")
+	require.NoError(t, err)
+
+	records, err := s.Backup.ExportKV(fromBotID)
+	require.NoError(t, err)
+	ttlKey, err := s.hashkey(config.KVAppPrefix, fromBotID, "ns", "ttl")
+	require.NoError(t, err)
+	for _, r := range records {
+		require.Contains(t, r.Key, fromBotID)
+		if r.Key == ttlKey {
+			require.NotZero(t, r.ExpireAt)
+		} else {
+			require.Zero(t, r.ExpireAt)
+		}
+	}
+
+	require.NoError(t, s.Backup.ImportKV(fromBotID, toBotID, records))
+
+	var v string
+	require.NoError(t, s.AppKV.Get(toBotID, "ns", "id1", &v))
+	require.Equal(t, "value1", v)
+	require.NoError(t, s.UserKV.Get(toBotID, userID, "id2", &v))
+	require.Equal(t, "value2", v)
+	require.NoError(t, s.OAuth2.GetUser(toBotID, userID, &v))
+	require.Equal(t, "token", v)
+	list, err := s.AppKV.ListKeys(toBotID, "ns", "", 10)
+	require.NoError(t, err)
+	require.Equal(t, []string{"id1", "ttl"}, list.IDs)
+	usage, err := s.AppKV.GetUsage(toBotID)
+	require.NoError(t, err)
+	require.Equal(t, 3, usage.Keys)
+
+	// The expiring value keeps its expiry.
+	toTTLKey, err := s.hashkey(config.KVAppPrefix, toBotID, "ns", "ttl")
+	require.NoError(t, err)
+	mockAPI.AssertCalled(t, "KVSetWithOptions", toTTLKey, mock.Anything, mock.MatchedBy(func(opts model.PluginKVSetOptions) bool {
+		return opts.ExpireInSeconds > 0 && opts.ExpireInSeconds <= 60
+	}))
+
+	v = ""
+	require.NoError(t, s.AppKV.Get(toBotID, "", "stale", &v))
+	require.Empty(t, v)
+
+	// The source data is untouched.
+	fromKeys := 0
+	for k := range kv {
+		if strings.Contains(k, fromBotID) {
+			fromKeys++
+		}
+	}
+	require.Equal(t, len(records), fromKeys)
+
+	t.Run("expired", func(t *testing.T) {
+		expired := []KVRecord{}
+		for _, r := range records {
+			if r.Key == ttlKey {
+				r.ExpireAt = 1
+			}
+			expired = append(expired, r)
+		}
+		require.NoError(t, s.Backup.ImportKV(fromBotID, toBotID, expired))
+		require.NotContains(t, kv, toTTLKey)
+	})
+
+	t.Run("failed import keeps the data", func(t *testing.T) {
+		mockAPI := &plugintest.API{}
+		mockAPI.On("KVSetWithOptions", mock.MatchedBy(func(key string) bool {
+			return strings.HasPrefix(key, config.KVUserKVPrefix+toBotID)
+		}), mock.Anything, mock.Anything).Return(false, model.NewAppError("KVSet", "", nil, "test error", http.StatusInternalServerError))
+		kv := memKV(mockAPI)
+		apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+		s := NewService(apiClient, utils.NewTestLogger(), config.NewTestConfigurator(config.Config{}), nil, "", nil)
+		_, err := s.AppKV.Set(toBotID, "", "kept", "kept")
+		require.NoError(t, err)
+		keptKey, err := s.hashkey(config.KVAppPrefix, toBotID, "", "kept")
+		require.NoError(t, err)
+
+		require.Error(t, s.Backup.ImportKV(fromBotID, toBotID, records))
+		require.Contains(t, kv, keptKey)
+	})
+
+	t.Run("foreign key", func(t *testing.T) {
+		err := s.Backup.ImportKV(fromBotID, toBotID, []KVRecord{{Key: "app.something", Value: []byte("1")}})
+		require.Error(t, err)
+	})
+}
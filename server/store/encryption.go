@@ -0,0 +1,216 @@
+package store
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+)
+
+// encryptedPrefix marks the encrypted values, the unencrypted ones are read
+// as they are.
+const encryptedPrefix = "enc1:"
+
+// envelope is an encrypted value. The data is encrypted with a random data
+// key, which is encrypted with the encryption key that KeyID identifies, so
+// that changing the encryption key only needs the data keys re-encrypted.
+type envelope struct {
+	KeyID   string `json:"kid"`
+	DataKey []byte `json:"key"`
+	Data    []byte `json:"data"`
+}
+
+// encryptionKeyIDLabel is what the key's ID is an HMAC of, keyed with the
+// key.
+const encryptionKeyIDLabel = "mattermost-plugin-apps key id"
+
+// encryptionKey is a configured AES-256 key, and its ID, stored with the
+// values that it encrypts.
+type encryptionKey struct {
+	id  string
+	key []byte
+}
+
+func newEncryptionKey(configured string) (*encryptionKey, error) {
+	key, err := config.DecodeEncryptionKey(configured)
+	if err != nil || key == nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(encryptionKeyIDLabel))
+	return &encryptionKey{
+		id:  hex.EncodeToString(mac.Sum(nil)[:8]),
+		key: key,
+	}, nil
+}
+
+func (s *Service) encryptionKeys() (current, previous *encryptionKey, err error) {
+	keys := s.conf.GetConfig().EncryptionKeys
+	current, err = newEncryptionKey(keys.Current)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid encryption key")
+	}
+	previous, err = newEncryptionKey(keys.Previous)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid previous encryption key")
+	}
+	return current, previous, nil
+}
+
+// encrypt encrypts data with the current encryption key. If there is none,
+// data is returned as is.
+func (s *Service) encrypt(data []byte) ([]byte, error) {
+	key, _, err := s.encryptionKeys()
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || data == nil {
+		return data, nil
+	}
+
+	dataKey := make([]byte, 32)
+	_, err = io.ReadFull(rand.Reader, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	encryptedKey, err := seal(key.key, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	encryptedData, err := seal(dataKey, data)
+	if err != nil {
+		return nil, err
+	}
+	env, err := json.Marshal(envelope{
+		KeyID:   key.id,
+		DataKey: encryptedKey,
+		Data:    encryptedData,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(encryptedPrefix + base64.RawStdEncoding.EncodeToString(env)), nil
+}
+
+// decrypt decrypts data with the key that it was encrypted with. Unencrypted
+// data is returned as is.
+func (s *Service) decrypt(data []byte) ([]byte, error) {
+	env, err := parseEnvelope(data)
+	if err != nil || env == nil {
+		return data, err
+	}
+
+	current, previous, err := s.encryptionKeys()
+	if err != nil {
+		return nil, err
+	}
+	var key *encryptionKey
+	for _, k := range []*encryptionKey{current, previous} {
+		if k != nil && k.id == env.KeyID {
+			key = k
+		}
+	}
+	if key == nil {
+		return nil, errors.Errorf("value is encrypted with an unknown key %s", env.KeyID)
+	}
+
+	dataKey, err := open(key.key, env.DataKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt data key")
+	}
+	decrypted, err := open(dataKey, env.Data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt")
+	}
+	return decrypted, nil
+}
+
+// isEncryptedWithCurrentKey returns true if data does not need to be
+// re-encrypted, i.e. it is encrypted with the current key, or it is not
+// encrypted, and there is no key.
+func (s *Service) isEncryptedWithCurrentKey(data []byte) bool {
+	env, err := parseEnvelope(data)
+	if err != nil {
+		return false
+	}
+	current, _, err := s.encryptionKeys()
+	if err != nil {
+		return false
+	}
+	if current == nil {
+		return env == nil
+	}
+	return env != nil && env.KeyID == current.id
+}
+
+func (s *Service) encryptString(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	encrypted, err := s.encrypt([]byte(v))
+	return string(encrypted), err
+}
+
+func (s *Service) decryptString(v string) (string, error) {
+	decrypted, err := s.decrypt([]byte(v))
+	return string(decrypted), err
+}
+
+func parseEnvelope(data []byte) (*envelope, error) {
+	if !bytes.HasPrefix(data, []byte(encryptedPrefix)) {
+		return nil, nil
+	}
+	decoded, err := base64.RawStdEncoding.DecodeString(string(data[len(encryptedPrefix):]))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid encrypted value")
+	}
+	env := &envelope{}
+	err = json.Unmarshal(decoded, env)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid encrypted value")
+	}
+	return env, nil
+}
+
+// seal encrypts data with AES-GCM, the nonce is prepended to the result.
+func seal(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+func open(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("encrypted data is too short")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,209 @@
+// +build !e2e
+
+package store
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+// Test keys, 32 bytes base64-encoded.
+const (
+	testKey1 = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	testKey2 = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+func setEncryptionKeys(s *Service, current, previous string) {
+	conf := s.conf.GetConfig()
+	conf.EncryptionKeys = config.EncryptionKeys{
+		Current:  current,
+		Previous: previous,
+	}
+	s.conf = config.NewTestConfigurator(conf)
+}
+
+func TestEncryption(t *testing.T) {
+	s := &Service{conf: config.NewTestConfigurator(config.Config{})}
+	data := []byte("some secret")
+
+	t.Run("no key", func(t *testing.T) {
+		encrypted, err := s.encrypt(data)
+		require.NoError(t, err)
+		require.Equal(t, data, encrypted)
+		require.True(t, s.isEncryptedWithCurrentKey(encrypted))
+	})
+
+	setEncryptionKeys(s, testKey1, "")
+	encrypted, err := s.encrypt(data)
+	require.NoError(t, err)
+
+	t.Run("round trip", func(t *testing.T) {
+		require.True(t, strings.HasPrefix(string(encrypted), encryptedPrefix))
+		require.NotContains(t, string(encrypted), string(data))
+		require.True(t, s.isEncryptedWithCurrentKey(encrypted))
+
+		decrypted, err := s.decrypt(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, data, decrypted)
+
+		again, err := s.encrypt(data)
+		require.NoError(t, err)
+		require.NotEqual(t, encrypted, again)
+	})
+
+	t.Run("unencrypted", func(t *testing.T) {
+		decrypted, err := s.decrypt(data)
+		require.NoError(t, err)
+		require.Equal(t, data, decrypted)
+		require.False(t, s.isEncryptedWithCurrentKey(data))
+	})
+
+	t.Run("previous key", func(t *testing.T) {
+		setEncryptionKeys(s, testKey2, testKey1)
+		decrypted, err := s.decrypt(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, data, decrypted)
+		require.False(t, s.isEncryptedWithCurrentKey(encrypted))
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		setEncryptionKeys(s, testKey2, "")
+		_, err := s.decrypt(encrypted)
+		require.Error(t, err)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		setEncryptionKeys(s, testKey1, "")
+		env, err := parseEnvelope(encrypted)
+		require.NoError(t, err)
+		env.Data[len(env.Data)-1] ^= 1
+		key, err := newEncryptionKey(testKey1)
+		require.NoError(t, err)
+		_, err = open(key.key, env.Data)
+		require.Error(t, err)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		for _, key := range []string{"key1", "a2V5MQ==", testKey1[:20]} {
+			setEncryptionKeys(s, key, "")
+			_, err := s.encrypt(data)
+			require.Error(t, err)
+
+			setEncryptionKeys(s, testKey2, key)
+			_, err = s.decrypt(encrypted)
+			require.Error(t, err)
+		}
+	})
+}
+
+func TestReencryptUsers(t *testing.T) {
+	botID := "botbotbotbotbotbotbotbotbo"
+	userID := "user1user1user1user1user1u"
+
+	mockAPI := &plugintest.API{}
+	kv := memKV(mockAPI)
+	apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+	s := NewService(apiClient, utils.NewTestLogger(), config.NewTestConfigurator(config.Config{}), nil, "", nil)
+	key, err := s.hashkey(config.KVUserPrefix, botID, "", userID)
+	require.NoError(t, err)
+
+	getUser := func() string {
+		var token string
+		require.NoError(t, s.OAuth2.GetUser(botID, userID, &token))
+		return token
+	}
+
+	// Saved before a key is configured.
+	require.NoError(t, s.OAuth2.SaveUser(botID, userID, "token"))
+	require.Equal(t, `"token"`, string(kv[key]))
+
+	setEncryptionKeys(s, testKey1, "")
+	require.Equal(t, "token", getUser())
+	n, err := s.OAuth2.ReencryptUsers()
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.True(t, strings.HasPrefix(string(kv[key]), encryptedPrefix))
+	require.Equal(t, "token", getUser())
+
+	n, err = s.OAuth2.ReencryptUsers()
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+
+	setEncryptionKeys(s, testKey2, testKey1)
+	require.Equal(t, "token", getUser())
+	n, err = s.OAuth2.ReencryptUsers()
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	setEncryptionKeys(s, testKey2, "")
+	require.Equal(t, "token", getUser())
+}
+
+func TestAppSecretsEncryption(t *testing.T) {
+	mockAPI := &plugintest.API{}
+	kv := memKV(mockAPI)
+	apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+	s := NewService(apiClient, utils.NewTestLogger(), config.NewTestConfigurator(config.Config{}), nil, "", nil)
+	setEncryptionKeys(s, testKey1, "")
+
+	app := &apps.App{
+		Manifest: apps.Manifest{
+			AppID: "app1",
+		},
+		Secret:           "secret",
+		WebhookSecret:    "webhook-secret",
+		BotAccessToken:   "bot-token",
+		MattermostOAuth2: apps.OAuth2App{ClientID: "id1", ClientSecret: "client-secret-1"},
+		RemoteOAuth2:     apps.OAuth2App{ClientID: "id2", ClientSecret: "client-secret-2"},
+		PreviousSecret:   apps.RotatedSecret{Secret: "previous-secret", ExpiresAt: 1000},
+	}
+	require.NoError(t, s.App.Save(app))
+
+	sha := s.conf.GetConfig().InstalledApps["app1"]
+	stored := string(kv[config.KVInstalledAppPrefix+sha])
+	require.Contains(t, stored, "id1")
+	for _, secret := range []string{"secret", "webhook-secret", "bot-token", "client-secret-1", "client-secret-2", "previous-secret"} {
+		require.NotContains(t, stored, `:"`+secret+`"`)
+	}
+
+	s.App.Configure(s.conf.GetConfig())
+	loaded, err := s.App.Get("app1")
+	require.NoError(t, err)
+	require.Equal(t, app, loaded)
+
+	setEncryptionKeys(s, testKey2, testKey1)
+	n, err := s.App.ReencryptSecrets()
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	setEncryptionKeys(s, testKey2, "")
+	s.App.Configure(s.conf.GetConfig())
+	loaded, err = s.App.Get("app1")
+	require.NoError(t, err)
+	require.Equal(t, app, loaded)
+	t.Run("unknown key", func(t *testing.T) {
+		setEncryptionKeys(s, testKey1, "")
+		s.App.Configure(s.conf.GetConfig())
+		_, err = s.App.Get("app1")
+		require.Error(t, err)
+		require.NotErrorIs(t, err, utils.ErrNotFound)
+
+		_, err = s.App.ReencryptSecrets()
+		require.EqualError(t, err, "failed to decrypt secrets of apps: app1")
+
+		setEncryptionKeys(s, testKey1, testKey2)
+		s.App.Configure(s.conf.GetConfig())
+		loaded, err = s.App.Get("app1")
+		require.NoError(t, err)
+		require.Equal(t, app, loaded)
+	})
+}
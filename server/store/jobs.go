@@ -0,0 +1,155 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package store
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+type JobStatus string
+
+const (
+	JobStatusPending JobStatus = "pending"
+	JobStatusRunning JobStatus = "running"
+	JobStatusDone    JobStatus = "done"
+	JobStatusFailed  JobStatus = "failed"
+)
+
+// Job is a one-shot task that must run once in the cluster. A node claims a
+// job by setting it to running with compare-and-set; if the node goes away,
+// the claim expires and the job is run again.
+type Job struct {
+	ID   string            `json:"id"`
+	Type string            `json:"type"`
+	Data map[string]string `json:"data,omitempty"`
+
+	Status   JobStatus `json:"status"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error,omitempty"`
+
+	// The times are in milliseconds since the epoch. NextAttemptAt is set
+	// for the pending jobs that are retried, ClaimedUntil for the running
+	// ones.
+	CreatedAt     int64 `json:"created_at"`
+	UpdatedAt     int64 `json:"updated_at"`
+	NextAttemptAt int64 `json:"next_attempt_at,omitempty"`
+	ClaimedUntil  int64 `json:"claimed_until,omitempty"`
+}
+
+type JobStore interface {
+	// Create saves a new job. It returns false if a job with the same ID
+	// already exists, and leaves it unchanged.
+	Create(job *Job) (bool, error)
+	Get(id string) (*Job, error)
+	List() ([]*Job, error)
+
+	// Update changes a job with compare-and-set. update may be called more
+	// than once, if the job is changed concurrently; it returns false to leave
+	// the job unchanged. Update returns the job as stored, and whether it was
+	// changed.
+	Update(id string, update func(job *Job) bool) (*Job, bool, error)
+	Delete(id string) error
+}
+
+type jobStore struct {
+	*Service
+}
+
+var _ JobStore = (*jobStore)(nil)
+
+func (s *jobStore) Create(job *Job) (bool, error) {
+	err := s.addToSortedSet(config.KVJobIndexKey, job.ID, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to index job")
+	}
+	return s.mm.KV.Set(config.KVJobPrefix+job.ID, job, pluginapi.SetAtomic(nil))
+}
+
+func (s *jobStore) Get(id string) (*Job, error) {
+	var job *Job
+	err := s.mm.KV.Get(config.KVJobPrefix+id, &job)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, utils.ErrNotFound
+	}
+	return job, nil
+}
+
+func (s *jobStore) List() ([]*Job, error) {
+	ids, err := s.getSortedSet(config.KVJobIndexKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list jobs")
+	}
+
+	var all []*Job
+	for _, id := range ids {
+		job, err := s.Get(id)
+		if err != nil {
+			if errors.Is(err, utils.ErrNotFound) {
+				// deleted since listed
+				continue
+			}
+			return nil, err
+		}
+		all = append(all, job)
+	}
+	return all, nil
+}
+
+func (s *jobStore) Update(id string, update func(job *Job) bool) (*Job, bool, error) {
+	var job *Job
+	changed := false
+	_, err := s.updateAtomic(config.KVJobPrefix+id, func(data []byte) ([]byte, error) {
+		job = nil
+		changed = false
+		if data == nil {
+			return nil, utils.NewNotFoundError("job %s", id)
+		}
+		err := json.Unmarshal(data, &job)
+		if err != nil {
+			return nil, err
+		}
+		if !update(job) {
+			return data, nil
+		}
+		changed = true
+		return json.Marshal(job)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return job, changed, nil
+}
+
+func (s *jobStore) Delete(id string) error {
+	err := s.mm.KV.Delete(config.KVJobPrefix + id)
+	if err != nil {
+		return err
+	}
+	_, err = s.removeFromSortedSet(config.KVJobIndexKey, id)
+	return err
+}
+
+// migrateIndex indexes the jobs that were created before the index was kept.
+func (s *jobStore) migrateIndex() error {
+	keys, err := s.listKeys(config.KVJobPrefix)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, key := range keys {
+		ids = append(ids, strings.TrimPrefix(key, config.KVJobPrefix))
+	}
+	return s.mergeIntoSortedSet(config.KVJobIndexKey, ids)
+}
@@ -0,0 +1,89 @@
+// +build !e2e
+
+package store
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestCreateJob(t *testing.T) {
+	mockAPI := &plugintest.API{}
+	defer mockAPI.AssertExpectations(t)
+	apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+	conf := config.NewService(apiClient, utils.NewTestLogger(), config.BuildConfig{}, "bot-id")
+	s := NewService(apiClient, utils.NewTestLogger(), conf, nil, "", nil)
+
+	job := &Job{ID: "id1", Type: "test", Status: JobStatusPending}
+	jobBytes, _ := json.Marshal(job)
+	mockAPI.On("KVGet", "jobi").Return(nil, nil).Once()
+	mockAPI.On("KVSetWithOptions", "jobi", []byte(`["id1"]`), model.PluginKVSetOptions{
+		Atomic: true,
+	}).Return(true, nil).Once()
+	mockAPI.On("KVGet", "jobi").Return([]byte(`["id1"]`), nil).Once()
+	mockAPI.On("KVSetWithOptions", "job.id1", jobBytes, model.PluginKVSetOptions{
+		Atomic: true,
+	}).Return(true, nil).Once()
+	mockAPI.On("KVSetWithOptions", "job.id1", jobBytes, model.PluginKVSetOptions{
+		Atomic: true,
+	}).Return(false, nil).Once()
+
+	created, err := s.Job.Create(job)
+	require.NoError(t, err)
+	require.True(t, created)
+
+	// Already exists.
+	created, err = s.Job.Create(job)
+	require.NoError(t, err)
+	require.False(t, created)
+}
+
+func TestUpdateJobConflict(t *testing.T) {
+	mockAPI := &plugintest.API{}
+	defer mockAPI.AssertExpectations(t)
+	apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+	conf := config.NewService(apiClient, utils.NewTestLogger(), config.BuildConfig{}, "bot-id")
+	s := NewService(apiClient, utils.NewTestLogger(), conf, nil, "", nil)
+
+	pending, _ := json.Marshal(&Job{ID: "id1", Status: JobStatusPending})
+	claimed, _ := json.Marshal(&Job{ID: "id1", Status: JobStatusRunning, Attempts: 1})
+	running, _ := json.Marshal(&Job{ID: "id1", Status: JobStatusRunning, Attempts: 1, ClaimedUntil: 1})
+
+	claim := func(job *Job) bool {
+		if job.Status != JobStatusPending {
+			return false
+		}
+		job.Status = JobStatusRunning
+		job.Attempts++
+		return true
+	}
+
+	// Another node claims the job first, so the claim is retried, and not
+	// made.
+	mockAPI.On("KVGet", "job.id1").Return(pending, nil).Once()
+	mockAPI.On("KVSetWithOptions", "job.id1", claimed, model.PluginKVSetOptions{
+		Atomic:   true,
+		OldValue: pending,
+	}).Return(false, nil).Once()
+	mockAPI.On("KVGet", "job.id1").Return(running, nil).Once()
+
+	job, changed, err := s.Job.Update("id1", claim)
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Equal(t, int64(1), job.ClaimedUntil)
+
+	mockAPI.On("KVGet", "job.id2").Return(nil, nil).Once()
+	_, _, err = s.Job.Update("id2", claim)
+	require.EqualError(t, err, "job id2: not found")
+	mockAPI.AssertNotCalled(t, "KVSetWithOptions", "job.id2", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,491 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+const (
+	// kvUsageShards is how many keys each app's usage is split into, so that
+	// concurrent changes of different values do not contend on one key.
+	kvUsageShards = 16
+
+	// kvUsageExpiryPeriod is how precisely the expiring values are counted:
+	// a value stops being counted at the end of the period in which it
+	// expires.
+	kvUsageExpiryPeriod = time.Hour
+
+	// kvUsageCacheTTL is how long an app's usage is cached in memory to check
+	// its quota, before it is read from its shards again, which accounts for
+	// the changes made by the other nodes of the cluster.
+	kvUsageCacheTTL = time.Minute
+)
+
+// KVUsage is how much of the KV store an app uses for its values, and its
+// per-user values. Bytes counts the sizes of the keys and the values.
+//
+// The usage is counted as the values are set and deleted, and recounted from
+// the values periodically, which also counts the values set before the usage
+// was counted.
+type KVUsage struct {
+	Keys  int `json:"keys"`
+	Bytes int `json:"bytes"`
+}
+
+func (u *KVUsage) add(delta KVUsage) {
+	u.Keys += delta.Keys
+	u.Bytes += delta.Bytes
+}
+
+// kvUsageShard is the usage of the values whose keys hash into the shard. The
+// values that expire are counted by the end of the period in which they
+// expire, in Unix milliseconds.
+type kvUsageShard struct {
+	KVUsage
+	Expiring map[int64]KVUsage `json:"expiring,omitempty"`
+}
+
+// kvUsageDelta is a change of the usage of the values that expire at
+// ExpireAt, 0 for the values that do not.
+type kvUsageDelta struct {
+	KVUsage
+	ExpireAt int64
+}
+
+func kvUsagePeriodEnd(expireAt int64) int64 {
+	period := kvUsageExpiryPeriod.Milliseconds()
+	return (expireAt/period + 1) * period
+}
+
+// live returns the usage of the values that have not expired by now.
+func (u *kvUsageShard) live(now int64) KVUsage {
+	usage := u.KVUsage
+	for end, expiring := range u.Expiring {
+		if end > now {
+			usage.add(expiring)
+		}
+	}
+	return usage
+}
+
+// add adds the deltas, and drops the periods that ended. The usage does not go
+// below zero, since the values set before it was counted may be deleted.
+func (u *kvUsageShard) add(now int64, deltas ...kvUsageDelta) {
+	for _, d := range deltas {
+		if d.ExpireAt == 0 {
+			u.KVUsage.add(d.KVUsage)
+			u.KVUsage = nonNegative(u.KVUsage)
+			continue
+		}
+		end := kvUsagePeriodEnd(d.ExpireAt)
+		if end <= now {
+			continue
+		}
+		if u.Expiring == nil {
+			u.Expiring = map[int64]KVUsage{}
+		}
+		expiring := u.Expiring[end]
+		expiring.add(d.KVUsage)
+		u.Expiring[end] = nonNegative(expiring)
+	}
+	for end, expiring := range u.Expiring {
+		if end <= now || expiring == (KVUsage{}) {
+			delete(u.Expiring, end)
+		}
+	}
+}
+
+func nonNegative(u KVUsage) KVUsage {
+	if u.Keys < 0 {
+		u.Keys = 0
+	}
+	if u.Bytes < 0 {
+		u.Bytes = 0
+	}
+	return u
+}
+
+func kvUsageShardKey(botUserID string, shard int) string {
+	return fmt.Sprintf("%s%s.%x", config.KVAppUsagePrefix, botUserID, shard)
+}
+
+func kvUsageShardOf(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % kvUsageShards)
+}
+
+// kvUsageCache keeps the apps' usage in memory, so that checking the quota
+// does not read all of an app's usage shards on every change. The changes
+// made by this node are added to the cached usage as they are made. The
+// methods of a nil cache do nothing.
+type kvUsageCache struct {
+	mutex sync.Mutex
+	usage map[string]cachedKVUsage
+}
+
+type cachedKVUsage struct {
+	KVUsage
+	readAt int64
+}
+
+func newKVUsageCache() *kvUsageCache {
+	return &kvUsageCache{
+		usage: map[string]cachedKVUsage{},
+	}
+}
+
+func (c *kvUsageCache) get(botUserID string, now int64) (KVUsage, bool) {
+	if c == nil {
+		return KVUsage{}, false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	cached, ok := c.usage[botUserID]
+	if !ok || now-cached.readAt >= kvUsageCacheTTL.Milliseconds() {
+		return KVUsage{}, false
+	}
+	return cached.KVUsage, true
+}
+
+func (c *kvUsageCache) set(botUserID string, usage KVUsage, now int64) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.usage[botUserID] = cachedKVUsage{
+		KVUsage: usage,
+		readAt:  now,
+	}
+}
+
+// add adds the deltas to the app's usage, if it is cached.
+func (c *kvUsageCache) add(botUserID string, deltas []kvUsageDelta) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	cached, ok := c.usage[botUserID]
+	if !ok {
+		return
+	}
+	for _, d := range deltas {
+		cached.add(d.KVUsage)
+	}
+	cached.KVUsage = nonNegative(cached.KVUsage)
+	c.usage[botUserID] = cached
+}
+
+func (c *kvUsageCache) delete(botUserID string) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.usage, botUserID)
+}
+
+// getKVUsage returns the app's usage, summed from its shards, and the value
+// it was stored in before it was sharded, until it is recounted.
+func (s *Service) getKVUsage(botUserID string) (*KVUsage, error) {
+	now := model.GetMillis()
+	usage := &KVUsage{}
+	err := s.mm.KV.Get(config.KVAppUsagePrefix+botUserID, usage)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < kvUsageShards; i++ {
+		shard := kvUsageShard{}
+		err := s.mm.KV.Get(kvUsageShardKey(botUserID, i), &shard)
+		if err != nil {
+			return nil, err
+		}
+		usage.add(shard.live(now))
+	}
+	return usage, nil
+}
+
+// kvExpiry is when a value being set expires, and when the value it replaces
+// expires, as recorded in the app's KV index. Both are in Unix milliseconds,
+// 0 if the value does not expire, or it is not known. PrevSize is the size of
+// the replaced value, if it expires.
+type kvExpiry struct {
+	ExpireAt     int64
+	PrevExpireAt int64
+	PrevSize     int
+}
+
+// setAppValue sets a key that holds an app's value, and counts the change in
+// the app's KV usage. A change that adds keys or bytes fails with
+// utils.ErrQuotaExceeded if the app would exceed its quota. A nil ref deletes
+// the key. It returns false if the value was not set because of the options.
+func (s *Service) setAppValue(botUserID, key string, ref interface{}, expiry kvExpiry, options ...pluginapi.KVSetOption) (bool, error) {
+	data, err := marshalValue(ref)
+	if err != nil {
+		return false, err
+	}
+
+	var prev []byte
+	err = s.mm.KV.Get(key, &prev)
+	if err != nil {
+		return false, err
+	}
+	deltas := kvUsageDeltas(key, prev, data, expiry)
+	growth := KVUsage{}
+	for _, d := range deltas {
+		growth.add(d.KVUsage)
+	}
+	if growth.Keys > 0 || growth.Bytes > 0 {
+		err = s.checkKVQuota(botUserID, growth)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	saved, err := s.mm.KV.Set(key, data, options...)
+	if err != nil || !saved {
+		return saved, err
+	}
+	if len(deltas) > 0 {
+		s.kvUsageCache.add(botUserID, deltas)
+		err = s.addKVUsage(botUserID, key, deltas)
+		if err != nil {
+			// The value is set; the usage is corrected when it is recounted.
+			s.log.WithError(err).Warnw("Failed to update the KV usage", "bot_user_id", botUserID)
+		}
+	}
+	return true, nil
+}
+
+// kvUsageDeltas returns the changes of the usage when data replaces prev. A
+// replaced value that expired is still counted until the end of its period,
+// so it is removed from there.
+func kvUsageDeltas(key string, prev, data []byte, expiry kvExpiry) []kvUsageDelta {
+	var deltas []kvUsageDelta
+	switch {
+	case prev != nil:
+		deltas = append(deltas, kvUsageDelta{
+			KVUsage:  KVUsage{Keys: -1, Bytes: -(len(key) + len(prev))},
+			ExpireAt: expiry.PrevExpireAt,
+		})
+	case expiry.PrevExpireAt != 0 && expiry.PrevSize != 0:
+		deltas = append(deltas, kvUsageDelta{
+			KVUsage:  KVUsage{Keys: -1, Bytes: -expiry.PrevSize},
+			ExpireAt: expiry.PrevExpireAt,
+		})
+	}
+	if data != nil {
+		deltas = append(deltas, kvUsageDelta{
+			KVUsage:  KVUsage{Keys: 1, Bytes: len(key) + len(data)},
+			ExpireAt: expiry.ExpireAt,
+		})
+	}
+	if len(deltas) == 2 && deltas[0].ExpireAt == deltas[1].ExpireAt {
+		sum := deltas[0]
+		sum.add(deltas[1].KVUsage)
+		if sum.KVUsage == (KVUsage{}) {
+			return nil
+		}
+		return []kvUsageDelta{sum}
+	}
+	return deltas
+}
+
+// checkKVQuota checks the quota before the change is made, against the cached
+// usage, so concurrent changes, and the changes made on other nodes within
+// kvUsageCacheTTL, may exceed it slightly.
+func (s *Service) checkKVQuota(botUserID string, delta KVUsage) error {
+	quota := s.conf.GetConfig().KVQuota()
+	if quota.MaxKeys == 0 && quota.MaxBytes == 0 {
+		return nil
+	}
+	now := model.GetMillis()
+	usage, ok := s.kvUsageCache.get(botUserID, now)
+	if !ok {
+		read, err := s.getKVUsage(botUserID)
+		if err != nil {
+			return err
+		}
+		usage = *read
+		s.kvUsageCache.set(botUserID, usage, now)
+	}
+	if quota.MaxKeys > 0 && delta.Keys > 0 && usage.Keys+delta.Keys > quota.MaxKeys {
+		return utils.NewQuotaExceededError("app may store at most %v keys", quota.MaxKeys)
+	}
+	if quota.MaxBytes > 0 && delta.Bytes > 0 && usage.Bytes+delta.Bytes > quota.MaxBytes {
+		return utils.NewQuotaExceededError("app may store at most %v bytes", quota.MaxBytes)
+	}
+	return nil
+}
+
+// addKVUsage adds the deltas to the usage shard of key. Zero usage is not
+// stored.
+func (s *Service) addKVUsage(botUserID, key string, deltas []kvUsageDelta) error {
+	_, err := s.updateAtomic(kvUsageShardKey(botUserID, kvUsageShardOf(key)), func(data []byte) ([]byte, error) {
+		shard := kvUsageShard{}
+		if len(data) > 0 {
+			err := json.Unmarshal(data, &shard)
+			if err != nil {
+				return nil, err
+			}
+		}
+		shard.add(model.GetMillis(), deltas...)
+		return marshalKVUsageShard(shard)
+	})
+	return err
+}
+
+func marshalKVUsageShard(shard kvUsageShard) ([]byte, error) {
+	if shard.KVUsage == (KVUsage{}) && len(shard.Expiring) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(shard)
+}
+
+// deleteKVUsage deletes the app's usage, including the single value it was
+// stored in before it was sharded.
+func (s *Service) deleteKVUsage(botUserID string) error {
+	s.kvUsageCache.delete(botUserID)
+	for i := 0; i < kvUsageShards; i++ {
+		err := s.mm.KV.Delete(kvUsageShardKey(botUserID, i))
+		if err != nil {
+			return err
+		}
+	}
+	return s.mm.KV.Delete(config.KVAppUsagePrefix + botUserID)
+}
+
+// kvUsageCounter recounts an app's usage from its values.
+type kvUsageCounter struct {
+	now    int64
+	shards [kvUsageShards]kvUsageShard
+	seen   map[string]bool
+}
+
+func (c *kvUsageCounter) count(key string, value []byte, expireAt int64) {
+	if value == nil || c.seen[key] {
+		return
+	}
+	c.seen[key] = true
+	c.shards[kvUsageShardOf(key)].add(c.now, kvUsageDelta{
+		KVUsage:  KVUsage{Keys: 1, Bytes: len(key) + len(value)},
+		ExpireAt: expireAt,
+	})
+}
+
+// recountKVUsage recounts the app's usage from its values: the values in its
+// KV index, with their expiry, and its per-user values. If the app's keys
+// are not all indexed, the rest of its values are found by scanning the KV
+// store, and counted as not expiring. The changes made while the values are
+// being counted may be missed, until the next recount.
+func (s *Service) recountKVUsage(botUserID string) error {
+	c := &kvUsageCounter{
+		now:  model.GetMillis(),
+		seen: map[string]bool{},
+	}
+	get := func(key string) ([]byte, error) {
+		var value []byte
+		err := s.mm.KV.Get(key, &value)
+		return value, err
+	}
+
+	appKV := &appKVStore{Service: s}
+	namespaces, err := appKV.getNamespaces(botUserID)
+	if err != nil {
+		return err
+	}
+	for _, ns := range namespaces.Namespaces {
+		dir, err := appKV.getIndexDir(botUserID, ns)
+		if err != nil {
+			return err
+		}
+		for _, page := range dir {
+			entries, err := appKV.getIndexPage(botUserID, ns, page.ID)
+			if err != nil {
+				return err
+			}
+			for _, e := range entries {
+				key, err := s.hashkey(config.KVAppPrefix, botUserID, ns, e.ID)
+				if err != nil {
+					return err
+				}
+				value, err := get(key)
+				if err != nil {
+					return err
+				}
+				c.count(key, value, e.ExpireAt)
+			}
+		}
+	}
+	if !namespaces.Complete {
+		keys, err := s.listKeys(config.KVAppPrefix + botUserID)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if c.seen[key] {
+				continue
+			}
+			value, err := get(key)
+			if err != nil {
+				return err
+			}
+			c.count(key, value, 0)
+		}
+	}
+
+	userKV := &userKVStore{Service: s}
+	userIDs, err := userKV.ListUsers(botUserID)
+	if err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		ids, err := userKV.List(botUserID, userID)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			key, err := userKV.userKVKey(botUserID, userID, id)
+			if err != nil {
+				return err
+			}
+			value, err := get(key)
+			if err != nil {
+				return err
+			}
+			c.count(key, value, 0)
+		}
+	}
+
+	usage := KVUsage{}
+	for i, shard := range c.shards {
+		data, err := marshalKVUsageShard(shard)
+		if err != nil {
+			return err
+		}
+		_, err = s.mm.KV.Set(kvUsageShardKey(botUserID, i), data)
+		if err != nil {
+			return errors.Wrap(err, "failed to save the KV usage")
+		}
+		usage.add(shard.live(c.now))
+	}
+	err = s.mm.KV.Delete(config.KVAppUsagePrefix + botUserID)
+	if err != nil {
+		return err
+	}
+	s.kvUsageCache.set(botUserID, usage, c.now)
+	return nil
+}
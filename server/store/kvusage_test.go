@@ -0,0 +1,210 @@
+// +build !e2e
+
+package store
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestKVUsage(t *testing.T) {
+	botID := "01234567890123456789012345"
+	userID := "user1user1user1user1user1u"
+	keySize := 50
+
+	setupKV := func(quota config.Config) (*Service, map[string][]byte) {
+		mockAPI := &plugintest.API{}
+		kv := memKV(mockAPI)
+		apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+		return NewService(apiClient, utils.NewTestLogger(), config.NewTestConfigurator(quota), nil, "", nil), kv
+	}
+	setup := func(quota config.Config) *Service {
+		s, _ := setupKV(quota)
+		return s
+	}
+
+	t.Run("counted", func(t *testing.T) {
+		s := setup(config.Config{})
+		_, err := s.AppKV.Set(botID, "", "id1", []byte("12345"))
+		require.NoError(t, err)
+		_, err = s.AppKV.Set(botID, "", "id2", []byte("1"))
+		require.NoError(t, err)
+		require.NoError(t, s.UserKV.Set(botID, userID, "id1", []byte("123")))
+
+		usage, err := s.AppKV.GetUsage(botID)
+		require.NoError(t, err)
+		require.Equal(t, &KVUsage{Keys: 3, Bytes: 3*keySize + 9}, usage)
+
+		// Overwrite with a smaller value.
+		_, err = s.AppKV.Set(botID, "", "id1", []byte("1"))
+		require.NoError(t, err)
+		require.NoError(t, s.AppKV.Delete(botID, "", "id2"))
+		require.NoError(t, s.UserKV.DeleteUser(botID, userID))
+		usage, err = s.AppKV.GetUsage(botID)
+		require.NoError(t, err)
+		require.Equal(t, &KVUsage{Keys: 1, Bytes: keySize + 1}, usage)
+	})
+
+	t.Run("expiring", func(t *testing.T) {
+		s, kv := setupKV(config.Config{})
+		_, err := s.AppKV.SetWithOptions(botID, "", "id1", []byte("12345"), apps.KVSetOptions{ExpireInSeconds: 60})
+		require.NoError(t, err)
+		usage, err := s.AppKV.GetUsage(botID)
+		require.NoError(t, err)
+		require.Equal(t, &KVUsage{Keys: 1, Bytes: keySize + 5}, usage)
+
+		// The value expires, and is set again: it is counted once.
+		key, err := s.hashkey(config.KVAppPrefix, botID, "", "id1")
+		require.NoError(t, err)
+		delete(kv, key)
+		_, err = s.AppKV.SetWithOptions(botID, "", "id1", []byte("1"), apps.KVSetOptions{ExpireInSeconds: 60})
+		require.NoError(t, err)
+		usage, err = s.AppKV.GetUsage(botID)
+		require.NoError(t, err)
+		require.Equal(t, &KVUsage{Keys: 1, Bytes: keySize + 1}, usage)
+
+		// Made persistent.
+		_, err = s.AppKV.Set(botID, "", "id1", []byte("12"))
+		require.NoError(t, err)
+		usage, err = s.AppKV.GetUsage(botID)
+		require.NoError(t, err)
+		require.Equal(t, &KVUsage{Keys: 1, Bytes: keySize + 2}, usage)
+	})
+
+	t.Run("expired period", func(t *testing.T) {
+		now := model.GetMillis()
+		shard := kvUsageShard{KVUsage: KVUsage{Keys: 1, Bytes: 10}}
+		shard.add(now, kvUsageDelta{KVUsage: KVUsage{Keys: 1, Bytes: 20}, ExpireAt: now + 1000})
+		require.Equal(t, KVUsage{Keys: 2, Bytes: 30}, shard.live(now))
+		require.Equal(t, KVUsage{Keys: 1, Bytes: 10}, shard.live(kvUsagePeriodEnd(now+1000)))
+
+		shard.add(kvUsagePeriodEnd(now + 1000))
+		require.Empty(t, shard.Expiring)
+	})
+
+	t.Run("recount", func(t *testing.T) {
+		s, kv := setupKV(config.Config{})
+		require.NoError(t, s.AppKV.InitIndex(botID))
+		_, err := s.AppKV.Set(botID, "", "id1", []byte("12345"))
+		require.NoError(t, err)
+		_, err = s.AppKV.SetWithOptions(botID, "ns", "id2", []byte("1"), apps.KVSetOptions{ExpireInSeconds: 60})
+		require.NoError(t, err)
+		require.NoError(t, s.UserKV.Set(botID, userID, "id1", []byte("123")))
+		expected, err := s.AppKV.GetUsage(botID)
+		require.NoError(t, err)
+		require.Equal(t, &KVUsage{Keys: 3, Bytes: 3*keySize + 9}, expected)
+
+		// The usage stored before it was sharded, and a lost update.
+		for k := range kv {
+			if strings.HasPrefix(k, config.KVAppUsagePrefix) {
+				delete(kv, k)
+			}
+		}
+		kv[config.KVAppUsagePrefix+botID] = []byte(`{"keys":1,"bytes":1}`)
+
+		require.NoError(t, s.AppKV.RecountUsage(botID))
+		usage, err := s.AppKV.GetUsage(botID)
+		require.NoError(t, err)
+		require.Equal(t, expected, usage)
+		require.NotContains(t, kv, config.KVAppUsagePrefix+botID)
+	})
+
+	t.Run("usage update failed", func(t *testing.T) {
+		mockAPI := &plugintest.API{}
+		mockAPI.On("KVSetWithOptions", mock.MatchedBy(func(key string) bool {
+			return strings.HasPrefix(key, config.KVAppUsagePrefix)
+		}), mock.Anything, mock.Anything).Return(false, model.NewAppError("KVSet", "", nil, "test error", http.StatusInternalServerError))
+		memKV(mockAPI)
+		apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+		s := NewService(apiClient, utils.NewTestLogger(), config.NewTestConfigurator(config.Config{}), nil, "", nil)
+
+		// The value is set, the usage is corrected by the next recount.
+		saved, err := s.AppKV.Set(botID, "", "id1", 1)
+		require.NoError(t, err)
+		require.True(t, saved)
+	})
+
+	t.Run("keys quota", func(t *testing.T) {
+		s := setup(config.Config{StoredConfig: config.StoredConfig{KVMaxKeysPerApp: 2}})
+		_, err := s.AppKV.Set(botID, "", "id1", 1)
+		require.NoError(t, err)
+		require.NoError(t, s.UserKV.Set(botID, userID, "id1", 1))
+		_, err = s.AppKV.Set(botID, "", "id2", 1)
+		require.Equal(t, utils.ErrQuotaExceeded, errors.Cause(err))
+
+		// Existing keys can still be changed.
+		_, err = s.AppKV.Set(botID, "", "id1", 2)
+		require.NoError(t, err)
+	})
+
+	t.Run("bytes quota", func(t *testing.T) {
+		s := setup(config.Config{StoredConfig: config.StoredConfig{KVMaxBytesPerApp: keySize + 10}})
+		_, err := s.AppKV.Set(botID, "", "id1", []byte("1234567890"))
+		require.NoError(t, err)
+		_, err = s.AppKV.Set(botID, "", "id1", []byte("12345678901"))
+		require.Equal(t, utils.ErrQuotaExceeded, errors.Cause(err))
+
+		// Shrinking is allowed.
+		_, err = s.AppKV.Set(botID, "", "id1", []byte("1"))
+		require.NoError(t, err)
+	})
+
+	t.Run("quota checked against the cached usage", func(t *testing.T) {
+		mockAPI := &plugintest.API{}
+		memKV(mockAPI)
+		apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+		conf := config.NewTestConfigurator(config.Config{StoredConfig: config.StoredConfig{KVMaxKeysPerApp: 3}})
+		s := NewService(apiClient, utils.NewTestLogger(), conf, nil, "", nil)
+		otherNode := NewService(apiClient, utils.NewTestLogger(), conf, nil, "", nil)
+		shardReads := func() int {
+			n := 0
+			for _, call := range mockAPI.Calls {
+				if call.Method == "KVGet" && call.Arguments.String(0) == kvUsageShardKey(botID, 0) {
+					n++
+				}
+			}
+			return n
+		}
+
+		_, err := s.AppKV.Set(botID, "", "id1", 1)
+		require.NoError(t, err)
+		_, err = s.AppKV.Set(botID, "", "id2", 1)
+		require.NoError(t, err)
+		require.Equal(t, 1, shardReads())
+
+		// Not seen until the cached usage expires.
+		_, err = otherNode.AppKV.Set(botID, "", "id3", 1)
+		require.NoError(t, err)
+		_, err = s.AppKV.Set(botID, "", "id4", 1)
+		require.NoError(t, err)
+
+		cached := s.kvUsageCache.usage[botID]
+		require.Equal(t, KVUsage{Keys: 3, Bytes: 3*keySize + 3}, cached.KVUsage)
+		cached.readAt -= kvUsageCacheTTL.Milliseconds()
+		s.kvUsageCache.usage[botID] = cached
+		_, err = s.AppKV.Set(botID, "", "id5", 1)
+		require.Equal(t, utils.ErrQuotaExceeded, errors.Cause(err))
+		require.Equal(t, 3, shardReads())
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		s := setup(config.Config{StoredConfig: config.StoredConfig{KVMaxKeysPerApp: -1, KVMaxBytesPerApp: -1}})
+		for _, id := range []string{"id1", "id2", "id3"} {
+			_, err := s.AppKV.Set(botID, "", id, 1)
+			require.NoError(t, err)
+		}
+	})
+}
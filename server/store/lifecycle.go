@@ -0,0 +1,148 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package store
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+)
+
+// lifecycleSnapshotShards is the number of KV records a snapshot is split
+// into, so that a poll only rewrites the records where something changed. The
+// number of shards is stored in the snapshot's head record, which is also used
+// to tell that a snapshot exists.
+const lifecycleSnapshotShards = 64
+
+// EntityState is what the lifecycle polling remembers about a user or a
+// channel, to detect that it changed.
+type EntityState struct {
+	UpdateAt int64 `json:"u"`
+	DeleteAt int64 `json:"d,omitempty"`
+}
+
+// LifecycleStore keeps the snapshots of the entities polled for the lifecycle
+// subjects that have no plugin hooks, keyed by the entity ID.
+type LifecycleStore interface {
+	// GetSnapshot returns nil if there is no snapshot yet.
+	GetSnapshot(name string) (map[string]EntityState, error)
+	// SaveSnapshot stores the current snapshot, writing only the shards that
+	// differ from prev, the snapshot returned by GetSnapshot.
+	SaveSnapshot(name string, prev, current map[string]EntityState) error
+	DeleteSnapshot(name string) error
+}
+
+type lifecycleStore struct {
+	*Service
+}
+
+var _ LifecycleStore = (*lifecycleStore)(nil)
+
+func lifecycleHeadKey(name string) string {
+	return config.KVLifecycleSnapshotPrefix + name + ".n"
+}
+
+func lifecycleShardKey(name string, shard int) string {
+	return fmt.Sprintf("%s%s.%02d", config.KVLifecycleSnapshotPrefix, name, shard)
+}
+
+func lifecycleShard(id string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return int(h.Sum32() % lifecycleSnapshotShards)
+}
+
+func splitSnapshot(snapshot map[string]EntityState) []map[string]EntityState {
+	shards := make([]map[string]EntityState, lifecycleSnapshotShards)
+	for i := range shards {
+		shards[i] = map[string]EntityState{}
+	}
+	for id, state := range snapshot {
+		shards[lifecycleShard(id)][id] = state
+	}
+	return shards
+}
+
+func (s *lifecycleStore) GetSnapshot(name string) (map[string]EntityState, error) {
+	numShards := 0
+	err := s.mm.KV.Get(lifecycleHeadKey(name), &numShards)
+	if err != nil {
+		return nil, err
+	}
+	if numShards == 0 {
+		return nil, nil
+	}
+
+	snapshot := map[string]EntityState{}
+	for i := 0; i < numShards; i++ {
+		var shard map[string]EntityState
+		err = s.mm.KV.Get(lifecycleShardKey(name, i), &shard)
+		if err != nil {
+			return nil, err
+		}
+		for id, state := range shard {
+			snapshot[id] = state
+		}
+	}
+	return snapshot, nil
+}
+
+func (s *lifecycleStore) SaveSnapshot(name string, prev, current map[string]EntityState) error {
+	prevShards := splitSnapshot(prev)
+	for i, shard := range splitSnapshot(current) {
+		if prev != nil && reflect.DeepEqual(prevShards[i], shard) {
+			continue
+		}
+		_, err := s.mm.KV.Set(lifecycleShardKey(name, i), shard)
+		if err != nil {
+			return err
+		}
+	}
+	if prev == nil {
+		_, err := s.mm.KV.Set(lifecycleHeadKey(name), lifecycleSnapshotShards)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *lifecycleStore) DeleteSnapshot(name string) error {
+	numShards := 0
+	err := s.mm.KV.Get(lifecycleHeadKey(name), &numShards)
+	if err != nil {
+		return err
+	}
+	if numShards == 0 {
+		return nil
+	}
+
+	// The head goes first, so that an interrupted delete does not leave a
+	// partial snapshot.
+	err = s.mm.KV.Delete(lifecycleHeadKey(name))
+	if err != nil {
+		return err
+	}
+	for i := 0; i < numShards; i++ {
+		err = s.mm.KV.Delete(lifecycleShardKey(name, i))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateShardedSnapshots deletes the snapshots that were stored as a single
+// value. The next poll records the state again.
+func (s *lifecycleStore) migrateShardedSnapshots() error {
+	for _, name := range []string{"users", "channels"} {
+		err := s.mm.KV.Delete(config.KVLifecycleSnapshotPrefix + name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// +build !e2e
+
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestLifecycleSnapshot(t *testing.T) {
+	mockAPI := &plugintest.API{}
+	kv := memKV(mockAPI)
+	apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+	s := NewService(apiClient, utils.NewTestLogger(), config.NewTestConfigurator(config.Config{}), nil, "", nil)
+
+	snapshot, err := s.Lifecycle.GetSnapshot("users")
+	require.NoError(t, err)
+	require.Nil(t, snapshot)
+	require.NoError(t, s.Lifecycle.DeleteSnapshot("users"))
+
+	first := map[string]EntityState{
+		"user1": {UpdateAt: 1},
+		"user2": {UpdateAt: 1},
+	}
+	require.NoError(t, s.Lifecycle.SaveSnapshot("users", nil, first))
+	require.Len(t, kv, lifecycleSnapshotShards+1)
+
+	snapshot, err = s.Lifecycle.GetSnapshot("users")
+	require.NoError(t, err)
+	require.Equal(t, first, snapshot)
+
+	// Only the shard of the changed user is written.
+	unchangedKey := lifecycleShardKey("users", lifecycleShard("user2"))
+	require.NotEqual(t, unchangedKey, lifecycleShardKey("users", lifecycleShard("user1")))
+	kv[unchangedKey] = []byte(`{"user2":{"u":5}}`)
+	require.NoError(t, s.Lifecycle.SaveSnapshot("users", first, map[string]EntityState{
+		"user1": {UpdateAt: 2, DeleteAt: 2},
+		"user2": {UpdateAt: 1},
+	}))
+	snapshot, err = s.Lifecycle.GetSnapshot("users")
+	require.NoError(t, err)
+	require.Equal(t, map[string]EntityState{
+		"user1": {UpdateAt: 2, DeleteAt: 2},
+		"user2": {UpdateAt: 5},
+	}, snapshot)
+
+	require.NoError(t, s.Lifecycle.DeleteSnapshot("users"))
+	require.Empty(t, kv)
+	snapshot, err = s.Lifecycle.GetSnapshot("users")
+	require.NoError(t, err)
+	require.Nil(t, snapshot)
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package store
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+)
+
+// migration backfills the data that was stored before a change in how it is
+// stored, e.g. builds an index of the existing keys. A migration runs once,
+// but it must be safe to run again if it was interrupted.
+type migration struct {
+	id  string
+	run func() error
+}
+
+func (s *Service) migrations() []migration {
+	return []migration{
+		{
+			id:  "lifecycle_sharded_snapshots",
+			run: (&lifecycleStore{Service: s}).migrateShardedSnapshots,
+		},
+		{
+			id:  "subscription_app_indexes",
+			run: (&subscriptionStore{Service: s}).migrateAppIndexes,
+		},
+		{
+			id:  "scheduled_call_index",
+			run: (&scheduleStore{Service: s}).migrateCallIndex,
+		},
+		{
+			id:  "job_index",
+			run: (&jobStore{Service: s}).migrateIndex,
+		},
+		{
+			id:  "app_kv_paged_indexes",
+			run: (&appKVStore{Service: s}).migratePagedIndexes,
+		},
+	}
+}
+
+// Migrate runs the migrations that have not completed yet. It is run on
+// activation, under a cluster mutex.
+func (s *Service) Migrate() error {
+	done, err := s.getSortedSet(config.KVMigrationsKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the completed migrations")
+	}
+
+	for _, m := range s.migrations() {
+		i := sort.SearchStrings(done, m.id)
+		if i < len(done) && done[i] == m.id {
+			continue
+		}
+
+		start := time.Now()
+		err = m.run()
+		if err != nil {
+			return errors.Wrapf(err, "migration %s failed", m.id)
+		}
+		err = s.addToSortedSet(config.KVMigrationsKey, m.id, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to record migration %s", m.id)
+		}
+		s.log.Infow("Migrated stored data", "migration", m.id, "elapsed", time.Since(start).String())
+	}
+	return nil
+}
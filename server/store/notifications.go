@@ -0,0 +1,195 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package store
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+// Notification is a subscription notification that an app failed to receive.
+// It is kept in the retry queue until it is delivered, or until it runs out of
+// attempts and is moved to the dead-letter list.
+type Notification struct {
+	ID      string       `json:"id"`
+	AppID   apps.AppID   `json:"app_id"`
+	Subject apps.Subject `json:"subject"`
+	Call    apps.Call    `json:"call"`
+
+	// ChannelID and TeamID are the scope of the subscription. The app's access
+	// to them is checked again before every attempt.
+	ChannelID string `json:"channel_id,omitempty"`
+	TeamID    string `json:"team_id,omitempty"`
+
+	// Context is the notification context before it was expanded for the
+	// app, it is re-expanded on every attempt.
+	Context *apps.Context `json:"context"`
+
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+
+	// CreatedAt and NextAttemptAt are in milliseconds since the epoch.
+	CreatedAt     int64 `json:"created_at"`
+	NextAttemptAt int64 `json:"next_attempt_at,omitempty"`
+}
+
+// MaxQueuedNotificationsPerApp limits how many notifications of an app are
+// kept in the retry queue, and how many in the dead-letter list, so that an
+// app that is down does not grow them without bound.
+const MaxQueuedNotificationsPerApp = 1000
+
+type NotificationStore interface {
+	// Queue saves (or updates) a notification in the retry queue. If the
+	// app's queue is full, a new notification is not queued, and a quota
+	// exceeded error is returned.
+	Queue(n *Notification) error
+	ListQueued() ([]*Notification, error)
+	DeleteQueued(appID apps.AppID, id string) error
+
+	// SaveDeadLetter moves a notification from the retry queue to the
+	// dead-letter list. If the app's dead-letter list is full, the
+	// notification is removed from the retry queue, and a quota exceeded
+	// error is returned.
+	SaveDeadLetter(n *Notification) error
+	GetDeadLetter(id string) (*Notification, error)
+	ListDeadLetters() ([]*Notification, error)
+	DeleteDeadLetter(appID apps.AppID, id string) error
+}
+
+type notificationStore struct {
+	*Service
+}
+
+var _ NotificationStore = (*notificationStore)(nil)
+
+// notificationList is the retry queue, or the dead-letter list. The IDs of
+// the notifications are indexed per app, and the apps that have an index are
+// kept in appsKey. The apps are not removed from it, it is bounded by the
+// number of apps.
+type notificationList struct {
+	appsKey     string
+	indexPrefix string
+	prefix      string
+}
+
+var (
+	notificationQueue = notificationList{
+		appsKey:     config.KVNotificationQueueAppsKey,
+		indexPrefix: config.KVNotificationQueueIndexPrefix,
+		prefix:      config.KVNotificationQueuePrefix,
+	}
+	notificationDeadLetters = notificationList{
+		appsKey:     config.KVNotificationDeadLetterAppsKey,
+		indexPrefix: config.KVNotificationDeadLetterIndexPrefix,
+		prefix:      config.KVNotificationDeadLetterPrefix,
+	}
+)
+
+func (s *notificationStore) Queue(n *Notification) error {
+	return s.save(notificationQueue, n)
+}
+
+func (s *notificationStore) ListQueued() ([]*Notification, error) {
+	return s.list(notificationQueue)
+}
+
+func (s *notificationStore) DeleteQueued(appID apps.AppID, id string) error {
+	return s.delete(notificationQueue, appID, id)
+}
+
+func (s *notificationStore) SaveDeadLetter(n *Notification) error {
+	err := s.save(notificationDeadLetters, n)
+	if err != nil {
+		if errors.Is(err, utils.ErrQuotaExceeded) {
+			if deleteErr := s.DeleteQueued(n.AppID, n.ID); deleteErr != nil {
+				return deleteErr
+			}
+		}
+		return err
+	}
+	return s.DeleteQueued(n.AppID, n.ID)
+}
+
+func (s *notificationStore) GetDeadLetter(id string) (*Notification, error) {
+	return s.get(config.KVNotificationDeadLetterPrefix + id)
+}
+
+func (s *notificationStore) ListDeadLetters() ([]*Notification, error) {
+	return s.list(notificationDeadLetters)
+}
+
+func (s *notificationStore) DeleteDeadLetter(appID apps.AppID, id string) error {
+	return s.delete(notificationDeadLetters, appID, id)
+}
+
+func (s *notificationStore) get(key string) (*Notification, error) {
+	var n *Notification
+	err := s.mm.KV.Get(key, &n)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil {
+		return nil, utils.ErrNotFound
+	}
+	return n, nil
+}
+
+// save indexes a notification in the app's index, unless the index is full,
+// and saves it.
+func (s *notificationStore) save(l notificationList, n *Notification) error {
+	err := s.addToSortedSet(l.appsKey, string(n.AppID), nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to index notification's app")
+	}
+
+	err = s.addToBoundedSortedSet(l.indexPrefix+string(n.AppID), n.ID, MaxQueuedNotificationsPerApp, func(count int) error {
+		return utils.NewQuotaExceededError("%s has %v notifications queued", n.AppID, count)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to index notification")
+	}
+
+	_, err = s.mm.KV.Set(l.prefix+n.ID, n)
+	return err
+}
+
+func (s *notificationStore) delete(l notificationList, appID apps.AppID, id string) error {
+	err := s.mm.KV.Delete(l.prefix + id)
+	if err != nil {
+		return err
+	}
+	_, err = s.removeFromSortedSet(l.indexPrefix+string(appID), id)
+	return err
+}
+
+// list returns the notifications whose IDs are in the apps' indexes.
+func (s *notificationStore) list(l notificationList) ([]*Notification, error) {
+	appIDs, err := s.getSortedSet(l.appsKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list notifications")
+	}
+
+	var all []*Notification
+	for _, appID := range appIDs {
+		ids, err := s.getSortedSet(l.indexPrefix + appID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list notifications of %s", appID)
+		}
+		for _, id := range ids {
+			n, err := s.get(l.prefix + id)
+			if err != nil {
+				if errors.Is(err, utils.ErrNotFound) {
+					// deleted since listed
+					continue
+				}
+				return nil, err
+			}
+			all = append(all, n)
+		}
+	}
+	return all, nil
+}
@@ -0,0 +1,84 @@
+// +build !e2e
+
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestNotificationIndexes(t *testing.T) {
+	mockAPI := &plugintest.API{}
+	kv := memKV(mockAPI)
+	apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+	s := NewService(apiClient, utils.NewTestLogger(), config.NewTestConfigurator(config.Config{}), nil, "", nil)
+
+	ids := func(nn []*Notification) []string {
+		out := []string{}
+		for _, n := range nn {
+			out = append(out, n.ID)
+		}
+		return out
+	}
+
+	require.NoError(t, s.Notification.Queue(&Notification{ID: "id2", AppID: "app2"}))
+	require.NoError(t, s.Notification.Queue(&Notification{ID: "id1", AppID: "app1"}))
+	require.NoError(t, s.Notification.Queue(&Notification{ID: "id3", AppID: "app1"}))
+	queued, err := s.Notification.ListQueued()
+	require.NoError(t, err)
+	require.Equal(t, []string{"id1", "id3", "id2"}, ids(queued))
+	require.Equal(t, `["id1","id3"]`, string(kv[config.KVNotificationQueueIndexPrefix+"app1"]))
+
+	require.NoError(t, s.Notification.SaveDeadLetter(&Notification{ID: "id1", AppID: "app1"}))
+	queued, err = s.Notification.ListQueued()
+	require.NoError(t, err)
+	require.Equal(t, []string{"id3", "id2"}, ids(queued))
+	dead, err := s.Notification.ListDeadLetters()
+	require.NoError(t, err)
+	require.Equal(t, []string{"id1"}, ids(dead))
+
+	require.NoError(t, s.Notification.DeleteDeadLetter("app1", "id1"))
+	require.NoError(t, s.Notification.DeleteQueued("app1", "id3"))
+	require.NoError(t, s.Notification.DeleteQueued("app2", "id2"))
+	queued, err = s.Notification.ListQueued()
+	require.NoError(t, err)
+	require.Empty(t, queued)
+	require.Nil(t, kv[config.KVNotificationQueueIndexPrefix+"app1"])
+
+	t.Run("full", func(t *testing.T) {
+		fullIndex := func(prefix string) []byte {
+			var full []string
+			for i := 0; i < MaxQueuedNotificationsPerApp; i++ {
+				full = append(full, fmt.Sprintf("%s%04d", prefix, i))
+			}
+			data, err := json.Marshal(full)
+			require.NoError(t, err)
+			return data
+		}
+		kv[config.KVNotificationQueueIndexPrefix+"app3"] = fullIndex("full")
+		kv[config.KVNotificationDeadLetterIndexPrefix+"app3"] = fullIndex("dead")
+
+		err := s.Notification.Queue(&Notification{ID: "new", AppID: "app3"})
+		require.ErrorIs(t, err, utils.ErrQuotaExceeded)
+		require.Nil(t, kv[config.KVNotificationQueuePrefix+"new"])
+
+		// A queued notification is updated.
+		require.NoError(t, s.Notification.Queue(&Notification{ID: "full0001", AppID: "app3", Attempts: 2}))
+		require.NotNil(t, kv[config.KVNotificationQueuePrefix+"full0001"])
+
+		// It is dropped if the dead-letter list is full.
+		err = s.Notification.SaveDeadLetter(&Notification{ID: "full0001", AppID: "app3"})
+		require.ErrorIs(t, err, utils.ErrQuotaExceeded)
+		require.Nil(t, kv[config.KVNotificationQueuePrefix+"full0001"])
+		require.Nil(t, kv[config.KVNotificationDeadLetterPrefix+"full0001"])
+	})
+}
@@ -10,6 +10,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/pkg/errors"
+
 	pluginapi "github.com/mattermost/mattermost-plugin-api"
 
 	"github.com/mattermost/mattermost-plugin-apps/server/config"
@@ -21,6 +23,10 @@ type OAuth2Store interface {
 	ValidateStateOnce(urlState, actingUserID string) error
 	SaveUser(botUserID, mattermostUserID string, ref interface{}) error
 	GetUser(botUserID, mattermostUserID string, ref interface{}) error
+
+	// ReencryptUsers re-encrypts the user records of all apps with the
+	// current encryption key.
+	ReencryptUsers() (int, error)
 }
 
 type oauth2Store struct {
@@ -69,7 +75,15 @@ func (s *oauth2Store) SaveUser(botUserID, mattermostUserID string, ref interface
 	if err != nil {
 		return err
 	}
-	_, err = s.mm.KV.Set(userkey, ref)
+	data, err := marshalValue(ref)
+	if err != nil {
+		return err
+	}
+	encrypted, err := s.encrypt(data)
+	if err != nil {
+		return errors.Wrap(err, "failed to encrypt user record")
+	}
+	_, err = s.mm.KV.Set(userkey, encrypted)
 	return err
 }
 
@@ -78,5 +92,53 @@ func (s *oauth2Store) GetUser(botUserID, mattermostUserID string, ref interface{
 	if err != nil {
 		return err
 	}
-	return s.mm.KV.Get(userkey, ref)
+	var data []byte
+	err = s.mm.KV.Get(userkey, &data)
+	if err != nil {
+		return err
+	}
+	decrypted, err := s.decrypt(data)
+	if err != nil {
+		return errors.Wrap(err, "failed to decrypt user record")
+	}
+	return unmarshalValue(decrypted, ref)
+}
+
+// ReencryptUsers re-encrypts the user records of all apps with the current
+// encryption key. It returns how many records were re-encrypted.
+func (s *oauth2Store) ReencryptUsers() (int, error) {
+	keys, err := s.listKeys(config.KVUserPrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, key := range keys {
+		var data []byte
+		err = s.mm.KV.Get(key, &data)
+		if err != nil {
+			return n, err
+		}
+		if data == nil || s.isEncryptedWithCurrentKey(data) {
+			continue
+		}
+		decrypted, err := s.decrypt(data)
+		if err != nil {
+			return n, errors.Wrapf(err, "failed to decrypt user record %s", key)
+		}
+		encrypted, err := s.encrypt(decrypted)
+		if err != nil {
+			return n, err
+		}
+		// If the record was changed concurrently, it is already encrypted with
+		// the current key.
+		saved, err := s.mm.KV.Set(key, encrypted, pluginapi.SetAtomic(data))
+		if err != nil {
+			return n, err
+		}
+		if saved {
+			n++
+		}
+	}
+	return n, nil
 }
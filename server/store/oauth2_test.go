@@ -66,7 +66,8 @@ func TestOAuth2User(t *testing.T) {
 	testDriver := &plugintest.Driver{}
 	s := oauth2Store{
 		Service: &Service{
-			mm: pluginapi.NewClient(testAPI, testDriver),
+			mm:   pluginapi.NewClient(testAPI, testDriver),
+			conf: config.NewTestConfigurator(config.Config{}),
 		},
 	}
 
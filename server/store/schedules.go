@@ -0,0 +1,163 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package store
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+// ScheduleStore keeps the one-time calls that the apps scheduled, and when
+// the schedules declared in the apps' manifests last ran.
+type ScheduleStore interface {
+	// SaveCall saves (or updates) a scheduled call. If the app already has
+	// apps.MaxScheduledCallsPerApp calls scheduled, a new call is not saved,
+	// and a quota exceeded error is returned.
+	SaveCall(c *apps.ScheduledCall) error
+	GetCall(id string) (*apps.ScheduledCall, error)
+	// ListCalls returns the scheduled calls of an app, or of all apps if
+	// appID is empty, by when they are due.
+	ListCalls(appID apps.AppID) ([]*apps.ScheduledCall, error)
+	DeleteCall(appID apps.AppID, id string) error
+
+	// GetLastRuns returns when each of the app's schedules last ran, in
+	// milliseconds since the epoch, by schedule name.
+	GetLastRuns(appID apps.AppID) (map[string]int64, error)
+	SaveLastRuns(appID apps.AppID, lastRuns map[string]int64) error
+	DeleteLastRuns(appID apps.AppID) error
+}
+
+type scheduleStore struct {
+	*Service
+}
+
+var _ ScheduleStore = (*scheduleStore)(nil)
+
+// The IDs of the scheduled calls are indexed per app, the index is also what
+// limits how many calls an app may schedule. The apps that have an index are
+// kept in config.KVScheduledCallAppsKey, and are not removed from it.
+func (s *scheduleStore) SaveCall(c *apps.ScheduledCall) error {
+	err := s.addToSortedSet(config.KVScheduledCallAppsKey, string(c.AppID), nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to index scheduled call's app")
+	}
+	err = s.addToBoundedSortedSet(config.KVScheduledCallIndexPrefix+string(c.AppID), c.ID, apps.MaxScheduledCallsPerApp, func(count int) error {
+		return utils.NewQuotaExceededError("%s already has %v scheduled calls", c.AppID, count)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to index scheduled call")
+	}
+	_, err = s.mm.KV.Set(config.KVScheduledCallPrefix+c.ID, c)
+	return err
+}
+
+func (s *scheduleStore) GetCall(id string) (*apps.ScheduledCall, error) {
+	var c *apps.ScheduledCall
+	err := s.mm.KV.Get(config.KVScheduledCallPrefix+id, &c)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, utils.ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *scheduleStore) ListCalls(appID apps.AppID) ([]*apps.ScheduledCall, error) {
+	appIDs := []string{string(appID)}
+	if appID == "" {
+		var err error
+		appIDs, err = s.getSortedSet(config.KVScheduledCallAppsKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list scheduled calls")
+		}
+	}
+
+	var out []*apps.ScheduledCall
+	for _, appID := range appIDs {
+		ids, err := s.getSortedSet(config.KVScheduledCallIndexPrefix + appID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list scheduled calls of %s", appID)
+		}
+		for _, id := range ids {
+			c, err := s.GetCall(id)
+			if err != nil {
+				if errors.Is(err, utils.ErrNotFound) {
+					// deleted since listed
+					continue
+				}
+				return nil, err
+			}
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].RunAt < out[j].RunAt
+	})
+	return out, nil
+}
+
+func (s *scheduleStore) DeleteCall(appID apps.AppID, id string) error {
+	err := s.mm.KV.Delete(config.KVScheduledCallPrefix + id)
+	if err != nil {
+		return err
+	}
+	_, err = s.removeFromSortedSet(config.KVScheduledCallIndexPrefix+string(appID), id)
+	return err
+}
+
+// migrateCallIndex indexes the calls that were scheduled before the index was
+// kept. The calls are indexed regardless of the limit.
+func (s *scheduleStore) migrateCallIndex() error {
+	keys, err := s.listKeys(config.KVScheduledCallPrefix)
+	if err != nil {
+		return err
+	}
+	byApp := map[apps.AppID][]string{}
+	for _, key := range keys {
+		c, err := s.GetCall(strings.TrimPrefix(key, config.KVScheduledCallPrefix))
+		if err != nil {
+			if errors.Is(err, utils.ErrNotFound) {
+				continue
+			}
+			return err
+		}
+		byApp[c.AppID] = append(byApp[c.AppID], c.ID)
+	}
+	for appID, ids := range byApp {
+		err = s.addToSortedSet(config.KVScheduledCallAppsKey, string(appID), nil)
+		if err != nil {
+			return err
+		}
+		err = s.mergeIntoSortedSet(config.KVScheduledCallIndexPrefix+string(appID), ids)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *scheduleStore) GetLastRuns(appID apps.AppID) (map[string]int64, error) {
+	var lastRuns map[string]int64
+	err := s.mm.KV.Get(config.KVScheduleLastRunsPrefix+string(appID), &lastRuns)
+	if err != nil {
+		return nil, err
+	}
+	return lastRuns, nil
+}
+
+func (s *scheduleStore) SaveLastRuns(appID apps.AppID, lastRuns map[string]int64) error {
+	_, err := s.mm.KV.Set(config.KVScheduleLastRunsPrefix+string(appID), lastRuns)
+	return err
+}
+
+func (s *scheduleStore) DeleteLastRuns(appID apps.AppID) error {
+	return s.mm.KV.Delete(config.KVScheduleLastRunsPrefix + string(appID))
+}
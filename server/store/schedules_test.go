@@ -0,0 +1,79 @@
+// +build !e2e
+
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestScheduledCallIndex(t *testing.T) {
+	mockAPI := &plugintest.API{}
+	kv := memKV(mockAPI)
+	apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+	s := NewService(apiClient, utils.NewTestLogger(), config.NewTestConfigurator(config.Config{}), nil, "", nil)
+
+	ids := func(cc []*apps.ScheduledCall) []string {
+		out := []string{}
+		for _, c := range cc {
+			out = append(out, c.ID)
+		}
+		return out
+	}
+
+	// Scheduled before the index was kept.
+	kv[config.KVScheduledCallPrefix+"old1"] = []byte(`{"id":"old1","app_id":"app1","run_at":3}`)
+	require.NoError(t, s.Schedule.SaveCall(&apps.ScheduledCall{ID: "new1", AppID: "app1", RunAt: 2}))
+	require.NoError(t, s.Schedule.SaveCall(&apps.ScheduledCall{ID: "new2", AppID: "app2", RunAt: 1}))
+
+	calls, err := s.Schedule.ListCalls("")
+	require.NoError(t, err)
+	require.Equal(t, []string{"new2", "new1"}, ids(calls))
+
+	require.NoError(t, s.Migrate())
+	calls, err = s.Schedule.ListCalls("")
+	require.NoError(t, err)
+	require.Equal(t, []string{"new2", "new1", "old1"}, ids(calls))
+	calls, err = s.Schedule.ListCalls("app1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"new1", "old1"}, ids(calls))
+
+	require.NoError(t, s.Schedule.DeleteCall("app1", "old1"))
+	require.NoError(t, s.Schedule.DeleteCall("app1", "new1"))
+	calls, err = s.Schedule.ListCalls("app1")
+	require.NoError(t, err)
+	require.Empty(t, calls)
+	require.NotContains(t, kv, config.KVScheduledCallIndexPrefix+"app1")
+}
+
+func TestScheduledCallLimit(t *testing.T) {
+	mockAPI := &plugintest.API{}
+	memKV(mockAPI)
+	apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+	s := NewService(apiClient, utils.NewTestLogger(), config.NewTestConfigurator(config.Config{}), nil, "", nil)
+
+	for i := 0; i < apps.MaxScheduledCallsPerApp; i++ {
+		require.NoError(t, s.Schedule.SaveCall(&apps.ScheduledCall{ID: fmt.Sprintf("id%v", i), AppID: "app1"}))
+	}
+
+	err := s.Schedule.SaveCall(&apps.ScheduledCall{ID: "more", AppID: "app1"})
+	require.ErrorIs(t, err, utils.ErrQuotaExceeded)
+	_, err = s.Schedule.GetCall("more")
+	require.ErrorIs(t, err, utils.ErrNotFound)
+
+	// An existing call can be updated, and other apps are not limited.
+	require.NoError(t, s.Schedule.SaveCall(&apps.ScheduledCall{ID: "id1", AppID: "app1", RunAt: 1}))
+	require.NoError(t, s.Schedule.SaveCall(&apps.ScheduledCall{ID: "other", AppID: "app2"}))
+
+	require.NoError(t, s.Schedule.DeleteCall("app1", "id1"))
+	require.NoError(t, s.Schedule.SaveCall(&apps.ScheduledCall{ID: "more", AppID: "app1"}))
+}
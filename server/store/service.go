@@ -4,7 +4,10 @@
 package store
 
 import (
+	"bytes"
 	"encoding/ascii85"
+	"encoding/json"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -13,6 +16,7 @@ import (
 	pluginapi "github.com/mattermost/mattermost-plugin-api"
 	"github.com/mattermost/mattermost-server/v5/model"
 
+	"github.com/mattermost/mattermost-plugin-apps/server/clusterevents"
 	"github.com/mattermost/mattermost-plugin-apps/server/config"
 	"github.com/mattermost/mattermost-plugin-apps/upstream/upaws"
 	"github.com/mattermost/mattermost-plugin-apps/utils"
@@ -23,21 +27,33 @@ type Service struct {
 	Subscription SubscriptionStore
 	Manifest     ManifestStore
 	AppKV        AppKVStore
+	UserKV       UserKVStore
 	OAuth2       OAuth2Store
+	Notification NotificationStore
+	Lifecycle    LifecycleStore
+	Schedule     ScheduleStore
+	Job          JobStore
+	Backup       BackupStore
 
-	mm   *pluginapi.Client
-	log  utils.Logger
-	conf config.Service
+	mm            *pluginapi.Client
+	log           utils.Logger
+	conf          config.Service
+	clusterEvents clusterevents.Service
+	kvUsageCache  *kvUsageCache
 
 	aws           upaws.Client
 	s3AssetBucket string
 }
 
-func NewService(mm *pluginapi.Client, log utils.Logger, conf config.Service, aws upaws.Client, s3AssetBucket string) *Service {
+// NewService creates the store. clusterEvents are used to keep the in-memory
+// caches consistent across the cluster; if nil, the data is not cached.
+func NewService(mm *pluginapi.Client, log utils.Logger, conf config.Service, aws upaws.Client, s3AssetBucket string, clusterEvents clusterevents.Service) *Service {
 	s := &Service{
 		mm:            mm,
 		log:           log,
 		conf:          conf,
+		clusterEvents: clusterEvents,
+		kvUsageCache:  newKVUsageCache(),
 		aws:           aws,
 		s3AssetBucket: s3AssetBucket,
 	}
@@ -47,18 +63,256 @@ func NewService(mm *pluginapi.Client, log utils.Logger, conf config.Service, aws
 	s.AppKV = &appKVStore{
 		Service: s,
 	}
-	s.OAuth2 = &oauth2Store{
+	s.UserKV = &userKVStore{
 		Service: s,
 	}
-	s.Subscription = &subscriptionStore{
+	s.OAuth2 = &oauth2Store{
 		Service: s,
 	}
+	s.Subscription = newSubscriptionStore(s)
 	s.Manifest = &manifestStore{
 		Service: s,
 	}
+	s.Notification = &notificationStore{
+		Service: s,
+	}
+	s.Lifecycle = &lifecycleStore{
+		Service: s,
+	}
+	s.Schedule = &scheduleStore{
+		Service: s,
+	}
+	s.Job = &jobStore{
+		Service: s,
+	}
+	s.Backup = &backupStore{
+		Service: s,
+	}
 	return s
 }
 
+// listKeys returns all keys that start with any of the prefixes. ListKeys
+// applies the prefix filter to each page, so the pages are filtered here to
+// detect the last one.
+func (s *Service) listKeys(prefixes ...string) ([]string, error) {
+	var matching []string
+	for i := 0; ; i++ {
+		keys, err := s.mm.KV.ListKeys(i, keysPerPage)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list keys - page, %d", i)
+		}
+
+		for _, k := range keys {
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(k, prefix) {
+					matching = append(matching, k)
+					break
+				}
+			}
+		}
+
+		if len(keys) < keysPerPage {
+			return matching, nil
+		}
+	}
+}
+
+// maxAtomicUpdateAttempts is how many times updateAtomic tries to apply a
+// change before giving up, when the value keeps being changed concurrently.
+const maxAtomicUpdateAttempts = 10
+
+// updateAtomic reads the raw value of a key, and replaces it with the value
+// returned by update, using compare-and-set. If the value was changed
+// concurrently, it is read and updated again. If update returns the value
+// unchanged, nothing is written; a nil value deletes the key. The value that
+// was written is returned.
+func (s *Service) updateAtomic(key string, update func(data []byte) ([]byte, error)) ([]byte, error) {
+	for i := 0; i < maxAtomicUpdateAttempts; i++ {
+		var data []byte
+		err := s.mm.KV.Get(key, &data)
+		if err != nil {
+			return nil, err
+		}
+
+		updated, err := update(data)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(data, updated) {
+			return data, nil
+		}
+
+		var oldValue interface{}
+		if data != nil {
+			oldValue = data
+		}
+		saved, err := s.mm.KV.Set(key, updated, pluginapi.SetAtomic(oldValue))
+		if err != nil {
+			return nil, err
+		}
+		if saved {
+			return updated, nil
+		}
+	}
+	return nil, errors.Errorf("failed to update %s: too many concurrent changes", key)
+}
+
+// getSortedSet returns the sorted set of strings stored under key.
+func (s *Service) getSortedSet(key string) ([]string, error) {
+	var values []string
+	err := s.mm.KV.Get(key, &values)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// addToSortedSet adds a value to the sorted set of strings stored under key.
+// If the set does not exist yet, onCreate (if not nil) is called before it is
+// created.
+func (s *Service) addToSortedSet(key, value string, onCreate func() error) error {
+	_, err := s.updateAtomic(key, func(data []byte) ([]byte, error) {
+		var values []string
+		if len(data) > 0 {
+			err := json.Unmarshal(data, &values)
+			if err != nil {
+				return nil, err
+			}
+		} else if onCreate != nil {
+			err := onCreate()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		i := sort.SearchStrings(values, value)
+		if i < len(values) && values[i] == value {
+			return data, nil
+		}
+		values = append(values, "")
+		copy(values[i+1:], values[i:])
+		values[i] = value
+		return json.Marshal(values)
+	})
+	return err
+}
+
+// addToBoundedSortedSet adds a value to the sorted set of strings stored under
+// key, unless the set already has limit values, then the error returned by
+// full is returned. Adding a value that is already in the set always succeeds.
+func (s *Service) addToBoundedSortedSet(key, value string, limit int, full func(n int) error) error {
+	_, err := s.updateAtomic(key, func(data []byte) ([]byte, error) {
+		var values []string
+		if len(data) > 0 {
+			err := json.Unmarshal(data, &values)
+			if err != nil {
+				return nil, err
+			}
+		}
+		i := sort.SearchStrings(values, value)
+		if i < len(values) && values[i] == value {
+			return data, nil
+		}
+		if len(values) >= limit {
+			return nil, full(len(values))
+		}
+		values = append(values, "")
+		copy(values[i+1:], values[i:])
+		values[i] = value
+		return json.Marshal(values)
+	})
+	return err
+}
+
+// removeFromSortedSet removes a value from the sorted set of strings stored
+// under key. An empty set is deleted, and true is returned.
+func (s *Service) removeFromSortedSet(key, value string) (bool, error) {
+	empty := false
+	_, err := s.updateAtomic(key, func(data []byte) ([]byte, error) {
+		empty = len(data) == 0
+		if empty {
+			return data, nil
+		}
+		var values []string
+		err := json.Unmarshal(data, &values)
+		if err != nil {
+			return nil, err
+		}
+
+		i := sort.SearchStrings(values, value)
+		if i == len(values) || values[i] != value {
+			return data, nil
+		}
+		values = append(values[:i], values[i+1:]...)
+		if len(values) == 0 {
+			empty = true
+			return nil, nil
+		}
+		return json.Marshal(values)
+	})
+	return empty, err
+}
+
+// mergeIntoSortedSet adds values to the sorted set of strings stored under
+// key.
+func (s *Service) mergeIntoSortedSet(key string, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	_, err := s.updateAtomic(key, func(data []byte) ([]byte, error) {
+		var existing []string
+		if len(data) > 0 {
+			err := json.Unmarshal(data, &existing)
+			if err != nil {
+				return nil, err
+			}
+		}
+		merged := map[string]bool{}
+		for _, v := range existing {
+			merged[v] = true
+		}
+		for _, v := range values {
+			merged[v] = true
+		}
+		if len(merged) == len(existing) {
+			return data, nil
+		}
+		updated := make([]string, 0, len(merged))
+		for v := range merged {
+			updated = append(updated, v)
+		}
+		sort.Strings(updated)
+		return json.Marshal(updated)
+	})
+	return err
+}
+
+// marshalValue returns the bytes that the KV store keeps for ref: []byte is
+// kept as is, anything else is JSON marshaled.
+func marshalValue(ref interface{}) ([]byte, error) {
+	switch v := ref.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	default:
+		return json.Marshal(ref)
+	}
+}
+
+// unmarshalValue reads the bytes kept in the KV store into ref, the same way
+// as pluginapi's KV.Get.
+func unmarshalValue(data []byte, ref interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if bref, ok := ref.(*[]byte); ok {
+		*bref = data
+		return nil
+	}
+	return json.Unmarshal(data, ref)
+}
+
 func (s *Service) hashkey(globalNamespace, botUserID, appNamespace, key string) (string, error) {
 	gns := []byte(globalNamespace)
 	b := []byte(botUserID)
@@ -11,7 +11,7 @@ import (
 )
 
 func TestHashkey(t *testing.T) {
-	s := NewService(nil, utils.NewTestLogger(), nil, nil, "")
+	s := NewService(nil, utils.NewTestLogger(), nil, nil, "", nil)
 	for _, tc := range []struct {
 		name                                string
 		globalPrefix, botUserID, prefix, id string
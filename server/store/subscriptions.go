@@ -4,6 +4,10 @@
 package store
 
 import (
+	"encoding/json"
+	"strings"
+	"sync"
+
 	"github.com/pkg/errors"
 
 	"github.com/mattermost/mattermost-plugin-apps/apps"
@@ -11,24 +15,64 @@ import (
 	"github.com/mattermost/mattermost-plugin-apps/utils"
 )
 
+// clusterEventInvalidateSubscriptions is broadcast when the subscriptions
+// stored under a key change, for the nodes to reload them.
+const clusterEventInvalidateSubscriptions = "invalidate_subscriptions"
+
+// SubscriptionStore stores the subscriptions in a KV key per subject and scope
+// (team or channel), so that the notifications for an event need to read only
+// one key, and the concurrent changes in different scopes do not conflict. The
+// keys are updated with compare-and-set. If the store has cluster events, all
+// subscriptions are also kept in memory on each node, and Get does not read
+// from the KV store.
 type SubscriptionStore interface {
 	Get(subject apps.Subject, teamID, channelID string) ([]*apps.Subscription, error)
 	Save(sub *apps.Subscription) error
 	Delete(*apps.Subscription) error
+
+	// ListByApp returns all subscriptions of an app, DeleteByApp deletes them.
+	ListByApp(appID apps.AppID) ([]*apps.Subscription, error)
+	DeleteByApp(appID apps.AppID) error
+
+	// ListSubscribedChannels returns the IDs of the channels that have
+	// subscriptions to a channel-scoped subject.
+	ListSubscribedChannels(subject apps.Subject) ([]string, error)
 }
 
 type subscriptionStore struct {
 	*Service
+
+	// cache holds the subscriptions by KV key, once loaded. The subscriptions
+	// in it must not be modified.
+	cacheMutex sync.RWMutex
+	cache      map[string][]*apps.Subscription
 }
 
 var _ SubscriptionStore = (*subscriptionStore)(nil)
 
+func newSubscriptionStore(s *Service) *subscriptionStore {
+	store := &subscriptionStore{
+		Service: s,
+	}
+	if s.clusterEvents != nil {
+		s.clusterEvents.Handle(clusterEventInvalidateSubscriptions, store.onInvalidateEvent)
+	}
+	return store
+}
+
 func subsKey(subject apps.Subject, teamID, channelID string) string {
 	idSuffix := ""
 	switch subject {
 	case apps.SubjectUserJoinedChannel,
 		apps.SubjectUserLeftChannel,
-		apps.SubjectPostCreated:
+		apps.SubjectPostCreated,
+		apps.SubjectPostUpdated,
+		apps.SubjectPostDeleted,
+		apps.SubjectReactionAdded,
+		apps.SubjectReactionRemoved,
+		apps.SubjectChannelUpdated,
+		apps.SubjectChannelArchived,
+		apps.SubjectChannelRestored:
 		idSuffix = "." + channelID
 	case apps.SubjectUserJoinedTeam,
 		apps.SubjectUserLeftTeam,
@@ -38,73 +82,384 @@ func subsKey(subject apps.Subject, teamID, channelID string) string {
 	return config.KVSubPrefix + string(subject) + idSuffix
 }
 
-func (s subscriptionStore) Delete(sub *apps.Subscription) error {
+func appIndexKey(appID apps.AppID) string {
+	return config.KVSubAppIndexPrefix + string(appID)
+}
+
+func (s *subscriptionStore) Delete(sub *apps.Subscription) error {
 	key := subsKey(sub.Subject, sub.TeamID, sub.ChannelID)
-	// get all subscriptions for the subject
-	var subs []*apps.Subscription
-	err := s.mm.KV.Get(key, &subs)
+	var updated []*apps.Subscription
+	found := false
+	err := s.update(key, func(subs []*apps.Subscription) ([]*apps.Subscription, error) {
+		for i, current := range subs {
+			if !sub.EqualScope(current) {
+				continue
+			}
+
+			// sub exists and needs to be deleted
+			found = true
+			updated = append(append([]*apps.Subscription{}, subs[:i]...), subs[i+1:]...)
+			return updated, nil
+		}
+		found = false
+		return nil, utils.ErrNotFound
+	})
 	if err != nil {
+		if found {
+			return errors.Wrap(err, "failed to save subscriptions")
+		}
 		return err
 	}
 
-	for i, current := range subs {
-		if !sub.EqualScope(current) {
-			continue
+	if !hasApp(updated, sub.AppID) {
+		err = s.removeFromAppIndex(sub.AppID, key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *subscriptionStore) Get(subject apps.Subject, teamID, channelID string) ([]*apps.Subscription, error) {
+	subs, err := s.getKey(subsKey(subject, teamID, channelID))
+	if err != nil {
+		return nil, err
+	}
+	if len(subs) == 0 {
+		return nil, utils.ErrNotFound
+	}
+	return subs, nil
+}
+
+func (s *subscriptionStore) Save(sub *apps.Subscription) error {
+	key := subsKey(sub.Subject, sub.TeamID, sub.ChannelID)
+	err := s.update(key, func(subs []*apps.Subscription) ([]*apps.Subscription, error) {
+		updated := append([]*apps.Subscription{}, subs...)
+		for i, s := range updated {
+			if s.EqualScope(sub) {
+				updated[i] = sub
+				return updated, nil
+			}
 		}
+		return append(updated, sub), nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.addToAppIndex(sub.AppID, key)
+}
 
-		// sub exists and needs to be deleted
-		updated := subs[:i]
-		if i < len(subs) {
-			updated = append(updated, subs[i+1:]...)
+func (s *subscriptionStore) ListByApp(appID apps.AppID) ([]*apps.Subscription, error) {
+	keys, err := s.getAppIndex(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []*apps.Subscription{}
+	for _, key := range keys {
+		subs, err := s.getKey(key)
+		if err != nil {
+			return nil, err
 		}
+		for _, sub := range subs {
+			if sub.AppID == appID {
+				out = append(out, sub)
+			}
+		}
+	}
+	return out, nil
+}
 
-		_, err = s.mm.KV.Set(key, updated)
+func (s *subscriptionStore) DeleteByApp(appID apps.AppID) error {
+	keys, err := s.getAppIndex(appID)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		err = s.update(key, func(subs []*apps.Subscription) ([]*apps.Subscription, error) {
+			updated := []*apps.Subscription{}
+			for _, sub := range subs {
+				if sub.AppID != appID {
+					updated = append(updated, sub)
+				}
+			}
+			return updated, nil
+		})
 		if err != nil {
 			return errors.Wrap(err, "failed to save subscriptions")
 		}
-		return nil
 	}
 
-	return utils.ErrNotFound
+	return s.mm.KV.Delete(appIndexKey(appID))
+}
+
+func (s *subscriptionStore) ListSubscribedChannels(subject apps.Subject) ([]string, error) {
+	prefix := subsKey(subject, "", "")
+	keys, err := s.listSubscriptionKeys(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var channelIDs []string
+	for _, key := range keys {
+		channelID := strings.TrimPrefix(key, prefix)
+		_, err = s.Get(subject, "", channelID)
+		switch {
+		case errors.Is(err, utils.ErrNotFound):
+			// All subscriptions were deleted.
+			continue
+		case err != nil:
+			return nil, err
+		}
+		channelIDs = append(channelIDs, channelID)
+	}
+	return channelIDs, nil
+}
+
+// update applies a change to the subscriptions stored under a key, atomically.
+// f must not modify the subscriptions it is passed. The nodes are notified to
+// reload the key.
+func (s *subscriptionStore) update(key string, f func([]*apps.Subscription) ([]*apps.Subscription, error)) error {
+	_, err := s.updateAtomic(key, func(data []byte) ([]byte, error) {
+		var subs []*apps.Subscription
+		if len(data) > 0 {
+			err := json.Unmarshal(data, &subs)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to unmarshal value for key %s", key)
+			}
+		}
+		updated, err := f(subs)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(updated)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidate(key)
+	return nil
 }
 
-func (s subscriptionStore) Get(subject apps.Subject, teamID, channelID string) ([]*apps.Subscription, error) {
-	key := subsKey(subject, teamID, channelID)
+// getKey returns the subscriptions stored under a key, from the cache if it
+// is available.
+func (s *subscriptionStore) getKey(key string) ([]*apps.Subscription, error) {
+	if s.clusterEvents != nil {
+		cache, err := s.getCache()
+		if err == nil {
+			return cache[key], nil
+		}
+		s.log.WithError(err).Warnf("Failed to load subscriptions, reading from the KV store")
+	}
+
 	var subs []*apps.Subscription
 	err := s.mm.KV.Get(key, &subs)
 	if err != nil {
 		return nil, err
 	}
-	if len(subs) == 0 {
-		return nil, utils.ErrNotFound
-	}
 	return subs, nil
 }
 
-func (s subscriptionStore) Save(sub *apps.Subscription) error {
-	key := subsKey(sub.Subject, sub.TeamID, sub.ChannelID)
-	// get all subscriptions for the subject
+func (s *subscriptionStore) listSubscriptionKeys(prefix string) ([]string, error) {
+	if s.clusterEvents != nil {
+		cache, err := s.getCache()
+		if err == nil {
+			var keys []string
+			for key := range cache {
+				if strings.HasPrefix(key, prefix) {
+					keys = append(keys, key)
+				}
+			}
+			return keys, nil
+		}
+		s.log.WithError(err).Warnf("Failed to load subscriptions, reading from the KV store")
+	}
+	return s.listKeys(prefix)
+}
+
+// getCache returns the cached subscriptions, loading all of them from the KV
+// store the first time. The returned map must not be modified.
+func (s *subscriptionStore) getCache() (map[string][]*apps.Subscription, error) {
+	s.cacheMutex.RLock()
+	cache := s.cache
+	s.cacheMutex.RUnlock()
+	if cache != nil {
+		return cache, nil
+	}
+
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+	if s.cache != nil {
+		return s.cache, nil
+	}
+
+	keys, err := s.listKeys(config.KVSubPrefix)
+	if err != nil {
+		return nil, err
+	}
+	cache = map[string][]*apps.Subscription{}
+	for _, key := range keys {
+		var subs []*apps.Subscription
+		err = s.mm.KV.Get(key, &subs)
+		if err != nil {
+			return nil, err
+		}
+		if len(subs) > 0 {
+			cache[key] = subs
+		}
+	}
+	s.cache = cache
+	return cache, nil
+}
+
+type invalidateSubscriptionsEvent struct {
+	Key string `json:"key"`
+}
+
+func (s *subscriptionStore) invalidate(key string) {
+	if s.clusterEvents == nil {
+		return
+	}
+	err := s.clusterEvents.Broadcast(clusterEventInvalidateSubscriptions, invalidateSubscriptionsEvent{
+		Key: key,
+	})
+	if err != nil {
+		s.log.WithError(err).Warnw("Failed to invalidate cached subscriptions on other nodes",
+			"key", key)
+	}
+}
+
+// onInvalidateEvent reloads the subscriptions stored under a key. The cache is
+// copied on write, so that the readers need not hold the lock. If the key can
+// not be read, the whole cache is dropped, to be loaded again on next use.
+func (s *subscriptionStore) onInvalidateEvent(data []byte) {
+	ev := invalidateSubscriptionsEvent{}
+	err := json.Unmarshal(data, &ev)
+	if err != nil {
+		s.log.WithError(err).Warnf("Failed to decode subscriptions invalidation event")
+		return
+	}
+
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+	if s.cache == nil {
+		return
+	}
+
 	var subs []*apps.Subscription
-	err := s.mm.KV.Get(key, &subs)
+	err = s.mm.KV.Get(ev.Key, &subs)
+	if err != nil {
+		s.log.WithError(err).Warnw("Failed to reload subscriptions", "key", ev.Key)
+		s.cache = nil
+		return
+	}
+
+	updated := make(map[string][]*apps.Subscription, len(s.cache)+1)
+	for key, v := range s.cache {
+		updated[key] = v
+	}
+	if len(subs) > 0 {
+		updated[ev.Key] = subs
+	} else {
+		delete(updated, ev.Key)
+	}
+	s.cache = updated
+}
+
+// migrateAppIndexes indexes the subscriptions that were saved before the
+// per-app indexes were kept.
+func (s *subscriptionStore) migrateAppIndexes() error {
+	keys, err := s.listKeys(config.KVSubPrefix)
 	if err != nil {
 		return err
 	}
 
-	add := true
-	for i, s := range subs {
-		if s.EqualScope(sub) {
-			subs[i] = sub
-			add = false
-			break
+	keysByApp := map[apps.AppID][]string{}
+	for _, key := range keys {
+		var subs []*apps.Subscription
+		err = s.mm.KV.Get(key, &subs)
+		if err != nil {
+			return err
+		}
+		for _, sub := range subs {
+			keysByApp[sub.AppID] = append(keysByApp[sub.AppID], key)
 		}
 	}
-	if add {
-		subs = append(subs, sub)
+
+	for appID, appKeys := range keysByApp {
+		err = s.mergeIntoSortedSet(appIndexKey(appID), appKeys)
+		if err != nil {
+			return errors.Wrapf(err, "failed to index the subscriptions of %s", appID)
+		}
 	}
+	return nil
+}
 
-	_, err = s.mm.KV.Set(key, subs)
+func (s *subscriptionStore) getAppIndex(appID apps.AppID) ([]string, error) {
+	var keys []string
+	err := s.mm.KV.Get(appIndexKey(appID), &keys)
 	if err != nil {
-		return err
+		return nil, errors.Wrapf(err, "failed to get the subscriptions index for %s", appID)
+	}
+	return keys, nil
+}
+
+func (s *subscriptionStore) addToAppIndex(appID apps.AppID, key string) error {
+	return s.updateAppIndex(appID, func(keys []string) []string {
+		for _, k := range keys {
+			if k == key {
+				return keys
+			}
+		}
+		return append(keys, key)
+	})
+}
+
+func (s *subscriptionStore) removeFromAppIndex(appID apps.AppID, key string) error {
+	return s.updateAppIndex(appID, func(keys []string) []string {
+		updated := []string{}
+		for _, k := range keys {
+			if k != key {
+				updated = append(updated, k)
+			}
+		}
+		return updated
+	})
+}
+
+// updateAppIndex applies a change to an app's index atomically. An empty
+// index is deleted.
+func (s *subscriptionStore) updateAppIndex(appID apps.AppID, f func([]string) []string) error {
+	_, err := s.updateAtomic(appIndexKey(appID), func(data []byte) ([]byte, error) {
+		var keys []string
+		if len(data) > 0 {
+			err := json.Unmarshal(data, &keys)
+			if err != nil {
+				return nil, err
+			}
+		}
+		updated := f(keys)
+		if len(updated) == len(keys) {
+			return data, nil
+		}
+		if len(updated) == 0 {
+			return nil, nil
+		}
+		return json.Marshal(updated)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the subscriptions index for %s", appID)
 	}
 	return nil
 }
+
+func hasApp(subs []*apps.Subscription, appID apps.AppID) bool {
+	for _, sub := range subs {
+		if sub.AppID == appID {
+			return true
+		}
+	}
+	return false
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
 
 	"github.com/mattermost/mattermost-plugin-apps/apps"
+	"github.com/mattermost/mattermost-plugin-apps/server/clusterevents"
 	"github.com/mattermost/mattermost-plugin-apps/server/config"
 	"github.com/mattermost/mattermost-plugin-apps/utils"
 )
@@ -27,7 +28,7 @@ func TestDeleteSub(t *testing.T) {
 
 	apiClient := pluginapi.NewClient(mockAPI, mockDriver)
 	conf := config.NewService(apiClient, utils.NewTestLogger(), config.BuildConfig{}, botID)
-	s := NewService(apiClient, utils.NewTestLogger(), conf, nil, "")
+	s := NewService(apiClient, utils.NewTestLogger(), conf, nil, "", nil)
 
 	toDelete := apps.Subscription{
 		Subject:   "user_joined_channel",
@@ -122,6 +123,8 @@ func TestDeleteSub(t *testing.T) {
 	t.Run("subscription deleted", func(t *testing.T) {
 		mockAPI.On("KVGet", subKey).Return(storedSubsWithToDeleteBytes, nil).Times(1)
 		mockAPI.On("KVSetWithOptions", subKey, storedSubsBytes, mock.Anything).Return(true, nil).Times(1)
+		mockAPI.On("KVGet", "subapp.app-id").Return([]byte(`["`+subKey+`"]`), nil).Times(1)
+		mockAPI.On("KVSetWithOptions", "subapp.app-id", []byte(nil), mock.Anything).Return(true, nil).Times(1)
 		err := s.Subscription.Delete(&toDelete)
 		require.NoError(t, err)
 	})
@@ -136,7 +139,7 @@ func TestGetSubs(t *testing.T) {
 
 	apiClient := pluginapi.NewClient(mockAPI, mockDriver)
 	conf := config.NewService(apiClient, utils.NewTestLogger(), config.BuildConfig{}, botID)
-	s := NewService(apiClient, utils.NewTestLogger(), conf, nil, "")
+	s := NewService(apiClient, utils.NewTestLogger(), conf, nil, "", nil)
 
 	emptySubs := []*apps.Subscription{}
 	emptySubsBytes, _ := json.Marshal(emptySubs)
@@ -201,7 +204,7 @@ func TestStoreSub(t *testing.T) {
 
 	apiClient := pluginapi.NewClient(mockAPI, mockDriver)
 	conf := config.NewService(apiClient, utils.NewTestLogger(), config.BuildConfig{}, botID)
-	s := NewService(apiClient, utils.NewTestLogger(), conf, nil, "")
+	s := NewService(apiClient, utils.NewTestLogger(), conf, nil, "", nil)
 
 	toStore := apps.Subscription{
 		Subject:   "user_joined_channel",
@@ -271,6 +274,8 @@ func TestStoreSub(t *testing.T) {
 	t.Run("no value for subs key", func(t *testing.T) {
 		mockAPI.On("KVGet", subKey).Return(nil, nil).Times(1)
 		mockAPI.On("KVSetWithOptions", subKey, emptySubsWithToStoreBytes, mock.Anything).Return(true, nil).Times(1)
+		mockAPI.On("KVGet", "subapp.app-id").Return(nil, nil).Times(1)
+		mockAPI.On("KVSetWithOptions", "subapp.app-id", []byte(`["`+subKey+`"]`), mock.Anything).Return(true, nil).Times(1)
 		err := s.Subscription.Save(&toStore)
 		require.NoError(t, err)
 	})
@@ -278,6 +283,8 @@ func TestStoreSub(t *testing.T) {
 	t.Run("empty list for subs key", func(t *testing.T) {
 		mockAPI.On("KVGet", subKey).Return(emptySubsBytes, nil).Times(1)
 		mockAPI.On("KVSetWithOptions", subKey, emptySubsWithToStoreBytes, mock.Anything).Return(true, nil).Times(1)
+		mockAPI.On("KVGet", "subapp.app-id").Return(nil, nil).Times(1)
+		mockAPI.On("KVSetWithOptions", "subapp.app-id", []byte(`["`+subKey+`"]`), mock.Anything).Return(true, nil).Times(1)
 		err := s.Subscription.Save(&toStore)
 		require.NoError(t, err)
 	})
@@ -293,11 +300,183 @@ func TestStoreSub(t *testing.T) {
 	t.Run("subscription stored", func(t *testing.T) {
 		mockAPI.On("KVGet", subKey).Return(storedSubsBytes, nil).Times(1)
 		mockAPI.On("KVSetWithOptions", subKey, storedSubsWithToStoreBytes, mock.Anything).Return(true, nil).Times(1)
+		mockAPI.On("KVGet", "subapp.app-id").Return(nil, nil).Times(1)
+		mockAPI.On("KVSetWithOptions", "subapp.app-id", []byte(`["`+subKey+`"]`), mock.Anything).Return(true, nil).Times(1)
 		err := s.Subscription.Save(&toStore)
 		require.NoError(t, err)
 	})
 }
 
+func TestListSubsByApp(t *testing.T) {
+	mockAPI := &plugintest.API{}
+	defer mockAPI.AssertExpectations(t)
+	apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+	conf := config.NewService(apiClient, utils.NewTestLogger(), config.BuildConfig{}, "bot-id")
+	s := NewService(apiClient, utils.NewTestLogger(), conf, nil, "", nil)
+
+	channelSubs := []*apps.Subscription{
+		{Subject: "user_joined_channel", ChannelID: "channel-id", AppID: "test1"},
+		{Subject: "user_joined_channel", ChannelID: "channel-id", AppID: "app-id"},
+	}
+	channelSubsBytes, _ := json.Marshal(channelSubs)
+	globalSubs := []*apps.Subscription{
+		{Subject: "user_created", AppID: "app-id"},
+	}
+	globalSubsBytes, _ := json.Marshal(globalSubs)
+
+	t.Run("no subscriptions", func(t *testing.T) {
+		mockAPI.On("KVGet", "subapp.app-id").Return(nil, nil).Times(1)
+		subs, err := s.Subscription.ListByApp("app-id")
+		require.NoError(t, err)
+		require.Empty(t, subs)
+	})
+
+	t.Run("subscriptions listed", func(t *testing.T) {
+		mockAPI.On("KVGet", "subapp.app-id").Return([]byte(`["sub.user_joined_channel.channel-id","sub.user_created"]`), nil).Times(1)
+		mockAPI.On("KVGet", "sub.user_joined_channel.channel-id").Return(channelSubsBytes, nil).Times(1)
+		mockAPI.On("KVGet", "sub.user_created").Return(globalSubsBytes, nil).Times(1)
+		subs, err := s.Subscription.ListByApp("app-id")
+		require.NoError(t, err)
+		require.Equal(t, []*apps.Subscription{channelSubs[1], globalSubs[0]}, subs)
+	})
+
+	t.Run("saved before the index was kept", func(t *testing.T) {
+		memAPI := &plugintest.API{}
+		kv := memKV(memAPI)
+		s := NewService(pluginapi.NewClient(memAPI, &plugintest.Driver{}), utils.NewTestLogger(), conf, nil, "", nil)
+		kv["sub.user_joined_channel.channel-id"] = channelSubsBytes
+		kv["sub.user_created"] = globalSubsBytes
+
+		subs, err := s.Subscription.ListByApp("app-id")
+		require.NoError(t, err)
+		require.Empty(t, subs)
+
+		require.NoError(t, s.Migrate())
+		subs, err = s.Subscription.ListByApp("app-id")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*apps.Subscription{channelSubs[1], globalSubs[0]}, subs)
+		subs, err = s.Subscription.ListByApp("test1")
+		require.NoError(t, err)
+		require.Equal(t, []*apps.Subscription{channelSubs[0]}, subs)
+	})
+}
+
+func TestDeleteSubsByApp(t *testing.T) {
+	mockAPI := &plugintest.API{}
+	defer mockAPI.AssertExpectations(t)
+	apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+	conf := config.NewService(apiClient, utils.NewTestLogger(), config.BuildConfig{}, "bot-id")
+	s := NewService(apiClient, utils.NewTestLogger(), conf, nil, "", nil)
+
+	channelSubs := []*apps.Subscription{
+		{Subject: "user_joined_channel", ChannelID: "channel-id", AppID: "test1"},
+		{Subject: "user_joined_channel", ChannelID: "channel-id", AppID: "app-id"},
+	}
+	channelSubsBytes, _ := json.Marshal(channelSubs)
+	remainingBytes, _ := json.Marshal(channelSubs[:1])
+	globalSubsBytes, _ := json.Marshal([]*apps.Subscription{
+		{Subject: "user_created", AppID: "app-id"},
+	})
+
+	mockAPI.On("KVGet", "subapp.app-id").Return([]byte(`["sub.user_joined_channel.channel-id","sub.user_created"]`), nil).Times(1)
+	mockAPI.On("KVGet", "sub.user_joined_channel.channel-id").Return(channelSubsBytes, nil).Times(1)
+	mockAPI.On("KVSetWithOptions", "sub.user_joined_channel.channel-id", remainingBytes, mock.Anything).Return(true, nil).Times(1)
+	mockAPI.On("KVGet", "sub.user_created").Return(globalSubsBytes, nil).Times(1)
+	mockAPI.On("KVSetWithOptions", "sub.user_created", []byte(`[]`), mock.Anything).Return(true, nil).Times(1)
+	mockAPI.On("KVSetWithOptions", "subapp.app-id", []byte(nil), mock.Anything).Return(true, nil).Times(1)
+
+	err := s.Subscription.DeleteByApp("app-id")
+	require.NoError(t, err)
+}
+
+func TestSubscriptionCache(t *testing.T) {
+	mockAPI := &plugintest.API{}
+	defer mockAPI.AssertExpectations(t)
+	apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+	conf := config.NewService(apiClient, utils.NewTestLogger(), config.BuildConfig{}, "bot-id")
+	clusterEvents := clusterevents.NewService(nil, utils.NewTestLogger())
+	s := NewService(apiClient, utils.NewTestLogger(), conf, nil, "", clusterEvents)
+
+	subKey := "sub.post_created.channel-id"
+	stored := []*apps.Subscription{
+		{Subject: "post_created", ChannelID: "channel-id", AppID: "test1"},
+	}
+	storedBytes, _ := json.Marshal(stored)
+	toStore := &apps.Subscription{Subject: "post_created", ChannelID: "channel-id", AppID: "app-id"}
+	updatedBytes, _ := json.Marshal(append(stored, toStore))
+
+	// The subscriptions are loaded once, on first use.
+	mockAPI.On("KVList", 0, keysPerPage).Return([]string{subKey, "subapp.test1", "other"}, nil).Once()
+	mockAPI.On("KVGet", subKey).Return(storedBytes, nil).Once()
+	for i := 0; i < 3; i++ {
+		subs, err := s.Subscription.Get("post_created", "", "channel-id")
+		require.NoError(t, err)
+		require.Equal(t, stored, subs)
+	}
+	_, err := s.Subscription.Get("post_created", "", "other-channel-id")
+	require.Equal(t, utils.ErrNotFound, err)
+
+	channelIDs, err := s.Subscription.ListSubscribedChannels("post_created")
+	require.NoError(t, err)
+	require.Equal(t, []string{"channel-id"}, channelIDs)
+
+	// Saving a subscription reloads the key.
+	mockAPI.On("KVGet", subKey).Return(storedBytes, nil).Once()
+	mockAPI.On("KVSetWithOptions", subKey, updatedBytes, model.PluginKVSetOptions{
+		Atomic:   true,
+		OldValue: storedBytes,
+	}).Return(true, nil).Once()
+	mockAPI.On("KVGet", subKey).Return(updatedBytes, nil).Once()
+	mockAPI.On("KVGet", "subapp.app-id").Return(nil, nil).Once()
+	mockAPI.On("KVSetWithOptions", "subapp.app-id", []byte(`["`+subKey+`"]`), model.PluginKVSetOptions{
+		Atomic: true,
+	}).Return(true, nil).Once()
+	err = s.Subscription.Save(toStore)
+	require.NoError(t, err)
+
+	subs, err := s.Subscription.Get("post_created", "", "channel-id")
+	require.NoError(t, err)
+	require.Equal(t, []*apps.Subscription{stored[0], toStore}, subs)
+
+	// A change on another node reloads the key.
+	mockAPI.On("KVGet", subKey).Return(nil, nil).Once()
+	clusterEvents.OnPluginClusterEvent(model.PluginClusterEvent{
+		Id:   clusterEventInvalidateSubscriptions,
+		Data: []byte(`{"key":"` + subKey + `"}`),
+	})
+	_, err = s.Subscription.Get("post_created", "", "channel-id")
+	require.Equal(t, utils.ErrNotFound, err)
+}
+
+func TestSaveSubConflict(t *testing.T) {
+	mockAPI := &plugintest.API{}
+	defer mockAPI.AssertExpectations(t)
+	apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+	conf := config.NewService(apiClient, utils.NewTestLogger(), config.BuildConfig{}, "bot-id")
+	s := NewService(apiClient, utils.NewTestLogger(), conf, nil, "", nil)
+
+	subKey := "sub.user_created"
+	toStore := &apps.Subscription{Subject: "user_created", AppID: "app-id"}
+	concurrent := &apps.Subscription{Subject: "user_created", AppID: "test1"}
+	concurrentBytes, _ := json.Marshal([]*apps.Subscription{concurrent})
+	toStoreBytes, _ := json.Marshal([]*apps.Subscription{toStore})
+	bothBytes, _ := json.Marshal([]*apps.Subscription{concurrent, toStore})
+
+	// Another subscription is saved concurrently, so the first write fails and
+	// is retried with the new value.
+	mockAPI.On("KVGet", subKey).Return(nil, nil).Once()
+	mockAPI.On("KVSetWithOptions", subKey, toStoreBytes, mock.Anything).Return(false, nil).Once()
+	mockAPI.On("KVGet", subKey).Return(concurrentBytes, nil).Once()
+	mockAPI.On("KVSetWithOptions", subKey, bothBytes, model.PluginKVSetOptions{
+		Atomic:   true,
+		OldValue: concurrentBytes,
+	}).Return(true, nil).Once()
+	mockAPI.On("KVGet", "subapp.app-id").Return([]byte(`["`+subKey+`"]`), nil).Once()
+
+	err := s.Subscription.Save(toStore)
+	require.NoError(t, err)
+}
+
 func TestSubsKey(t *testing.T) {
 	for name, testcase := range map[string]struct {
 		Subject   apps.Subject
@@ -347,6 +526,60 @@ func TestSubsKey(t *testing.T) {
 			"channel-id",
 			"sub.post_created.channel-id",
 		},
+		string(apps.SubjectPostUpdated): {
+			apps.SubjectPostUpdated,
+			"team-id",
+			"channel-id",
+			"sub.post_updated.channel-id",
+		},
+		string(apps.SubjectPostDeleted): {
+			apps.SubjectPostDeleted,
+			"team-id",
+			"channel-id",
+			"sub.post_deleted.channel-id",
+		},
+		string(apps.SubjectReactionAdded): {
+			apps.SubjectReactionAdded,
+			"team-id",
+			"channel-id",
+			"sub.reaction_added.channel-id",
+		},
+		string(apps.SubjectReactionRemoved): {
+			apps.SubjectReactionRemoved,
+			"team-id",
+			"channel-id",
+			"sub.reaction_removed.channel-id",
+		},
+		string(apps.SubjectUserUpdated): {
+			apps.SubjectUserUpdated,
+			"team-id",
+			"channel-id",
+			"sub.user_updated",
+		},
+		string(apps.SubjectUserDeactivated): {
+			apps.SubjectUserDeactivated,
+			"team-id",
+			"channel-id",
+			"sub.user_deactivated",
+		},
+		string(apps.SubjectChannelUpdated): {
+			apps.SubjectChannelUpdated,
+			"team-id",
+			"channel-id",
+			"sub.channel_updated.channel-id",
+		},
+		string(apps.SubjectChannelArchived): {
+			apps.SubjectChannelArchived,
+			"team-id",
+			"channel-id",
+			"sub.channel_archived.channel-id",
+		},
+		string(apps.SubjectChannelRestored): {
+			apps.SubjectChannelRestored,
+			"team-id",
+			"channel-id",
+			"sub.channel_restored.channel-id",
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			r := subsKey(testcase.Subject, testcase.TeamID, testcase.ChannelID)
@@ -0,0 +1,148 @@
+package store
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+)
+
+// UserKVStore stores the apps' per-user values, keyed by the app's bot and
+// the Mattermost user.
+type UserKVStore interface {
+	// ref can be either a []byte for raw data, or anything else will be JSON
+	// marshaled.
+	Set(botUserID, userID, id string, ref interface{}) error
+	Get(botUserID, userID, id string, ref interface{}) error
+	Delete(botUserID, userID, id string) error
+	// List returns the IDs that the app set for the user, sorted.
+	List(botUserID, userID string) ([]string, error)
+
+	// ListUsers returns the users that the app has values for, sorted.
+	ListUsers(botUserID string) ([]string, error)
+	// DeleteUser deletes all of the app's values for the user.
+	DeleteUser(botUserID, userID string) error
+	// DeleteAll deletes all of the app's values, for all users.
+	DeleteAll(botUserID string) error
+}
+
+type userKVStore struct {
+	*Service
+}
+
+var _ UserKVStore = (*userKVStore)(nil)
+
+func (s *userKVStore) Set(botUserID, userID, id string, ref interface{}) error {
+	key, err := s.userKVKey(botUserID, userID, id)
+	if err != nil {
+		return err
+	}
+	indexKey, err := s.hashkey(config.KVUserKVIndexPrefix, botUserID, "", userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.addToSortedSet(indexKey, id, func() error {
+		return s.addToSortedSet(config.KVUserKVUsersPrefix+botUserID, userID, nil)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to index key %s", id)
+	}
+	_, err = s.setAppValue(botUserID, key, ref, kvExpiry{})
+	return err
+}
+
+func (s *userKVStore) Get(botUserID, userID, id string, ref interface{}) error {
+	key, err := s.userKVKey(botUserID, userID, id)
+	if err != nil {
+		return err
+	}
+	return s.mm.KV.Get(key, ref)
+}
+
+func (s *userKVStore) Delete(botUserID, userID, id string) error {
+	key, err := s.userKVKey(botUserID, userID, id)
+	if err != nil {
+		return err
+	}
+	indexKey, err := s.hashkey(config.KVUserKVIndexPrefix, botUserID, "", userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.setAppValue(botUserID, key, nil, kvExpiry{})
+	if err != nil {
+		return err
+	}
+	empty, err := s.removeFromSortedSet(indexKey, id)
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove key %s from the index", id)
+	}
+	if empty {
+		_, err = s.removeFromSortedSet(config.KVUserKVUsersPrefix+botUserID, userID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *userKVStore) List(botUserID, userID string) ([]string, error) {
+	indexKey, err := s.hashkey(config.KVUserKVIndexPrefix, botUserID, "", userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.getSortedSet(indexKey)
+}
+
+func (s *userKVStore) ListUsers(botUserID string) ([]string, error) {
+	return s.getSortedSet(config.KVUserKVUsersPrefix + botUserID)
+}
+
+func (s *userKVStore) DeleteUser(botUserID, userID string) error {
+	indexKey, err := s.hashkey(config.KVUserKVIndexPrefix, botUserID, "", userID)
+	if err != nil {
+		return err
+	}
+	ids, err := s.getSortedSet(indexKey)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		key, err := s.userKVKey(botUserID, userID, id)
+		if err != nil {
+			return err
+		}
+		_, err = s.setAppValue(botUserID, key, nil, kvExpiry{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete key %s", id)
+		}
+	}
+
+	err = s.mm.KV.Delete(indexKey)
+	if err != nil {
+		return err
+	}
+	_, err = s.removeFromSortedSet(config.KVUserKVUsersPrefix+botUserID, userID)
+	return err
+}
+
+func (s *userKVStore) DeleteAll(botUserID string) error {
+	userIDs, err := s.ListUsers(botUserID)
+	if err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		err = s.DeleteUser(botUserID, userID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete the values for user %s", userID)
+		}
+	}
+	return nil
+}
+
+func (s *userKVStore) userKVKey(botUserID, userID, id string) (string, error) {
+	if userID == "" || id == "" {
+		return "", errors.New("user ID and key must not be empty")
+	}
+	return s.hashkey(config.KVUserKVPrefix, botUserID, "", userID+"/"+id)
+}
@@ -0,0 +1,87 @@
+// +build !e2e
+
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-apps/server/config"
+	"github.com/mattermost/mattermost-plugin-apps/utils"
+)
+
+func TestUserKV(t *testing.T) {
+	botID := "01234567890123456789012345"
+	otherBotID := "99999999999999999999999999"
+	user1 := "user1user1user1user1user1u"
+	user2 := "user2user2user2user2user2u"
+
+	setup := func() (*Service, map[string][]byte) {
+		mockAPI := &plugintest.API{}
+		kv := memKV(mockAPI)
+		apiClient := pluginapi.NewClient(mockAPI, &plugintest.Driver{})
+		return NewService(apiClient, utils.NewTestLogger(), config.NewTestConfigurator(config.Config{}), nil, "", nil), kv
+	}
+
+	t.Run("set get list delete", func(t *testing.T) {
+		s, kv := setup()
+		require.NoError(t, s.UserKV.Set(botID, user1, "b", 2))
+		require.NoError(t, s.UserKV.Set(botID, user1, "a", 1))
+		require.NoError(t, s.UserKV.Set(botID, user2, "a", "other"))
+
+		var v int
+		require.NoError(t, s.UserKV.Get(botID, user1, "a", &v))
+		require.Equal(t, 1, v)
+		ids, err := s.UserKV.List(botID, user1)
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, ids)
+		users, err := s.UserKV.ListUsers(botID)
+		require.NoError(t, err)
+		require.Equal(t, []string{user1, user2}, users)
+
+		require.NoError(t, s.UserKV.Delete(botID, user2, "a"))
+		users, err = s.UserKV.ListUsers(botID)
+		require.NoError(t, err)
+		require.Equal(t, []string{user1}, users)
+
+		require.NoError(t, s.UserKV.Delete(botID, user1, "a"))
+		require.NoError(t, s.UserKV.Delete(botID, user1, "b"))
+		require.Empty(t, kv)
+	})
+
+	t.Run("delete user", func(t *testing.T) {
+		s, _ := setup()
+		require.NoError(t, s.UserKV.Set(botID, user1, "a", 1))
+		require.NoError(t, s.UserKV.Set(botID, user2, "a", 2))
+
+		require.NoError(t, s.UserKV.DeleteUser(botID, user1))
+		ids, err := s.UserKV.List(botID, user1)
+		require.NoError(t, err)
+		require.Empty(t, ids)
+		var v interface{}
+		require.NoError(t, s.UserKV.Get(botID, user1, "a", &v))
+		require.Nil(t, v)
+		users, err := s.UserKV.ListUsers(botID)
+		require.NoError(t, err)
+		require.Equal(t, []string{user2}, users)
+	})
+
+	t.Run("delete all", func(t *testing.T) {
+		s, kv := setup()
+		require.NoError(t, s.UserKV.Set(botID, user1, "a", 1))
+		require.NoError(t, s.UserKV.Set(botID, user2, "a", 2))
+		require.NoError(t, s.UserKV.Set(otherBotID, user1, "a", 3))
+
+		require.NoError(t, s.UserKV.DeleteAll(botID))
+		for k := range kv {
+			require.NotContains(t, k, botID)
+		}
+		var v int
+		require.NoError(t, s.UserKV.Get(otherBotID, user1, "a", &v))
+		require.Equal(t, 3, v)
+	})
+}
@@ -30,19 +30,17 @@ func NewUpstream(app *apps.App, httpOut httpout.Service) *Upstream {
 	staticUp := NewStaticUpstream(&app.Manifest, httpOut)
 	return &Upstream{
 		StaticUpstream: *staticUp,
-		appSecret:      app.Secret,
+		appSecret:      app.JWTSecret(),
 	}
 }
 
 func (u *Upstream) Roundtrip(call *apps.CallRequest, async bool) (io.ReadCloser, error) {
 	if async {
-		go func() {
-			resp, _ := u.invoke(call.Context.BotUserID, call)
-			if resp != nil {
-				resp.Body.Close()
-			}
-		}()
-		return nil, nil
+		resp, err := u.invoke(call.Context.BotUserID, call)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, err
 	}
 
 	resp, err := u.invoke(call.Context.ActingUserID, call) // nolint:bodyclose
@@ -32,13 +32,11 @@ func NewUpstream(app *apps.App, api PluginHTTPAPI) *Upstream {
 
 func (u *Upstream) Roundtrip(call *apps.CallRequest, async bool) (io.ReadCloser, error) {
 	if async {
-		go func() {
-			resp, _ := u.invoke(call.Context.BotUserID, call)
-			if resp != nil {
-				resp.Body.Close()
-			}
-		}()
-		return nil, nil
+		resp, err := u.invoke(call.Context.BotUserID, call)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, err
 	}
 
 	resp, err := u.invoke(call.Context.ActingUserID, call) // nolint:bodyclose
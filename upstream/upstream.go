@@ -10,6 +10,11 @@ import (
 )
 
 // Upstream should be abbreviated as `up`.
+//
+// Roundtrip with async set does not return the response, and may return before
+// the app has processed the call if the upstream supports it (AWS Lambda
+// "Event" invocations). Otherwise it still blocks until the call is sent,
+// running it in the background is up to the caller.
 type Upstream interface {
 	StaticUpstream
 	Roundtrip(call *apps.CallRequest, async bool) (io.ReadCloser, error)
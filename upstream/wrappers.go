@@ -17,6 +17,16 @@ func Notify(u Upstream, call *apps.CallRequest) error {
 	return err
 }
 
+// Deliver sends a notification and waits for the app to receive it, so that a
+// failed delivery can be retried. The response is discarded.
+func Deliver(u Upstream, call *apps.CallRequest) error {
+	r, err := u.Roundtrip(call, false)
+	if r != nil {
+		r.Close()
+	}
+	return err
+}
+
 func Call(u Upstream, call *apps.CallRequest) *apps.CallResponse {
 	r, err := u.Roundtrip(call, false)
 	if err != nil {
@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed cron schedule, in UTC.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+
+	// If both the day of month and the day of week are restricted, a day
+	// matches if either of them does.
+	domRestricted, dowRestricted bool
+}
+
+// cronSearchYears limits how far ahead Next looks for a matching time, for
+// the schedules like "0 0 30 2 *" that never match.
+const cronSearchYears = 5
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronMonthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronDayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// ParseCron parses a standard 5-field cron expression: minute, hour, day of
+// month, month, and day of week. Each field is a "*", or a comma-separated
+// list of values and ranges ("1-5"), optionally with a step ("*/15",
+// "0-30/10"). Months and days of week may be given by their 3-letter English
+// names, Sunday is either 0 or 7. The @yearly, @monthly, @weekly, @daily and
+// @hourly shorthands are also accepted.
+func ParseCron(spec string) (*Cron, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := cronDescriptors[strings.ToLower(spec)]; ok {
+		spec = d
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, NewInvalidError("cron expression %q must have 5 fields", spec)
+	}
+
+	c := &Cron{}
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7, cronDayNames); err != nil {
+		return nil, err
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domRestricted = fields[2] != "*"
+	c.dowRestricted = fields[4] != "*"
+	return c, nil
+}
+
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, NewInvalidError("invalid step in cron field %q", field)
+			}
+			rangePart = part[:i]
+		}
+
+		low, high := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			i := strings.Index(rangePart, "-")
+			var err error
+			if low, err = parseCronValue(rangePart[:i], min, max, names); err != nil {
+				return 0, err
+			}
+			if high, err = parseCronValue(rangePart[i+1:], min, max, names); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, NewInvalidError("invalid range in cron field %q", field)
+			}
+		default:
+			v, err := parseCronValue(rangePart, min, max, names)
+			if err != nil {
+				return 0, err
+			}
+			low = v
+			if !strings.Contains(part, "/") {
+				high = v
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(s string, min, max int, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, NewInvalidError("invalid cron value %q", s)
+	}
+	if v < min || v > max {
+		return 0, NewInvalidError("cron value %v is out of range %v-%v", v, min, max)
+	}
+	return v, nil
+}
+
+// Next returns the first time after t that matches the schedule, or the zero
+// time if there is none in the next few years.
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domRestricted && c.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
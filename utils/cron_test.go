@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronNext(t *testing.T) {
+	// A Wednesday.
+	start := time.Date(2021, 6, 16, 10, 30, 15, 0, time.UTC)
+	for _, tc := range []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2021, 6, 16, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, 6, 16, 10, 45, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2021, 6, 17, 10, 30, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2021, 6, 17, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1,3,5", time.Date(2021, 6, 18, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2021, 6, 20, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 15 * fri", time.Date(2021, 6, 18, 12, 0, 0, 0, time.UTC)},
+		{"0-10/5 11 * * *", time.Date(2021, 6, 16, 11, 0, 0, 0, time.UTC)},
+		{"45/5 10 * * *", time.Date(2021, 6, 16, 10, 45, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2021, 6, 16, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2021, 6, 17, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2021, 6, 20, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	} {
+		t.Run(tc.spec, func(t *testing.T) {
+			c, err := ParseCron(tc.spec)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, c.Next(start))
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, tc := range []struct {
+		spec          string
+		expectedError string
+	}{
+		{"* * * *", `cron expression "* * * *" must have 5 fields: invalid input`},
+		{"60 * * * *", `cron value 60 is out of range 0-59: invalid input`},
+		{"* 24 * * *", `cron value 24 is out of range 0-23: invalid input`},
+		{"* * 0 * *", `cron value 0 is out of range 1-31: invalid input`},
+		{"* * * foo *", `invalid cron value "foo": invalid input`},
+		{"*/0 * * * *", `invalid step in cron field "*/0": invalid input`},
+		{"10-5 * * * *", `invalid range in cron field "10-5": invalid input`},
+		{"@often", `cron expression "@often" must have 5 fields: invalid input`},
+	} {
+		t.Run(tc.spec, func(t *testing.T) {
+			_, err := ParseCron(tc.spec)
+			require.EqualError(t, err, tc.expectedError)
+		})
+	}
+}
@@ -6,6 +6,8 @@ var ErrAlreadyExists = errors.New("already exists")
 var ErrForbidden = errors.New("forbidden")
 var ErrInvalid = errors.New("invalid input")
 var ErrNotFound = errors.New("not found")
+var ErrQuotaExceeded = errors.New("quota exceeded")
+var ErrTooManyRequests = errors.New("too many requests")
 var ErrUnauthorized = errors.New("unauthorized")
 
 func NewError(source error, args ...interface{}) error {
@@ -27,8 +29,10 @@ func NewError(source error, args ...interface{}) error {
 	}
 }
 
-func NewAlreadyExistsError(args ...interface{}) error { return NewError(ErrAlreadyExists, args...) }
-func NewForbiddenError(args ...interface{}) error     { return NewError(ErrForbidden, args...) }
-func NewInvalidError(args ...interface{}) error       { return NewError(ErrInvalid, args...) }
-func NewNotFoundError(args ...interface{}) error      { return NewError(ErrNotFound, args...) }
-func NewUnauthorizedError(args ...interface{}) error  { return NewError(ErrUnauthorized, args...) }
+func NewAlreadyExistsError(args ...interface{}) error   { return NewError(ErrAlreadyExists, args...) }
+func NewForbiddenError(args ...interface{}) error       { return NewError(ErrForbidden, args...) }
+func NewInvalidError(args ...interface{}) error         { return NewError(ErrInvalid, args...) }
+func NewNotFoundError(args ...interface{}) error        { return NewError(ErrNotFound, args...) }
+func NewQuotaExceededError(args ...interface{}) error   { return NewError(ErrQuotaExceeded, args...) }
+func NewTooManyRequestsError(args ...interface{}) error { return NewError(ErrTooManyRequests, args...) }
+func NewUnauthorizedError(args ...interface{}) error    { return NewError(ErrUnauthorized, args...) }
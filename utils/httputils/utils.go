@@ -55,17 +55,27 @@ func WriteError(w http.ResponseWriter, err error) {
 		http.Error(w, "invalid (unknown?) error", http.StatusInternalServerError)
 		return
 	}
+	http.Error(w, err.Error(), ErrorStatus(err))
+}
+
+// ErrorStatus returns the HTTP status code for an error.
+func ErrorStatus(err error) int {
 	switch errors.Cause(err) {
 	case utils.ErrForbidden:
-		http.Error(w, err.Error(), http.StatusForbidden)
+		return http.StatusForbidden
 	case utils.ErrUnauthorized:
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return http.StatusUnauthorized
 	case utils.ErrNotFound:
-		http.Error(w, err.Error(), http.StatusNotFound)
+		return http.StatusNotFound
 	case utils.ErrInvalid:
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		return http.StatusBadRequest
+	case utils.ErrTooManyRequests:
+		return http.StatusTooManyRequests
+	case utils.ErrQuotaExceeded:
+		// The error message says which quota is exceeded.
+		return http.StatusForbidden
 	default:
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return http.StatusInternalServerError
 	}
 }
 
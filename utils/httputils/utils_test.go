@@ -5,9 +5,13 @@
 package httputils
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-apps/utils"
 )
 
 func TestNormalizeRemoteBaseURL(t *testing.T) {
@@ -52,3 +56,10 @@ func TestNormalizeRemoteBaseURL(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteQuotaExceededError(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteError(w, utils.NewQuotaExceededError("app may store at most 10 keys"))
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Equal(t, "app may store at most 10 keys: quota exceeded\n", w.Body.String())
+}